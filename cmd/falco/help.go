@@ -17,6 +17,10 @@ func printHelp(cmd string) {
 		printTestHelp()
 	case subcommandLint:
 		printLintHelp()
+	case subcommandFormat:
+		printFormatHelp()
+	case subcommandLSP:
+		printLSPHelp()
 	default:
 		printGlobalHelp()
 	}
@@ -46,6 +50,8 @@ Subcommands:
     stats     : Analyze VCL statistics
     simulate  : Run simulator server with provided VCLs
     test      : Run local testing for provided VCLs
+    fmt       : Format provided VCLs
+    lsp       : Run language server over stdio
 
 See subcommands help with:
     falco [subcommand] -h
@@ -81,6 +87,7 @@ Flags:
     -v                 : Output lint warnings (verbose)
     -vv                : Output all lint results (very verbose)
     -json              : Output results as JSON (very verbose)
+    --parallel         : Number of services linted concurrently (default: number of CPUs)
 
 Linting with terraform:
     terraform plan -out planned.out
@@ -99,12 +106,18 @@ Flags:
     -r, --remote       : Connect with Fastly API
     -request           : Simulate request config
     -debug             : Enable debug mode
+    --proxy            : Respond the actual response instead of process JSON
     --max_backends     : Override max backends limitation
     --max_acls         : Override max acls limitation
+    --cache-dir        : Persist cache objects to the directory
+    --cache-capacity   : Maximum number of cache objects, least recently used object is evicted
 
 Local simulator example:
     falco simulate -I . /path/to/vcl/main.vcl
 
+Local proxy example:
+    falco simulate -I . --proxy /path/to/vcl/main.vcl
+
 Local debugger example:
     falco simulate -I . -debug /path/to/vcl/main.vcl
 	`))
@@ -141,6 +154,10 @@ Flags:
     -request           : Override request config
     --max_backends     : Override max backends limitation
     --max_acls         : Override max acls limitation
+    --coverage         : Collect VCL code coverage and write lcov and Cobertura XML reports
+    --coverage-dir     : Set directory to write coverage reports (default: coverage)
+    --reporter         : Write test results to the file in the format, one of junit or tap
+    --reporter-file    : Set file path to write test results (default: falco-test.xml or falco-test.tap)
 
 Local testing example:
     falco test -I . -I ./tests /path/to/vcl/main.vcl
@@ -160,8 +177,59 @@ Flags:
     -v                 : Output lint warnings (verbose)
     -vv                : Output all lint results (very verbose)
     -json              : Output results as JSON (very verbose)
+    --format           : Output results in specified format, one of text, json, sarif, checkstyle or github (default: text)
+    --fix              : Apply automatic fixes of lint errors to files
+    --plugin           : Add custom lint rule plugin, "falco-rule-[name]" binary in PATH
+    --cache            : Cache parsed AST and lint result on disk to lint unchanged files faster
+    --lint-cache-dir   : Directory to store lint caches (default: falco/lint in the user cache directory)
+    --baseline         : Suppress lint errors which are recorded in the baseline file
+    --write-baseline   : Record current lint errors to the baseline file
 
 Simple linting with very verbose example:
     falco lint -I . -vv /path/to/vcl/main.vcl
+
+Upload lint results to GitHub code scanning example:
+    falco lint -I . --format sarif /path/to/vcl/main.vcl > falco.sarif
+
+Fix lint errors automatically example:
+    falco lint -I . --fix /path/to/vcl/main.vcl
+
+Report only new lint errors against the baseline example:
+    falco lint -I . -vv --write-baseline .falco-baseline.json /path/to/vcl/main.vcl
+    falco lint -I . -vv --baseline .falco-baseline.json /path/to/vcl/main.vcl
+	`))
+}
+
+func printFormatHelp() {
+	writeln(white, strings.TrimSpace(`
+Usage:
+    falco fmt [flags]
+
+Flags:
+    -I, --include_path : Add include path
+    -h, --help         : Show this help
+    -w, --write        : Overwrite files with formatted result
+    --check            : Print diff and exit with non-zero code if files are not formatted
+
+Print formatted VCL example:
+    falco fmt -I . /path/to/vcl/main.vcl
+
+Check formatting on CI example:
+    falco fmt -I . --check /path/to/vcl/main.vcl
+	`))
+}
+
+func printLSPHelp() {
+	writeln(white, strings.TrimSpace(`
+Usage:
+    falco lsp [flags]
+
+Flags:
+    -I, --include_path : Add include path
+    -h, --help         : Show this help
+
+Language server communicates with the editor over stdio.
+Configure your editor to run the command for VCL files, for example:
+    falco lsp -I /path/to/vcl/modules
 	`))
 }
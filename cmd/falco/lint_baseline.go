@@ -0,0 +1,188 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/ysugimoto/falco/lexer"
+	"github.com/ysugimoto/falco/linter"
+)
+
+const lintBaselineVersion = 1
+
+// LintBaseline records existing lint errors in order to report only new problems.
+// Each error is identified by rule, file and fingerprint of the offending source line
+// instead of line number so that the baseline survives line shifts.
+type LintBaseline struct {
+	Version int              `json:"version"`
+	Errors  []*BaselineError `json:"errors"`
+}
+
+type BaselineError struct {
+	Service     string `json:"service,omitempty"`
+	Rule        string `json:"rule"`
+	File        string `json:"file"`
+	Fingerprint string `json:"fingerprint"`
+	Message     string `json:"message"`
+	Count       int    `json:"count"`
+}
+
+type baselineKey struct {
+	service     string
+	rule        string
+	file        string
+	fingerprint string
+}
+
+func (e *BaselineError) key() baselineKey {
+	return baselineKey{
+		service:     e.Service,
+		rule:        e.Rule,
+		file:        e.File,
+		fingerprint: e.Fingerprint,
+	}
+}
+
+func NewLintBaseline() *LintBaseline {
+	return &LintBaseline{
+		Version: lintBaselineVersion,
+		Errors:  []*BaselineError{},
+	}
+}
+
+func LoadLintBaseline(file string) (*LintBaseline, error) {
+	buf, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to read baseline file: %w", err)
+	}
+	var b LintBaseline
+	if err := json.Unmarshal(buf, &b); err != nil {
+		return nil, fmt.Errorf("Failed to decode baseline file %s: %w", file, err)
+	}
+	if b.Version != lintBaselineVersion {
+		return nil, fmt.Errorf("Unsupported baseline version %d in %s", b.Version, file)
+	}
+	return &b, nil
+}
+
+// Add lint error to the baseline, the same errors on the same source line are counted up
+func (b *LintBaseline) Add(service, fingerprint string, le *linter.LintError) {
+	entry := &BaselineError{
+		Service:     service,
+		Rule:        string(le.Rule),
+		File:        baselineFile(le.Token.File),
+		Fingerprint: fingerprint,
+		Message:     le.Message,
+		Count:       1,
+	}
+	for _, e := range b.Errors {
+		if e.key() == entry.key() {
+			e.Count++
+			return
+		}
+	}
+	b.Errors = append(b.Errors, entry)
+}
+
+// Merge errors of other baseline, used for combining baselines of multiple services
+func (b *LintBaseline) Merge(other *LintBaseline) {
+	for _, e := range other.Errors {
+		copied := *e
+		b.Errors = append(b.Errors, &copied)
+	}
+}
+
+// Returns remaining counts of each baseline error, lint errors are suppressed while the count remains
+func (b *LintBaseline) counts() map[baselineKey]int {
+	counts := make(map[baselineKey]int)
+	for _, e := range b.Errors {
+		counts[e.key()] += e.Count
+	}
+	return counts
+}
+
+// Write baseline to the file, errors are sorted in order to keep the diff of the file small
+func (b *LintBaseline) Write(file string) error {
+	sort.SliceStable(b.Errors, func(i, j int) bool {
+		a, c := b.Errors[i], b.Errors[j]
+		if a.Service != c.Service {
+			return a.Service < c.Service
+		}
+		if a.File != c.File {
+			return a.File < c.File
+		}
+		if a.Rule != c.Rule {
+			return a.Rule < c.Rule
+		}
+		return a.Fingerprint < c.Fingerprint
+	})
+	buf, err := json.MarshalIndent(b, "", "  ")
+	if err != nil {
+		return fmt.Errorf("Failed to encode baseline: %w", err)
+	}
+	if err := os.WriteFile(file, append(buf, '\n'), 0o644); err != nil {
+		return fmt.Errorf("Failed to write baseline file: %w", err)
+	}
+	return nil
+}
+
+// Baseline file is usually committed to the repository and used in other environments like CI,
+// so file path is stored as relative path from the current working directory
+func baselineFile(file string) string {
+	if !filepath.IsAbs(file) {
+		return filepath.ToSlash(file)
+	}
+	wd, err := os.Getwd()
+	if err != nil {
+		return filepath.ToSlash(file)
+	}
+	rel, err := filepath.Rel(wd, file)
+	if err != nil {
+		return filepath.ToSlash(file)
+	}
+	return filepath.ToSlash(rel)
+}
+
+// Fingerprint is a hash of the source line which contains the error token.
+// Whitespaces are normalized so that indentation changes do not affect to the fingerprint.
+func baselineFingerprint(lx *lexer.Lexer, le *linter.LintError) string {
+	var line string
+	if lx != nil {
+		line, _ = lx.GetLine(le.Token.Line)
+	}
+	return hashStrings(string(le.Rule), strings.Join(strings.Fields(line), " "))[:16]
+}
+
+// Write recorded lint errors of all results to the baseline file.
+// Existing lint errors are accepted by writing baseline so it succeeds unless parse error exists.
+func writeLintBaseline(file string, results ...*RunnerResult) error {
+	b := NewLintBaseline()
+	var parseErrors int
+	for _, result := range results {
+		if result == nil {
+			continue
+		}
+		if result.baseline != nil {
+			b.Merge(result.baseline)
+		}
+		parseErrors += len(result.ParseErrors)
+	}
+	if err := b.Write(file); err != nil {
+		writeln(red, err.Error())
+		return ErrExit
+	}
+
+	var count int
+	for _, e := range b.Errors {
+		count += e.Count
+	}
+	writeln(green, "Wrote %d lint errors to baseline file %s", count, file)
+	if parseErrors > 0 {
+		return ErrExit
+	}
+	return nil
+}
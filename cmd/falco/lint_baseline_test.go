@@ -0,0 +1,78 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ysugimoto/falco/config"
+	"github.com/ysugimoto/falco/resolver"
+)
+
+func TestLintBaseline(t *testing.T) {
+	dir := t.TempDir()
+	main := filepath.Join(dir, "main.vcl")
+	baseline := filepath.Join(dir, "baseline.json")
+	writeMain := func(src string) {
+		if err := os.WriteFile(main, []byte(src), 0o644); err != nil {
+			t.Fatalf("Failed to write main.vcl: %s", err)
+		}
+	}
+	run := func(c *config.Config) *RunnerResult {
+		resolvers, err := resolver.NewFileResolvers(main, c.IncludePaths)
+		if err != nil {
+			t.Fatalf("Unexpected resolver creation error: %s", err)
+		}
+		r, err := NewRunner(c, nil)
+		if err != nil {
+			t.Fatalf("Unexpected runner creation error: %s", err)
+		}
+		ret, err := r.Run(resolvers[0])
+		if err != nil {
+			t.Fatalf("Unexpected error running Run(): %s", err)
+		}
+		return ret
+	}
+
+	writeMain(`sub vcl_recv {
+  #FASTLY RECV
+  declare local var.unused STRING;
+  set req.http.X = "1";
+  return(lookup);
+}
+`)
+	ret := run(&config.Config{
+		Linter: &config.LinterConfig{Format: "json", WriteBaseline: baseline},
+	})
+	if ret.Warnings != 1 {
+		t.Fatalf("Expected 1 warning, got %d", ret.Warnings)
+	}
+	if err := ret.baseline.Write(baseline); err != nil {
+		t.Fatalf("Failed to write baseline: %s", err)
+	}
+
+	// Baseline error is still suppressed after line shifts and indentation changes,
+	// and only new problem is reported
+	writeMain(`# Comment
+
+sub vcl_recv {
+  #FASTLY RECV
+    declare   local var.unused STRING;
+  declare local var.unused2 STRING;
+  set req.http.X = "1";
+  return(lookup);
+}
+`)
+	ret = run(&config.Config{
+		Linter: &config.LinterConfig{Format: "json", Baseline: baseline},
+	})
+	if ret.Warnings != 1 {
+		t.Errorf("Expected 1 warning, got %d", ret.Warnings)
+	}
+	if ret.suppressed != 1 {
+		t.Errorf("Expected 1 suppressed error, got %d", ret.suppressed)
+	}
+	if errs := ret.LintErrors[main]; len(errs) != 1 || errs[0].Token.Line != 6 {
+		t.Errorf("Expected new lint error at line 6, got %v", errs)
+	}
+}
@@ -0,0 +1,261 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/gob"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+
+	"github.com/ysugimoto/falco/ast"
+	"github.com/ysugimoto/falco/config"
+	"github.com/ysugimoto/falco/linter"
+	"github.com/ysugimoto/falco/resolver"
+
+	// AST node types are registered to gob in plugin package
+	_ "github.com/ysugimoto/falco/plugin"
+)
+
+// LintCache is an on-disk cache for linting.
+// Parsed AST of each module is stored by its content hash, and lint result of the main VCL is stored
+// with content hashes of all loaded modules in order to reuse it unless any module is changed.
+// Lint result could not be cached per module because linter registers root declarations of all modules
+// to the shared context before linting statements, and unused declarations are checked after the whole program is linted,
+// so lint errors of a module could be changed by another module.
+// All caches are invalidated when falco version or linter configuration is changed.
+type LintCache struct {
+	dir string
+	key string
+}
+
+type lintCacheAST struct {
+	Statements  []ast.Statement
+	EmptySlices []int
+}
+
+type lintCacheResult struct {
+	Modules map[string]string // module name -> content hash
+	Errors  []*linter.LintError
+}
+
+func NewLintCache(c *config.Config) (*LintCache, error) {
+	dir := c.Linter.CacheDir
+	if dir == "" {
+		d, err := os.UserCacheDir()
+		if err != nil {
+			return nil, fmt.Errorf("Failed to get user cache directory: %w", err)
+		}
+		dir = filepath.Join(d, "falco", "lint")
+	}
+	for _, sub := range []string{"ast", "result"} {
+		if err := os.MkdirAll(filepath.Join(dir, sub), 0o755); err != nil {
+			return nil, fmt.Errorf("Failed to create lint cache directory: %w", err)
+		}
+	}
+
+	// Options which do not affect lint result should not invalidate caches
+	lc := *c.Linter
+	lc.Fix = false
+	lc.Format = ""
+	lc.Cache = false
+	lc.CacheDir = ""
+	lc.Parallel = 0
+	lc.Baseline = ""
+	lc.WriteBaseline = ""
+	conf, err := json.Marshal(struct {
+		Linter       config.LinterConfig
+		IncludePaths []string
+	}{lc, c.IncludePaths})
+	if err != nil {
+		return nil, fmt.Errorf("Failed to encode linter configuration: %w", err)
+	}
+
+	return &LintCache{
+		dir: dir,
+		key: hashStrings(buildVersion(), string(conf)),
+	}, nil
+}
+
+// Development build does not have version so use modified time of the executable instead
+func buildVersion() string {
+	if version != "" {
+		return version
+	}
+	bin, err := os.Executable()
+	if err != nil {
+		return ""
+	}
+	stat, err := os.Stat(bin)
+	if err != nil {
+		return ""
+	}
+	return stat.ModTime().String()
+}
+
+func hashStrings(values ...string) string {
+	h := sha256.New()
+	for i := range values {
+		h.Write([]byte(values[i]))
+		h.Write([]byte{0x00})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// Cache is best-effort, failing to read or write cache file is not an error
+func (c *LintCache) read(name string, v interface{}) bool {
+	buf, err := os.ReadFile(filepath.Join(c.dir, name))
+	if err != nil {
+		return false
+	}
+	return gob.NewDecoder(bytes.NewReader(buf)).Decode(v) == nil
+}
+
+func (c *LintCache) write(name string, v interface{}) {
+	buf := new(bytes.Buffer)
+	if err := gob.NewEncoder(buf).Encode(v); err != nil {
+		return
+	}
+	// Write to temporary file and rename it in order not to read incomplete file
+	fp, err := os.CreateTemp(filepath.Join(c.dir, filepath.Dir(name)), ".tmp-*")
+	if err != nil {
+		return
+	}
+	defer os.Remove(fp.Name())
+	if _, err := fp.Write(buf.Bytes()); err != nil {
+		fp.Close()
+		return
+	}
+	fp.Close()
+	os.Rename(fp.Name(), filepath.Join(c.dir, name)) // nolint:errcheck
+}
+
+func (c *LintCache) resultName(rslv resolver.Resolver, main string) string {
+	return filepath.Join("result", hashStrings(c.key, rslv.Name(), main))
+}
+
+// LoadResult returns cached lint errors and contents of loaded modules if all modules are not changed
+func (c *LintCache) LoadResult(rslv resolver.Resolver, main *resolver.VCL) ([]*linter.LintError, map[string]string, bool) {
+	var result lintCacheResult
+	if !c.read(c.resultName(rslv, main.Name), &result) {
+		return nil, nil, false
+	}
+
+	modules := make(map[string]string)
+	for name, hash := range result.Modules {
+		var content string
+		switch {
+		case name == main.Name:
+			content = main.Data
+		case filepath.IsAbs(name):
+			// Module is resolved as absolute path by file resolver
+			buf, err := os.ReadFile(name)
+			if err != nil {
+				return nil, nil, false
+			}
+			content = string(buf)
+		default:
+			module, err := rslv.Resolve(&ast.IncludeStatement{
+				Module: &ast.String{Value: name},
+			})
+			if err != nil {
+				return nil, nil, false
+			}
+			content = module.Data
+		}
+		if hashStrings(content) != hash {
+			return nil, nil, false
+		}
+		modules[name] = content
+	}
+	return result.Errors, modules, true
+}
+
+// Session creates cache session for linting a main VCL
+func (c *LintCache) Session() *lintCacheSession {
+	return &lintCacheSession{
+		cache:   c,
+		modules: make(map[string]string),
+	}
+}
+
+// lintCacheSession implements linter.ParseCache and records modules which are loaded while linting
+type lintCacheSession struct {
+	cache   *LintCache
+	modules map[string]string
+}
+
+func (s *lintCacheSession) astName(file, content string, snippet bool) string {
+	return filepath.Join("ast", hashStrings(s.cache.key, file, content, strconv.FormatBool(snippet)))
+}
+
+func (s *lintCacheSession) Load(file, content string, snippet bool) ([]ast.Statement, bool) {
+	s.modules[file] = hashStrings(content)
+
+	var v lintCacheAST
+	if !s.cache.read(s.astName(file, content, snippet), &v) {
+		return nil, false
+	}
+	var index, empty int
+	walkSlices(reflect.ValueOf(&v.Statements).Elem(), func(slice reflect.Value) {
+		if empty < len(v.EmptySlices) && v.EmptySlices[empty] == index {
+			slice.Set(reflect.MakeSlice(slice.Type(), 0, 0))
+			empty++
+		}
+		index++
+	})
+	return v.Statements, true
+}
+
+// gob encodes both of nil and empty slices as nil so record positions of empty slices,
+// and restore them after decoding in order to keep the same AST as parsed
+func walkSlices(v reflect.Value, fn func(v reflect.Value)) {
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if !v.IsNil() {
+			walkSlices(v.Elem(), fn)
+		}
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			if v.Type().Field(i).IsExported() {
+				walkSlices(v.Field(i), fn)
+			}
+		}
+	case reflect.Slice:
+		fn(v)
+		for i := 0; i < v.Len(); i++ {
+			walkSlices(v.Index(i), fn)
+		}
+	}
+}
+
+func (s *lintCacheSession) Save(file, content string, snippet bool, statements []ast.Statement) {
+	var index int
+	var empty []int
+	walkSlices(reflect.ValueOf(&statements).Elem(), func(slice reflect.Value) {
+		if !slice.IsNil() && slice.Len() == 0 {
+			empty = append(empty, index)
+		}
+		index++
+	})
+	s.cache.write(s.astName(file, content, snippet), &lintCacheAST{
+		Statements:  statements,
+		EmptySlices: empty,
+	})
+}
+
+func (s *lintCacheSession) SaveResult(rslv resolver.Resolver, main string, errs []error) {
+	result := &lintCacheResult{
+		Modules: s.modules,
+	}
+	for i := range errs {
+		if le, ok := errs[i].(*linter.LintError); ok {
+			result.Errors = append(result.Errors, le)
+		}
+	}
+	s.cache.write(s.cache.resultName(rslv, main), result)
+}
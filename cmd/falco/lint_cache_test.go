@@ -0,0 +1,119 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/ysugimoto/falco/config"
+	"github.com/ysugimoto/falco/lexer"
+	"github.com/ysugimoto/falco/parser"
+	"github.com/ysugimoto/falco/resolver"
+)
+
+func TestLintCacheParsedAST(t *testing.T) {
+	buf, err := os.ReadFile("../../examples/linter/default03.vcl")
+	if err != nil {
+		t.Fatalf("Failed to read file: %s", err)
+	}
+	vcl, err := parser.New(lexer.NewFromString(string(buf))).ParseVCL()
+	if err != nil {
+		t.Fatalf("Unexpected parse error: %s", err)
+	}
+
+	cache, err := NewLintCache(&config.Config{
+		Linter: &config.LinterConfig{CacheDir: t.TempDir()},
+	})
+	if err != nil {
+		t.Fatalf("Unexpected cache creation error: %s", err)
+	}
+	session := cache.Session()
+	if _, ok := session.Load("main.vcl", string(buf), false); ok {
+		t.Errorf("Expected cache miss before saving")
+	}
+	session.Save("main.vcl", string(buf), false, vcl.Statements)
+
+	statements, ok := cache.Session().Load("main.vcl", string(buf), false)
+	if !ok {
+		t.Fatalf("Expected cache hit after saving")
+	}
+	if diff := cmp.Diff(vcl.Statements, statements); diff != "" {
+		t.Errorf("Cached AST mismatch, diff=%s", diff)
+	}
+	if _, ok := cache.Session().Load("main.vcl", string(buf), true); ok {
+		t.Errorf("Expected cache miss for snippet parsing")
+	}
+}
+
+func TestRunnerLintCache(t *testing.T) {
+	dir := t.TempDir()
+	main := filepath.Join(dir, "main.vcl")
+	module := filepath.Join(dir, "mod.vcl")
+	if err := os.WriteFile(main, []byte(`include "mod";
+
+sub vcl_recv {
+  #FASTLY RECV
+  call mod_recv;
+  return(lookup);
+}
+`), 0o644); err != nil {
+		t.Fatalf("Failed to write main.vcl: %s", err)
+	}
+	writeModule := func(src string) {
+		if err := os.WriteFile(module, []byte(src), 0o644); err != nil {
+			t.Fatalf("Failed to write mod.vcl: %s", err)
+		}
+	}
+	writeModule(`sub mod_recv {
+  declare local var.unused STRING;
+  set req.http.X = "1";
+}
+`)
+
+	c := &config.Config{
+		Linter: &config.LinterConfig{
+			Format:   "json",
+			Cache:    true,
+			CacheDir: filepath.Join(dir, "cache"),
+		},
+	}
+	run := func() *RunnerResult {
+		resolvers, err := resolver.NewFileResolvers(main, c.IncludePaths)
+		if err != nil {
+			t.Fatalf("Unexpected resolver creation error: %s", err)
+		}
+		r, err := NewRunner(c, nil)
+		if err != nil {
+			t.Fatalf("Unexpected runner creation error: %s", err)
+		}
+		ret, err := r.Run(resolvers[0])
+		if err != nil {
+			t.Fatalf("Unexpected error running Run(): %s", err)
+		}
+		return ret
+	}
+
+	first := run()
+	if first.Warnings != 1 {
+		t.Fatalf("Expected 1 warning, got %d", first.Warnings)
+	}
+	second := run()
+	if diff := cmp.Diff(first.LintErrors, second.LintErrors); diff != "" {
+		t.Errorf("Cached lint result mismatch, diff=%s", diff)
+	}
+	if diff := cmp.Diff(first.Vcl, second.Vcl); diff != "" {
+		t.Errorf("Cached VCL mismatch, diff=%s", diff)
+	}
+
+	// Changing included module invalidates the lint result
+	writeModule(`sub mod_recv {
+  declare local var.unused STRING;
+  declare local var.unused2 STRING;
+  set req.http.X = "1";
+}
+`)
+	if third := run(); third.Warnings != 2 {
+		t.Errorf("Expected 2 warnings after changing module, got %d", third.Warnings)
+	}
+}
@@ -0,0 +1,426 @@
+package main
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/ysugimoto/falco/config"
+	"github.com/ysugimoto/falco/linter"
+	"github.com/ysugimoto/falco/token"
+)
+
+type LintFormat string
+
+const (
+	LintFormatText       LintFormat = "text"
+	LintFormatJSON       LintFormat = "json"
+	LintFormatSARIF      LintFormat = "sarif"
+	LintFormatCheckstyle LintFormat = "checkstyle"
+	LintFormatGitHub     LintFormat = "github"
+)
+
+const (
+	sarifSchema  = "https://json.schemastore.org/sarif-2.1.0.json"
+	sarifVersion = "2.1.0"
+	falcoURL     = "https://github.com/ysugimoto/falco"
+
+	// Rule id for lint errors which do not have specific rule
+	defaultRuleId = "falco"
+	// Rule id for parse errors
+	parseErrorRuleId = "parse-error"
+)
+
+func parseLintFormat(c string) (LintFormat, error) {
+	switch f := LintFormat(strings.ToLower(c)); f {
+	case "":
+		return LintFormatText, nil
+	case LintFormatText, LintFormatJSON, LintFormatSARIF, LintFormatCheckstyle, LintFormatGitHub:
+		return f, nil
+	default:
+		return "", fmt.Errorf("Unsupported lint format: %s, must be one of text, json, sarif, checkstyle or github", c)
+	}
+}
+
+// Get lint format from configuration, -json option is an alias of "--format json"
+func lintFormat(c *config.Config) (LintFormat, error) {
+	if c.Json {
+		return LintFormatJSON, nil
+	}
+	return parseLintFormat(c.Linter.Format)
+}
+
+// reportItem is common representation of lint error and parse error for reporting
+type reportItem struct {
+	File      string
+	Line      int
+	Column    int
+	Length    int
+	Severity  linter.Severity
+	Rule      string
+	Message   string
+	Reference string
+}
+
+// Collect all reportable items which are sorted by file and line
+func (r *Runner) reportItems(result *RunnerResult) []*reportItem {
+	var items []*reportItem
+
+	for _, pe := range result.ParseErrors {
+		items = append(items, newReportItem(pe.Token, linter.ERROR, parseErrorRuleId, pe.Message, ""))
+	}
+	for _, errs := range result.LintErrors {
+		for _, le := range errs {
+			severity := r.severity(le)
+			if severity == linter.IGNORE {
+				continue
+			}
+			rule := string(le.Rule)
+			if rule == "" {
+				rule = defaultRuleId
+			}
+			items = append(items, newReportItem(le.Token, severity, rule, le.Message, le.Reference))
+		}
+	}
+
+	sortReportItems(items)
+	return items
+}
+
+func sortReportItems(items []*reportItem) {
+	sort.SliceStable(items, func(i, j int) bool {
+		if items[i].File != items[j].File {
+			return items[i].File < items[j].File
+		}
+		if items[i].Line != items[j].Line {
+			return items[i].Line < items[j].Line
+		}
+		return items[i].Column < items[j].Column
+	})
+}
+
+// Merge report items of multiple services into single list which is sorted by file and line.
+// Modules which are shared between services report the same item, so duplicated items are removed.
+func mergeReportItems(lists ...[]*reportItem) []*reportItem {
+	var merged []*reportItem
+	seen := make(map[reportItem]struct{})
+	for _, items := range lists {
+		for _, item := range items {
+			if _, ok := seen[*item]; ok {
+				continue
+			}
+			seen[*item] = struct{}{}
+			merged = append(merged, item)
+		}
+	}
+	sortReportItems(merged)
+	return merged
+}
+
+func newReportItem(t token.Token, severity linter.Severity, rule, message, reference string) *reportItem {
+	return &reportItem{
+		File:      relativePath(t.File),
+		Line:      t.Line,
+		Column:    t.Position,
+		Length:    len([]rune(t.Literal)),
+		Severity:  severity,
+		Rule:      rule,
+		Message:   message,
+		Reference: reference,
+	}
+}
+
+// Code scanning tools expect file path which is relative from repository root,
+// so convert to relative path from working directory if possible
+func relativePath(file string) string {
+	if !filepath.IsAbs(file) {
+		return filepath.ToSlash(file)
+	}
+	wd, err := os.Getwd()
+	if err != nil {
+		return filepath.ToSlash(file)
+	}
+	rel, err := filepath.Rel(wd, file)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return filepath.ToSlash(file)
+	}
+	return filepath.ToSlash(rel)
+}
+
+// Report writes lint result to writer in the runner format
+func (r *Runner) Report(w io.Writer, result *RunnerResult) error {
+	switch r.format {
+	case LintFormatJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return errors.WithStack(enc.Encode(result))
+	default:
+		return writeReportItems(w, r.format, r.reportItems(result))
+	}
+}
+
+// Write report items in the format, JSON format is not supported because it reports whole lint result
+func writeReportItems(w io.Writer, format LintFormat, items []*reportItem) error {
+	switch format {
+	case LintFormatSARIF:
+		return reportSARIF(w, items)
+	case LintFormatCheckstyle:
+		return reportCheckstyle(w, items)
+	case LintFormatGitHub:
+		return reportGitHub(w, items)
+	default:
+		// Text format has already been reported while linting
+		return nil
+	}
+}
+
+// SARIF 2.1.0 report, subset which is needed for GitHub code scanning.
+// https://docs.oasis-open.org/sarif/sarif/v2.1.0/sarif-v2.1.0.html
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	InformationURI string      `json:"informationUri"`
+	Version        string      `json:"version,omitempty"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID               string       `json:"id"`
+	ShortDescription sarifMessage `json:"shortDescription"`
+	HelpURI          string       `json:"helpUri,omitempty"`
+}
+
+type sarifResult struct {
+	RuleID    string          `json:"ruleId"`
+	RuleIndex int             `json:"ruleIndex"`
+	Level     string          `json:"level"`
+	Message   sarifMessage    `json:"message"`
+	Locations []sarifLocation `json:"locations"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           *sarifRegion          `json:"region,omitempty"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type sarifRegion struct {
+	StartLine   int `json:"startLine"`
+	StartColumn int `json:"startColumn,omitempty"`
+	EndColumn   int `json:"endColumn,omitempty"`
+}
+
+func reportSARIF(w io.Writer, items []*reportItem) error {
+	driver := sarifDriver{
+		Name:           "falco",
+		InformationURI: falcoURL,
+		Version:        version,
+		Rules:          []sarifRule{},
+	}
+	results := []sarifResult{}
+	ruleIndexes := make(map[string]int)
+
+	for _, item := range items {
+		index, ok := ruleIndexes[item.Rule]
+		if !ok {
+			index = len(driver.Rules)
+			ruleIndexes[item.Rule] = index
+			driver.Rules = append(driver.Rules, sarifRule{
+				ID:               item.Rule,
+				ShortDescription: sarifMessage{Text: item.Rule},
+				HelpURI:          linter.Rule(item.Rule).Reference(),
+			})
+		}
+
+		message := item.Message
+		if item.Reference != "" {
+			message += "\nSee reference documentation: " + item.Reference
+		}
+		location := sarifLocation{
+			PhysicalLocation: sarifPhysicalLocation{
+				ArtifactLocation: sarifArtifactLocation{URI: item.File},
+			},
+		}
+		// Line is zero when error is not related to any token
+		if item.Line > 0 {
+			location.PhysicalLocation.Region = &sarifRegion{
+				StartLine:   item.Line,
+				StartColumn: item.Column,
+			}
+			if item.Length > 0 {
+				location.PhysicalLocation.Region.EndColumn = item.Column + item.Length
+			}
+		}
+
+		results = append(results, sarifResult{
+			RuleID:    item.Rule,
+			RuleIndex: index,
+			Level:     sarifLevel(item.Severity),
+			Message:   sarifMessage{Text: message},
+			Locations: []sarifLocation{location},
+		})
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return errors.WithStack(enc.Encode(sarifLog{
+		Schema:  sarifSchema,
+		Version: sarifVersion,
+		Runs: []sarifRun{
+			{
+				Tool:    sarifTool{Driver: driver},
+				Results: results,
+			},
+		},
+	}))
+}
+
+func sarifLevel(s linter.Severity) string {
+	switch s {
+	case linter.ERROR:
+		return "error"
+	case linter.WARNING:
+		return "warning"
+	default:
+		return "note"
+	}
+}
+
+// Checkstyle XML report which is consumed by Jenkins and so on.
+type checkstyleReport struct {
+	XMLName xml.Name         `xml:"checkstyle"`
+	Version string           `xml:"version,attr"`
+	Files   []checkstyleFile `xml:"file"`
+}
+
+type checkstyleFile struct {
+	Name   string            `xml:"name,attr"`
+	Errors []checkstyleError `xml:"error"`
+}
+
+type checkstyleError struct {
+	Line     int    `xml:"line,attr"`
+	Column   int    `xml:"column,attr,omitempty"`
+	Severity string `xml:"severity,attr"`
+	Message  string `xml:"message,attr"`
+	Source   string `xml:"source,attr"`
+}
+
+func reportCheckstyle(w io.Writer, items []*reportItem) error {
+	report := checkstyleReport{Version: "4.3"}
+
+	for _, item := range items {
+		if n := len(report.Files); n == 0 || report.Files[n-1].Name != item.File {
+			report.Files = append(report.Files, checkstyleFile{Name: item.File})
+		}
+		file := &report.Files[len(report.Files)-1]
+
+		var severity string
+		switch item.Severity {
+		case linter.ERROR:
+			severity = "error"
+		case linter.WARNING:
+			severity = "warning"
+		default:
+			severity = "info"
+		}
+		file.Errors = append(file.Errors, checkstyleError{
+			Line:     item.Line,
+			Column:   item.Column,
+			Severity: severity,
+			Message:  item.Message,
+			Source:   "falco." + item.Rule,
+		})
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return errors.WithStack(err)
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(report); err != nil {
+		return errors.WithStack(err)
+	}
+	_, err := io.WriteString(w, "\n")
+	return errors.WithStack(err)
+}
+
+// GitHub Actions workflow commands which annotate pull request files.
+// https://docs.github.com/en/actions/using-workflows/workflow-commands-for-github-actions
+func reportGitHub(w io.Writer, items []*reportItem) error {
+	for _, item := range items {
+		var command string
+		switch item.Severity {
+		case linter.ERROR:
+			command = "error"
+		case linter.WARNING:
+			command = "warning"
+		default:
+			command = "notice"
+		}
+
+		properties := []string{"file=" + escapeGitHubProperty(item.File)}
+		if item.Line > 0 {
+			properties = append(
+				properties,
+				fmt.Sprintf("line=%d", item.Line),
+				fmt.Sprintf("col=%d", item.Column),
+			)
+			if item.Length > 0 {
+				properties = append(properties, fmt.Sprintf("endColumn=%d", item.Column+item.Length))
+			}
+		}
+		properties = append(properties, "title="+escapeGitHubProperty("falco ("+item.Rule+")"))
+
+		message := item.Message
+		if item.Reference != "" {
+			message += "\nSee reference documentation: " + item.Reference
+		}
+		if _, err := fmt.Fprintf(
+			w, "::%s %s::%s\n",
+			command, strings.Join(properties, ","), escapeGitHubData(message),
+		); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+	return nil
+}
+
+func escapeGitHubData(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A").Replace(s)
+}
+
+func escapeGitHubProperty(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A", ":", "%3A", ",", "%2C").Replace(s)
+}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/ysugimoto/falco/config"
+	"github.com/ysugimoto/falco/resolver"
+)
+
+func runLintWithFormat(t *testing.T, fileName, format string) string {
+	c := &config.Config{
+		Linter: &config.LinterConfig{
+			Format: format,
+		},
+	}
+	resolvers, err := resolver.NewFileResolvers(fileName, c.IncludePaths)
+	if err != nil {
+		t.Fatalf("Unexpected resolver creation error: %s", err)
+	}
+	r, err := NewRunner(c, nil)
+	if err != nil {
+		t.Fatalf("Unexpected runner creation error: %s", err)
+	}
+	result, err := r.Run(resolvers[0])
+	if err != nil {
+		t.Fatalf("Unexpected error running Run(): %s", err)
+	}
+
+	var buf bytes.Buffer
+	if err := r.Report(&buf, result); err != nil {
+		t.Fatalf("Unexpected report error: %s", err)
+	}
+	return buf.String()
+}
+
+// Files outside of working directory are reported as absolute path
+func absPath(t *testing.T, fileName string) string {
+	abs, err := filepath.Abs(fileName)
+	if err != nil {
+		t.Fatalf("Failed to get absolute path: %s", err)
+	}
+	return filepath.ToSlash(abs)
+}
+
+func TestLintFormatSARIF(t *testing.T) {
+	out := runLintWithFormat(t, "../../examples/linter/default03.vcl", "sarif")
+
+	var log sarifLog
+	if err := json.Unmarshal([]byte(out), &log); err != nil {
+		t.Errorf("Failed to unmarshal SARIF output: %s", err)
+		return
+	}
+	if log.Version != "2.1.0" || len(log.Runs) != 1 {
+		t.Errorf("Unexpected SARIF log: %s", out)
+		return
+	}
+
+	run := log.Runs[0]
+	expectRules := []sarifRule{
+		{
+			ID:               "regex/matched-value-override",
+			ShortDescription: sarifMessage{Text: "regex/matched-value-override"},
+		},
+	}
+	if diff := cmp.Diff(expectRules, run.Tool.Driver.Rules); diff != "" {
+		t.Errorf("SARIF rules assertion error, diff=%s", diff)
+	}
+	expectResults := []sarifResult{
+		{
+			RuleID:    "regex/matched-value-override",
+			RuleIndex: 0,
+			Level:     "note",
+			Message:   sarifMessage{Text: "Regex captured variable may override older one"},
+			Locations: []sarifLocation{
+				{
+					PhysicalLocation: sarifPhysicalLocation{
+						ArtifactLocation: sarifArtifactLocation{URI: absPath(t, "../../examples/linter/default03.vcl")},
+						Region:           &sarifRegion{StartLine: 34, StartColumn: 23, EndColumn: 24},
+					},
+				},
+			},
+		},
+	}
+	if diff := cmp.Diff(expectResults, run.Results); diff != "" {
+		t.Errorf("SARIF results assertion error, diff=%s", diff)
+	}
+}
+
+func TestLintFormatCheckstyle(t *testing.T) {
+	out := runLintWithFormat(t, "../../examples/linter/default03.vcl", "checkstyle")
+
+	var report checkstyleReport
+	if err := xml.Unmarshal([]byte(out), &report); err != nil {
+		t.Errorf("Failed to unmarshal checkstyle output: %s", err)
+		return
+	}
+	expect := []checkstyleFile{
+		{
+			Name: absPath(t, "../../examples/linter/default03.vcl"),
+			Errors: []checkstyleError{
+				{
+					Line:     34,
+					Column:   23,
+					Severity: "info",
+					Message:  "Regex captured variable may override older one",
+					Source:   "falco.regex/matched-value-override",
+				},
+			},
+		},
+	}
+	if diff := cmp.Diff(expect, report.Files); diff != "" {
+		t.Errorf("Checkstyle assertion error, diff=%s", diff)
+	}
+}
+
+func TestLintFormatGitHub(t *testing.T) {
+	t.Run("Lint error", func(t *testing.T) {
+		out := runLintWithFormat(t, "../../examples/linter/default03.vcl", "github")
+		expect := "::notice file=" + absPath(t, "../../examples/linter/default03.vcl") + ",line=34,col=23,endColumn=24," +
+			"title=falco (regex/matched-value-override)::Regex captured variable may override older one\n"
+		if diff := cmp.Diff(expect, out); diff != "" {
+			t.Errorf("GitHub annotation assertion error, diff=%s", diff)
+		}
+	})
+
+	t.Run("Parse error", func(t *testing.T) {
+		out := runLintWithFormat(t, "../../examples/linter/default02.vcl", "github")
+		if !strings.HasPrefix(out, "::error file="+absPath(t, "../../examples/linter/default02.vcl")+",line=24,") {
+			t.Errorf("Unexpected GitHub annotation: %s", out)
+		}
+		if !strings.Contains(out, "title=falco (parse-error)::Missing semicolon") {
+			t.Errorf("Unexpected GitHub annotation: %s", out)
+		}
+	})
+
+	t.Run("Escape special characters", func(t *testing.T) {
+		if v := escapeGitHubData("100%\nnext"); v != "100%25%0Anext" {
+			t.Errorf("Unexpected escaped data: %s", v)
+		}
+		if v := escapeGitHubProperty("a:b,c"); v != "a%3Ab%2Cc" {
+			t.Errorf("Unexpected escaped property: %s", v)
+		}
+	})
+}
+
+func TestUnsupportedLintFormat(t *testing.T) {
+	_, err := NewRunner(&config.Config{
+		Linter: &config.LinterConfig{Format: "unknown"},
+	}, nil)
+	if err == nil {
+		t.Errorf("Expected error for unsupported format but got nil")
+	}
+}
@@ -2,6 +2,7 @@ package main
 
 import (
 	"fmt"
+	"io"
 	"math"
 	"os"
 	"path/filepath"
@@ -15,8 +16,11 @@ import (
 	"github.com/mattn/go-colorable"
 	"github.com/pkg/errors"
 	"github.com/ysugimoto/falco/config"
+	"github.com/ysugimoto/falco/formatter"
+	"github.com/ysugimoto/falco/interpreter/coverage"
 	ife "github.com/ysugimoto/falco/interpreter/function/errors"
 	"github.com/ysugimoto/falco/lexer"
+	"github.com/ysugimoto/falco/lsp"
 	"github.com/ysugimoto/falco/remote"
 	"github.com/ysugimoto/falco/resolver"
 	"github.com/ysugimoto/falco/snippets"
@@ -51,16 +55,22 @@ const (
 	subcommandSimulate  = "simulate"
 	subcommandStats     = "stats"
 	subcommandTest      = "test"
+	subcommandFormat    = "fmt"
+	subcommandLSP       = "lsp"
 )
 
 func write(c *color.Color, format string, args ...interface{}) {
-	c.Fprint(output, emoji.Sprintf(format, args...))
+	fprint(output, c, format, args...)
 }
 
 func writeln(c *color.Color, format string, args ...interface{}) {
 	write(c, format+"\n", args...)
 }
 
+func fprint(w io.Writer, c *color.Color, format string, args ...interface{}) {
+	c.Fprint(w, emoji.Sprintf(format, args...))
+}
+
 func main() {
 	c, err := config.New(os.Args[1:])
 	if err != nil {
@@ -87,11 +97,19 @@ func main() {
 			fetcher = terraform.NewTerraformFetcher(fastlyServices)
 		}
 		action = c.Commands.At(1)
-	case subcommandSimulate, subcommandLint, subcommandStats, subcommandTest:
-		// "lint", "simulate", "stats" and "test" command provides single file of service,
+	case subcommandSimulate, subcommandLint, subcommandStats, subcommandTest, subcommandFormat:
+		// "lint", "simulate", "stats", "test" and "fmt" command provides single file of service,
 		// then resolvers size is always 1
 		resolvers, err = resolver.NewFileResolvers(c.Commands.At(1), c.IncludePaths)
 		action = c.Commands.At(0)
+	case subcommandLSP:
+		// Language server communicates with the editor over stdio,
+		// and resolves VCL files for each opened document
+		if err := lsp.New(c, version).Serve(os.Stdin, os.Stdout); err != nil {
+			writeln(red, err.Error())
+			os.Exit(1)
+		}
+		os.Exit(0)
 	case "":
 		printHelp("")
 		os.Exit(1)
@@ -127,6 +145,14 @@ func main() {
 		os.Exit(1)
 	}
 
+	// Multiple services in terraform planned input are linted concurrently
+	if len(resolvers) > 1 && isLintAction(action) {
+		if err := runParallelLint(c, resolvers, fetcher); err != nil {
+			os.Exit(1)
+		}
+		return
+	}
+
 	var shouldExit bool
 	for _, v := range resolvers {
 		if name := v.Name(); name != "" {
@@ -154,8 +180,16 @@ func main() {
 			exitErr = runSimulate(runner, v)
 		case subcommandStats:
 			exitErr = runStats(runner, v)
+		case subcommandFormat:
+			exitErr = runFormat(runner, v)
 		default:
-			exitErr = runLint(runner, v)
+			var result *RunnerResult
+			result, exitErr = runLintWithFix(runner, v, func() (*Runner, error) {
+				return NewRunner(c, fetcher)
+			}, os.Stdout)
+			if c.Linter.WriteBaseline != "" && result != nil {
+				exitErr = writeLintBaseline(c.Linter.WriteBaseline, result)
+			}
 		}
 
 		if exitErr == ErrExit {
@@ -168,58 +202,118 @@ func main() {
 	}
 }
 
-func runLint(runner *Runner, rslv resolver.Resolver) error {
+func isLintAction(action string) bool {
+	switch action {
+	case subcommandTest, subcommandSimulate, subcommandStats, subcommandFormat:
+		return false
+	default:
+		return true
+	}
+}
+
+// Apply automatic fixes at first if enabled, and then lint fixed VCL with the fresh runner
+func runLintWithFix(
+	runner *Runner,
+	rslv resolver.Resolver,
+	newRunner func() (*Runner, error),
+	w io.Writer,
+) (*RunnerResult, error) {
+
+	if runner.config.Linter.Fix {
+		if err := runFix(runner, rslv); err != nil {
+			return nil, err
+		}
+		fresh, err := newRunner()
+		if err != nil {
+			runner.writeln(red, err.Error())
+			return nil, ErrExit
+		}
+		runner = fresh
+	}
+	return runLint(runner, rslv, w)
+}
+
+// Lint and display the result, machine readable report is written to w unless it is nil
+func runLint(runner *Runner, rslv resolver.Resolver, w io.Writer) (*RunnerResult, error) {
 	result, err := runner.Run(rslv)
 	if err != nil {
 		if err != ErrParser {
-			writeln(red, err.Error())
+			runner.writeln(red, err.Error())
 		}
-		return ErrExit
+		return nil, ErrExit
 	}
 
-	if runner.config.Json {
-		enc := json.NewEncoder(os.Stdout)
-		enc.SetIndent("", "  ")
-		if err := enc.Encode(result); err != nil {
-			writeln(red, err.Error())
-			return ErrExit
+	// Output result for machine readable format like JSON, SARIF and so on
+	if w != nil {
+		if err := runner.Report(w, result); err != nil {
+			runner.writeln(red, err.Error())
+			return result, ErrExit
 		}
 	}
 
-	write(red, ":fire:%d errors, ", result.Errors)
-	write(yellow, ":exclamation:%d warnings, ", result.Warnings)
-	writeln(cyan, ":speaker:%d recommendations.", result.Infos)
+	runner.write(red, ":fire:%d errors, ", result.Errors)
+	runner.write(yellow, ":exclamation:%d warnings, ", result.Warnings)
+	runner.writeln(cyan, ":speaker:%d recommendations.", result.Infos)
+	if result.suppressed > 0 {
+		runner.writeln(white, "%d lint errors are suppressed by the baseline.", result.suppressed)
+	}
 
 	// Display message corresponds to runner result
-	if result.Errors == 0 {
+	if result.Errors == 0 && len(result.ParseErrors) == 0 {
 		switch {
 		case result.Warnings > 0:
-			writeln(white, "VCL lint warnings encountered, but things should run OK :thumbsup:")
+			runner.writeln(white, "VCL lint warnings encountered, but things should run OK :thumbsup:")
 			if runner.level < LevelWarning {
-				writeln(white, "Run command with the -v option to output warnings.")
+				runner.writeln(white, "Run command with the -v option to output warnings.")
 			}
 		case result.Infos > 0:
-			writeln(green, "VCL looks good :sparkles: Some recommendations are available :thumbsup:")
+			runner.writeln(green, "VCL looks good :sparkles: Some recommendations are available :thumbsup:")
 			if runner.level < LevelInfo {
-				writeln(white, "Run command with the -vv option to output recommendations.")
+				runner.writeln(white, "Run command with the -vv option to output recommendations.")
 			}
 		default:
-			writeln(green, "VCL looks great :sparkles:")
+			runner.writeln(green, "VCL looks great :sparkles:")
 		}
 	}
 
-	// if lint error is not zero, stop process
-	if result.Errors > 0 {
+	// if lint error or parse error is not zero, stop process
+	if result.Errors > 0 || len(result.ParseErrors) > 0 {
 		if len(runner.transformers) > 0 {
-			writeln(white, "Program aborted. Please fix lint errors before transforming.")
+			runner.writeln(white, "Program aborted. Please fix lint errors before transforming.")
 		}
-		return ErrExit
+		return result, ErrExit
 	}
 
 	if err := runner.Transform(result.Vcl); err != nil {
-		writeln(red, err.Error())
+		runner.writeln(red, err.Error())
+		return result, ErrExit
+	}
+	return result, nil
+}
+
+func runFix(runner *Runner, rslv resolver.Resolver) error {
+	results, err := runner.Fix(rslv)
+	if err != nil {
+		if err != ErrParser {
+			runner.writeln(red, err.Error())
+		}
 		return ErrExit
 	}
+
+	for _, r := range results {
+		stat, err := os.Stat(r.File)
+		if err != nil {
+			runner.writeln(red, err.Error())
+			return ErrExit
+		}
+		if err := os.WriteFile(r.File, []byte(r.Fixed), stat.Mode().Perm()); err != nil {
+			runner.writeln(red, "Failed to write fixed file %s: %s", r.File, err.Error())
+			return ErrExit
+		}
+		for _, fix := range r.Fixes {
+			runner.message(green, ":wrench: %s in %s at line %d\n", fix.Message, r.File, fix.Edits[0].Token.Line)
+		}
+	}
 	return nil
 }
 
@@ -275,21 +369,98 @@ func runStats(runner *Runner, rslv resolver.Resolver) error {
 	return nil
 }
 
+func runFormat(runner *Runner, rslv resolver.Resolver) error {
+	results, err := runner.Format(rslv)
+	if err != nil {
+		if err != ErrParser {
+			writeln(red, err.Error())
+		}
+		return ErrExit
+	}
+
+	fc := runner.config.Format
+	switch {
+	// Check mode: print unified diff and exit with non-zero code if some files are not formatted
+	case fc.Check:
+		var unformatted int
+		for _, r := range results {
+			if !r.IsChanged() {
+				continue
+			}
+			unformatted++
+			fmt.Fprint(os.Stdout, formatter.Diff(r.File, r.Original, r.Formatted))
+		}
+		if unformatted > 0 {
+			writeln(red, "%d file(s) are not formatted", unformatted)
+			return ErrExit
+		}
+		writeln(green, "All files are formatted :sparkles:")
+	// Overwrite mode: write formatted result to each file
+	case fc.Overwrite:
+		for _, r := range results {
+			if !r.IsChanged() {
+				continue
+			}
+			stat, err := os.Stat(r.File)
+			if err != nil {
+				writeln(red, err.Error())
+				return ErrExit
+			}
+			if err := os.WriteFile(r.File, []byte(r.Formatted), stat.Mode().Perm()); err != nil {
+				writeln(red, "Failed to write formatted file %s: %s", r.File, err.Error())
+				return ErrExit
+			}
+			writeln(white, "Formatted %s", r.File)
+		}
+	// Otherwise, print formatted results to stdout
+	default:
+		for _, r := range results {
+			if len(results) > 1 {
+				fmt.Fprintf(os.Stdout, "==> %s <==\n", r.File)
+			}
+			fmt.Fprint(os.Stdout, r.Formatted)
+		}
+	}
+	return nil
+}
+
 func runTest(runner *Runner, rslv resolver.Resolver) error {
+	// Validate reporter before running tests
+	if _, err := parseTestReporter(runner.config.Testing.Reporter); err != nil {
+		writeln(red, err.Error())
+		return ErrExit
+	}
+
 	factory, err := runner.Test(rslv)
 	if err != nil {
 		return ErrExit
 	}
 
+	var coverageFiles []string
+	if factory.Coverage != nil {
+		coverageFiles, err = writeCoverageReports(runner.config.Testing.CoverageDir, factory.Coverage)
+		if err != nil {
+			writeln(red, "Failed to write coverage reports: %s", err.Error())
+			return ErrExit
+		}
+	}
+	reportFile, err := writeTestReport(runner.config.Testing, factory)
+	if err != nil {
+		writeln(red, "Failed to write test report: %s", err.Error())
+		return ErrExit
+	}
+
 	if runner.config.Json {
 		enc := json.NewEncoder(os.Stdout)
 		enc.SetIndent("", "  ")
 		if err := enc.Encode(struct {
-			Tests   []*tester.TestResult `json:"tests"`
-			Summary *tester.TestCounter  `json:"summary"`
+			Tests    []*tester.TestResult `json:"tests"`
+			Summary  *tester.TestCounter  `json:"summary"`
+			Coverage *coverage.Report     `json:"coverage,omitempty"`
 		}{
-			Tests:   factory.Results,
-			Summary: factory.Statistics,
+			Tests:    factory.Results,
+			Summary:  factory.Statistics,
+			Coverage: factory.Coverage,
 		}); err != nil {
 			writeln(red, err.Error())
 			return ErrExit
@@ -338,9 +509,16 @@ func runTest(runner *Runner, rslv resolver.Resolver) error {
 		for _, c := range r.Cases {
 			totalCount++
 			if c.Error != nil {
-				writeln(redBold, "%s●  [%s] %s\n", indent(1), c.Scope, c.Name)
-				writeln(red, "%s%s", indent(2), c.Error.Error())
-				switch e := c.Error.(type) {
+				err := c.Error
+				// Failure in the hook subroutine is displayed distinctly from the test subroutine's one
+				if he, ok := err.(*tester.HookError); ok {
+					writeln(redBold, "%s●  [%s] %s (%s hook %s is failed)\n", indent(1), c.Scope, c.Name, he.Type, he.Name)
+					err = he.Err
+				} else {
+					writeln(redBold, "%s●  [%s] %s\n", indent(1), c.Scope, c.Name)
+				}
+				writeln(red, "%s%s", indent(2), err.Error())
+				switch e := err.(type) {
 				case *ife.AssertionError:
 					write(white, "%sActual Value: ", indent(2))
 					writeln(red, "%s\n", e.Actual.String())
@@ -371,6 +549,16 @@ func runTest(runner *Runner, rslv resolver.Resolver) error {
 	write(white, "%d total, ", totalCount)
 	writeln(white, "%d assertions", factory.Statistics.Asserts)
 
+	if factory.Coverage != nil {
+		printCoverageSummary(factory.Coverage)
+		for _, file := range coverageFiles {
+			writeln(white, "Coverage report is written to %s", file)
+		}
+	}
+	if reportFile != "" {
+		writeln(white, "Test report is written to %s", reportFile)
+	}
+
 	if factory.Statistics.Fails > 0 {
 		return ErrExit
 	} else {
@@ -0,0 +1,188 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"os"
+	"runtime"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/ysugimoto/falco/config"
+	"github.com/ysugimoto/falco/resolver"
+	"github.com/ysugimoto/falco/snippets"
+	"github.com/ysugimoto/falco/terraform"
+)
+
+// serviceLintResult holds buffered output and lint result of a service
+type serviceLintResult struct {
+	name   string
+	stderr bytes.Buffer
+	result *RunnerResult
+	items  []*reportItem
+	err    error
+}
+
+// Lint multiple services concurrently with worker pool.
+// Output of each service is buffered and displayed atomically in the order of services,
+// and then combined report and summary are displayed.
+func runParallelLint(c *config.Config, resolvers []resolver.Resolver, fetcher snippets.Fetcher) error {
+	format, err := lintFormat(c)
+	if err != nil {
+		writeln(red, err.Error())
+		return ErrExit
+	}
+
+	workers := c.Linter.Parallel
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+	workers = min(workers, len(resolvers))
+
+	results := make([]*serviceLintResult, len(resolvers))
+	done := make([]chan struct{}, len(resolvers))
+	for i := range done {
+		done[i] = make(chan struct{})
+	}
+	jobs := make(chan int)
+	for i := 0; i < workers; i++ {
+		go func() {
+			for index := range jobs {
+				results[index] = lintService(c, resolvers[index], fetcher, format)
+				close(done[index])
+			}
+		}()
+	}
+	go func() {
+		for i := range resolvers {
+			jobs <- i
+		}
+		close(jobs)
+	}()
+
+	var exitErr error
+	for i := range resolvers {
+		<-done[i]
+		ret := results[i]
+		output.Write(ret.stderr.Bytes()) // nolint:errcheck
+		if ret.err != nil {
+			exitErr = ErrExit
+		}
+	}
+
+	if err := writeParallelLintReport(os.Stdout, format, results); err != nil {
+		writeln(red, err.Error())
+		return ErrExit
+	}
+	printLintSummary(output, results)
+
+	// Baseline of all services is written to the single file
+	if file := c.Linter.WriteBaseline; file != "" {
+		var linted []*RunnerResult
+		var failed bool
+		for _, ret := range results {
+			// Service which could not be linted is not recorded, then fails
+			if ret.result == nil {
+				failed = true
+				continue
+			}
+			linted = append(linted, ret.result)
+		}
+		if err := writeLintBaseline(file, linted...); err != nil || failed {
+			return ErrExit
+		}
+		return nil
+	}
+
+	return exitErr
+}
+
+func lintService(
+	c *config.Config,
+	rslv resolver.Resolver,
+	fetcher snippets.Fetcher,
+	format LintFormat,
+) *serviceLintResult {
+
+	ret := &serviceLintResult{
+		name: rslv.Name(),
+	}
+	fprint(&ret.stderr, white, "Lint service of \"%s\"\n", ret.name)
+	fprint(&ret.stderr, white, strings.Repeat("=", 18+len(ret.name))+"\n")
+
+	// Terraform fetcher filters resources by service name so each service needs its own fetcher
+	if t, ok := fetcher.(*terraform.TerraformFetcher); ok {
+		fetcher = t.Service(ret.name)
+	}
+	create := func() (*Runner, error) {
+		return newRunner(c, fetcher, &ret.stderr)
+	}
+
+	runner, err := create()
+	if err != nil {
+		fprint(&ret.stderr, red, err.Error()+"\n")
+		ret.err = ErrExit
+		return ret
+	}
+
+	// Machine readable report is written after all services are linted in order to output single document
+	ret.result, ret.err = runLintWithFix(runner, rslv, create, nil)
+	if ret.result != nil && format != LintFormatText {
+		ret.items = runner.reportItems(ret.result)
+	}
+	return ret
+}
+
+// Write single report document of all services in the machine readable format.
+// JSON format outputs the document which is keyed by service name,
+// and other formats output the report of merged lint errors.
+func writeParallelLintReport(w io.Writer, format LintFormat, results []*serviceLintResult) error {
+	switch format {
+	case LintFormatText:
+		return nil
+	case LintFormatJSON:
+		combined := make(map[string]*RunnerResult)
+		for _, ret := range results {
+			combined[ret.name] = ret.result
+		}
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return errors.WithStack(enc.Encode(combined))
+	default:
+		lists := make([][]*reportItem, 0, len(results))
+		for _, ret := range results {
+			lists = append(lists, ret.items)
+		}
+		return writeReportItems(w, format, mergeReportItems(lists...))
+	}
+}
+
+func printLintSummary(w io.Writer, results []*serviceLintResult) {
+	width := len("Service")
+	for _, ret := range results {
+		width = max(width, len(ret.name))
+	}
+	line := strings.Repeat("-", width+37)
+
+	fprint(w, white, "\n%s\n", line)
+	fprint(w, white, "| %-*s | %8s | %8s | %8s |\n", width, "Service", "Errors", "Warnings", "Infos")
+	fprint(w, white, "%s\n", line)
+	for _, ret := range results {
+		// Result is not present when the service could not be linted like parse error
+		if ret.result == nil {
+			fprint(w, red, "| %-*s | %8s | %8s | %8s |\n", width, ret.name, "-", "-", "-")
+			continue
+		}
+		errCount := ret.result.Errors + len(ret.result.ParseErrors)
+		c := green
+		switch {
+		case errCount > 0:
+			c = red
+		case ret.result.Warnings > 0:
+			c = yellow
+		}
+		fprint(w, c, "| %-*s | %8d | %8d | %8d |\n", width, ret.name, errCount, ret.result.Warnings, ret.result.Infos)
+	}
+	fprint(w, white, "%s\n", line)
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/fatih/color"
+	"github.com/ysugimoto/falco/config"
+)
+
+func TestParallelLint(t *testing.T) {
+	rslv, f := loadFromTfJson("../../terraform/data/terraform-multiple-services.json", t)
+	c := &config.Config{
+		Linter: &config.LinterConfig{},
+	}
+
+	results := make([]*serviceLintResult, len(rslv))
+	var wg sync.WaitGroup
+	for i := range rslv {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i] = lintService(c, rslv[i], f, LintFormatText)
+		}(i)
+	}
+	wg.Wait()
+
+	expects := map[string]int{
+		"service-a": 0,
+		"service-b": 2,
+		"service-c": 0,
+	}
+	for _, ret := range results {
+		if ret.result == nil {
+			t.Errorf("Service %s must have lint result", ret.name)
+			continue
+		}
+		if ret.result.Errors != expects[ret.name] {
+			t.Errorf("Service %s expects %d errors, got %d", ret.name, expects[ret.name], ret.result.Errors)
+		}
+		// Output is buffered for each service
+		stderr := ret.stderr.String()
+		if !strings.HasPrefix(stderr, `Lint service of "`+ret.name+`"`) {
+			t.Errorf("Unexpected output of service %s: %s", ret.name, stderr)
+		}
+		if ret.name != "service-b" && strings.Contains(stderr, "req.foo") {
+			t.Errorf("Service %s output contains other service lint errors: %s", ret.name, stderr)
+		}
+	}
+
+	color.NoColor = true
+	var buf bytes.Buffer
+	printLintSummary(&buf, results)
+	if !strings.Contains(buf.String(), "| service-b |        2 |        3 |        0 |") {
+		t.Errorf("Unexpected summary: %s", buf.String())
+	}
+}
+
+func TestParallelLintReport(t *testing.T) {
+	rslv, f := loadFromTfJson("../../terraform/data/terraform-multiple-services.json", t)
+	lint := func(format LintFormat) []*serviceLintResult {
+		c := &config.Config{
+			Linter: &config.LinterConfig{
+				Format: string(format),
+			},
+		}
+		results := make([]*serviceLintResult, len(rslv))
+		for i := range rslv {
+			results[i] = lintService(c, rslv[i], f, format)
+		}
+		return results
+	}
+
+	t.Run("sarif", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := writeParallelLintReport(&buf, LintFormatSARIF, lint(LintFormatSARIF)); err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		// Output must be single SARIF document
+		var log sarifLog
+		if err := json.Unmarshal(buf.Bytes(), &log); err != nil {
+			t.Fatalf("Failed to unmarshal SARIF output: %s", err)
+		}
+		if len(log.Runs) != 1 {
+			t.Fatalf("Expected single run, got %d", len(log.Runs))
+		}
+		var errors int
+		for _, r := range log.Runs[0].Results {
+			if r.Level == "error" {
+				errors++
+			}
+		}
+		if errors != 2 {
+			t.Errorf("Expected 2 errors of service-b, got %d", errors)
+		}
+	})
+
+	t.Run("checkstyle", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := writeParallelLintReport(&buf, LintFormatCheckstyle, lint(LintFormatCheckstyle)); err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		if n := strings.Count(buf.String(), "<checkstyle"); n != 1 {
+			t.Fatalf("Expected single checkstyle root, got %d", n)
+		}
+		var report checkstyleReport
+		if err := xml.Unmarshal(buf.Bytes(), &report); err != nil {
+			t.Fatalf("Failed to unmarshal checkstyle output: %s", err)
+		}
+		var errors int
+		for _, f := range report.Files {
+			for _, e := range f.Errors {
+				if e.Severity == "error" {
+					errors++
+				}
+			}
+		}
+		if errors != 2 {
+			t.Errorf("Expected 2 errors of service-b, got %d", errors)
+		}
+	})
+}
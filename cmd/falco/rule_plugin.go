@@ -0,0 +1,128 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/ysugimoto/falco/linter"
+	"github.com/ysugimoto/falco/plugin"
+)
+
+const rulePluginPrefix = "falco-rule-"
+
+// RulePlugin is custom lint rule which is provided as independent binary, named "falco-rule-[name]"
+type RulePlugin struct {
+	name    string
+	command string
+	bin     string
+	output  io.Writer
+}
+
+func NewRulePlugin(name string) (*RulePlugin, error) {
+	command := rulePluginPrefix + name
+	bin, err := exec.LookPath(command)
+	if err != nil {
+		return nil, fmt.Errorf(`Rule plugin command "%s" does not exist in PATH`, command)
+	}
+	return &RulePlugin{
+		name:    name,
+		command: command,
+		bin:     bin,
+		output:  output,
+	}, nil
+}
+
+// Find all "falco-rule-[name]" executables in the directory
+func FindRulePlugins(dir string) ([]*RulePlugin, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to read rule plugin directory %s: %w", dir, err)
+	}
+
+	var plugins []*RulePlugin
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasPrefix(entry.Name(), rulePluginPrefix) {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return nil, err
+		}
+		// Skip non-executable file
+		if info.Mode().Perm()&0o111 == 0 {
+			continue
+		}
+		bin, err := filepath.Abs(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		plugins = append(plugins, &RulePlugin{
+			name:    strings.TrimPrefix(entry.Name(), rulePluginPrefix),
+			command: entry.Name(),
+			bin:     bin,
+			output:  output,
+		})
+	}
+	return plugins, nil
+}
+
+// Execute sends encoded VCL and context summary to the plugin and receives lint errors
+func (p *RulePlugin) Execute(input []byte) ([]*linter.LintError, error) {
+	var stdout bytes.Buffer
+	cmd := exec.Command(p.bin)
+	cmd.Stdin = bytes.NewReader(input)
+	cmd.Stdout = &stdout
+	cmd.Stderr = p
+
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("Failed to execute %s rule plugin: %w", p.command, err)
+	}
+	output, err := plugin.DecodeRuleOutput(&stdout)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to decode %s rule plugin output: %w", p.command, err)
+	}
+
+	errs := make([]*linter.LintError, len(output.Errors))
+	for i, e := range output.Errors {
+		errs[i] = p.lintError(e)
+	}
+	return errs, nil
+}
+
+func (p *RulePlugin) lintError(e *plugin.LintError) *linter.LintError {
+	// Rule name participates in the rule severity overrides
+	rule := e.Rule
+	if rule == "" {
+		rule = p.name
+	}
+
+	var severity linter.Severity
+	switch strings.ToUpper(e.Severity) {
+	case "WARNING":
+		severity = linter.WARNING
+	case "INFO":
+		severity = linter.INFO
+	default:
+		severity = linter.ERROR
+	}
+
+	return &linter.LintError{
+		Severity:  severity,
+		Token:     e.Token,
+		Message:   e.Message,
+		Reference: e.Reference,
+		Rule:      linter.Rule(rule),
+	}
+}
+
+func (p *RulePlugin) Write(v []byte) (int, error) {
+	fprint(p.output, magenta, "["+p.command+"] ")
+	fprint(p.output, white, string(v))
+
+	return len(v), nil
+}
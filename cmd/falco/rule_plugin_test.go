@@ -0,0 +1,189 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ysugimoto/falco/ast"
+	"github.com/ysugimoto/falco/config"
+	"github.com/ysugimoto/falco/linter"
+	"github.com/ysugimoto/falco/plugin"
+	"github.com/ysugimoto/falco/resolver"
+)
+
+const rulePluginHelperEnv = "FALCO_RULE_PLUGIN_HELPER"
+
+// The test binary behaves as rule plugin when it is executed from the runner
+func TestMain(m *testing.M) {
+	if os.Getenv(rulePluginHelperEnv) == "1" {
+		if err := runHelperRulePlugin(); err != nil {
+			os.Stderr.WriteString(err.Error())
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+// Report backends which do not have .first_byte_timeout property
+func runHelperRulePlugin() error {
+	input, err := plugin.DecodeRuleInput(os.Stdin)
+	if err != nil {
+		return err
+	}
+
+	var errs []*plugin.LintError
+	for _, stmt := range input.VCL.AST.Statements {
+		backend, ok := stmt.(*ast.BackendDeclaration)
+		if !ok {
+			continue
+		}
+		var found bool
+		for _, p := range backend.Properties {
+			if p.Key.Value == "first_byte_timeout" {
+				found = true
+			}
+		}
+		if !found {
+			errs = append(errs, &plugin.LintError{
+				Rule:     "backend/first-byte-timeout",
+				Severity: "WARNING",
+				Message:  "Backend " + backend.Name.Value + " must set .first_byte_timeout",
+				Token:    backend.GetMeta().Token,
+			})
+		}
+	}
+	// Context summary is also sent
+	if len(input.Context.Backends) != 3 {
+		errs = append(errs, &plugin.LintError{Message: "Context summary should have 3 backends"})
+	}
+	return plugin.WriteRuleOutput(os.Stdout, errs)
+}
+
+func TestRulePlugin(t *testing.T) {
+	dir := t.TempDir()
+	main := filepath.Join(dir, "main.vcl")
+	if err := os.WriteFile(main, []byte(`backend F_origin {
+  .host = "example.com";
+  .first_byte_timeout = 15s;
+}
+
+backend F_slow {
+  .host = "example.com";
+}
+
+# Plugin error is also ignored
+# falco-ignore-next-line
+backend F_ignored {
+  .host = "example.com";
+}
+
+sub vcl_recv {
+  #FASTLY RECV
+  if (req.http.Slow) {
+    set req.backend = F_slow;
+  } else if (req.http.Ignored) {
+    set req.backend = F_ignored;
+  } else {
+    set req.backend = F_origin;
+  }
+  return(lookup);
+}
+`), 0o644); err != nil {
+		t.Fatalf("Failed to write main.vcl: %s", err)
+	}
+
+	pluginDir := filepath.Join(dir, "plugins")
+	if err := os.Mkdir(pluginDir, 0o755); err != nil {
+		t.Fatalf("Failed to create plugin directory: %s", err)
+	}
+	bin, err := os.Executable()
+	if err != nil {
+		t.Fatalf("Failed to get test executable: %s", err)
+	}
+	if err := os.Symlink(bin, filepath.Join(pluginDir, "falco-rule-timeout")); err != nil {
+		t.Fatalf("Failed to create rule plugin: %s", err)
+	}
+	// Non rule plugin file should be ignored
+	if err := os.WriteFile(filepath.Join(pluginDir, "README.md"), []byte(""), 0o644); err != nil {
+		t.Fatalf("Failed to write file: %s", err)
+	}
+	t.Setenv(rulePluginHelperEnv, "1")
+
+	tests := []struct {
+		name     string
+		rules    map[string]string
+		severity linter.Severity
+		errors   int
+		warnings int
+	}{
+		{name: "report plugin error", severity: linter.WARNING, warnings: 1},
+		{
+			name:     "override plugin rule severity",
+			rules:    map[string]string{"backend/first-byte-timeout": "ERROR"},
+			severity: linter.ERROR,
+			errors:   1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &config.Config{
+				Linter: &config.LinterConfig{
+					Format:    "json",
+					PluginDir: pluginDir,
+					Rules:     tt.rules,
+				},
+			}
+			resolvers, err := resolver.NewFileResolvers(main, c.IncludePaths)
+			if err != nil {
+				t.Fatalf("Unexpected resolver creation error: %s", err)
+			}
+			r, err := NewRunner(c, nil)
+			if err != nil {
+				t.Fatalf("Unexpected runner creation error: %s", err)
+			}
+			ret, err := r.Run(resolvers[0])
+			if err != nil {
+				t.Fatalf("Unexpected error running Run(): %s", err)
+			}
+			if ret.Errors != tt.errors || ret.Warnings != tt.warnings {
+				t.Errorf("Expected %d errors and %d warnings, got %d errors and %d warnings: %v",
+					tt.errors, tt.warnings, ret.Errors, ret.Warnings, ret.LintErrors[main])
+				return
+			}
+			errs := ret.LintErrors[main]
+			if len(errs) != 1 {
+				t.Errorf("Expected 1 lint error, got %d", len(errs))
+				return
+			}
+			if errs[0].Rule != "backend/first-byte-timeout" || errs[0].Token.Line != 6 {
+				t.Errorf("Unexpected lint error: %s", errs[0])
+			}
+			if r.severity(errs[0]) != tt.severity {
+				t.Errorf("Expected severity %s, got %s", tt.severity, r.severity(errs[0]))
+			}
+		})
+	}
+}
+
+func TestRulePluginNotFound(t *testing.T) {
+	_, err := NewRunner(&config.Config{
+		Linter: &config.LinterConfig{Plugins: []string{"not-exist"}},
+	}, nil)
+	if err == nil {
+		t.Errorf("Expected error for not existing rule plugin but got nil")
+	}
+}
+
+func TestInvalidCustomRuleConfig(t *testing.T) {
+	_, err := NewRunner(&config.Config{
+		Linter: &config.LinterConfig{
+			CustomRules: []*config.CustomRule{{Name: "unknown-node", Node: "foo"}},
+		},
+	}, nil)
+	if err == nil {
+		t.Errorf("Expected configuration error for invalid custom rule but got nil")
+	}
+}
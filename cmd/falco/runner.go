@@ -3,7 +3,10 @@ package main
 import (
 	"bytes"
 	"fmt"
+	"io"
 	"net/http"
+	"os"
+	"sort"
 	"strings"
 
 	"github.com/fatih/color"
@@ -12,7 +15,9 @@ import (
 	"github.com/ysugimoto/falco/config"
 	"github.com/ysugimoto/falco/context"
 	"github.com/ysugimoto/falco/debugger"
+	"github.com/ysugimoto/falco/formatter"
 	"github.com/ysugimoto/falco/interpreter"
+	icache "github.com/ysugimoto/falco/interpreter/cache"
 	icontext "github.com/ysugimoto/falco/interpreter/context"
 	"github.com/ysugimoto/falco/lexer"
 	"github.com/ysugimoto/falco/linter"
@@ -45,6 +50,9 @@ type RunnerResult struct {
 	ParseErrors map[string]*parser.ParseError
 
 	Vcl *plugin.VCL
+
+	suppressed int           // number of lint errors which are suppressed by the baseline
+	baseline   *LintBaseline // recorded lint errors for writing baseline
 }
 
 type StatsResult struct {
@@ -58,6 +66,23 @@ type StatsResult struct {
 	Lines       int    `json:"lines"`
 }
 
+type FormatResult struct {
+	File      string
+	Original  string
+	Formatted string
+}
+
+func (f *FormatResult) IsChanged() bool {
+	return f.Original != f.Formatted
+}
+
+type FixResult struct {
+	File     string
+	Original string
+	Fixed    string
+	Fixes    []*linter.Fix
+}
+
 type Fetcher interface {
 	Backends() ([]*types.RemoteBackend, error)
 	Dictionaries() ([]*types.RemoteDictionary, error)
@@ -74,45 +99,74 @@ const (
 
 type Runner struct {
 	transformers []*Transformer
+	rulePlugins  []*RulePlugin
+	cache        *LintCache
+	baseline     map[baselineKey]int
+	recorded     *LintBaseline
+	service      string
 	overrides    map[string]linter.Severity
 	lexers       map[string]*lexer.Lexer
 	snippets     *snippets.Snippets
 	config       *config.Config
+	format       LintFormat
+	output       io.Writer
 
 	level       Level
 	lintErrors  map[string][]*linter.LintError
 	parseErrors map[string]*parser.ParseError
 
 	// runner result fields
-	infos    int
-	warnings int
-	errors   int
+	infos      int
+	warnings   int
+	errors     int
+	suppressed int
 }
 
-// Wrap writeln function in order to prevent to write when json mode turns on
+// Wrap writeln function in order to prevent to write when machine readable format turns on
 func (r *Runner) message(c *color.Color, format string, args ...interface{}) {
-	// Suppress output when JSON mode turns on
+	// Suppress output when machine readable format like JSON turns on
 	// This is because JSON only should display JSON string
 	// so any other messages we must not output
-	if r.config.Json {
+	if r.format != LintFormatText {
 		return
 	}
-	write(c, format, args...)
+	r.write(c, format, args...)
+}
+
+func (r *Runner) write(c *color.Color, format string, args ...interface{}) {
+	fprint(r.output, c, format, args...)
+}
+
+func (r *Runner) writeln(c *color.Color, format string, args ...interface{}) {
+	r.write(c, format+"\n", args...)
 }
 
 func NewRunner(c *config.Config, fetcher snippets.Fetcher) (*Runner, error) {
+	return newRunner(c, fetcher, output)
+}
+
+// Create runner which writes messages to w, transformers and rule plugins also write to it
+func newRunner(c *config.Config, fetcher snippets.Fetcher, w io.Writer) (*Runner, error) {
 	r := &Runner{
 		level:       LevelError,
 		overrides:   make(map[string]linter.Severity),
 		lexers:      make(map[string]*lexer.Lexer),
 		config:      c,
+		output:      w,
 		lintErrors:  make(map[string][]*linter.LintError),
 		parseErrors: make(map[string]*parser.ParseError),
 	}
 
+	format, err := lintFormat(c)
+	if err != nil {
+		return nil, err
+	}
+	r.format = format
+
 	// If fetch interface is provided, communicate with it
 	if fetcher != nil {
-		s, err := snippets.Fetch(fetcher)
+		// Progress is written to the runner output which is stderr, so that it does not break machine readable report
+		s, err := snippets.Fetch(fetcher, r.output)
 		if err != nil {
 			r.message(red, err.Error()+"\n")
 		}
@@ -133,6 +187,54 @@ func NewRunner(c *config.Config, fetcher snippets.Fetcher) (*Runner, error) {
 		r.transformers = append(r.transformers, tf)
 	}
 
+	// Custom rules in the configuration are validated before linting
+	if err := linter.ValidateCustomRules(c.Linter.CustomRules); err != nil {
+		return nil, fmt.Errorf("Invalid linter.custom_rules configuration: %w", err)
+	}
+
+	// Custom lint rules are provided as "falco-rule-[name]" binary in PATH or plugin directory
+	for i := range c.Linter.Plugins {
+		p, err := NewRulePlugin(c.Linter.Plugins[i])
+		if err != nil {
+			return nil, err
+		}
+		r.rulePlugins = append(r.rulePlugins, p)
+	}
+	if c.Linter.PluginDir != "" {
+		plugins, err := FindRulePlugins(c.Linter.PluginDir)
+		if err != nil {
+			return nil, err
+		}
+		r.rulePlugins = append(r.rulePlugins, plugins...)
+	}
+	for i := range r.transformers {
+		r.transformers[i].output = w
+	}
+	for i := range r.rulePlugins {
+		r.rulePlugins[i].output = w
+	}
+
+	// On-disk cache for parsed AST and lint result
+	if c.Linter.Cache {
+		cache, err := NewLintCache(c)
+		if err != nil {
+			return nil, err
+		}
+		r.cache = cache
+	}
+
+	// Lint errors which are recorded in the baseline are suppressed
+	if c.Linter.Baseline != "" {
+		b, err := LoadLintBaseline(c.Linter.Baseline)
+		if err != nil {
+			return nil, err
+		}
+		r.baseline = b.counts()
+	}
+	if c.Linter.WriteBaseline != "" {
+		r.recorded = NewLintBaseline()
+	}
+
 	// Set verbose level
 	if c.Linter.VerboseInfo {
 		r.level = LevelInfo
@@ -186,10 +288,13 @@ func (r *Runner) Run(rslv resolver.Resolver) (*RunnerResult, error) {
 		return nil, err
 	}
 
+	// Baseline errors are distinguished by service name for multiple services in terraform
+	r.service = rslv.Name()
+
 	// Note: this context is not Go context, our parsing context :)
 	ctx := context.New(options...)
 	vcl, err := r.run(ctx, main, RunModeLint)
-	if err != nil && !r.config.Json {
+	if err != nil && r.format == LintFormatText {
 		return nil, err
 	}
 
@@ -200,31 +305,28 @@ func (r *Runner) Run(rslv resolver.Resolver) (*RunnerResult, error) {
 		LintErrors:  r.lintErrors,
 		ParseErrors: r.parseErrors,
 		Vcl:         vcl,
+		suppressed:  r.suppressed,
+		baseline:    r.recorded,
 	}, nil
 }
 
 func (r *Runner) run(ctx *context.Context, main *resolver.VCL, mode RunMode) (*plugin.VCL, error) {
-	vcl, err := r.parseVCL(main.Name, main.Data)
-	if err != nil {
-		return nil, err
-	}
-
-	// If remote snippets exists, prepare parse and prepend to main VCL
-	if r.snippets != nil {
-		for _, snip := range r.snippets.EmbedSnippets() {
-			s, err := r.parseVCL(snip.Name, snip.Data)
-			if err != nil {
-				return nil, err
-			}
-			vcl.Statements = append(s.Statements, vcl.Statements...)
+	var session *lintCacheSession
+	if r.cache != nil {
+		session = r.cache.Session()
+	}
+	// Lint result could be reused only when it depends on VCL modules,
+	// remote snippets and rule plugins may return different result without any VCL changes
+	cacheResult := session != nil && mode&RunModeLint > 0 && r.snippets == nil && len(r.rulePlugins) == 0
+	if cacheResult {
+		if vcl, ok := r.runCachedLint(ctx, main, session); ok {
+			return vcl, nil
 		}
 	}
 
-	lt := linter.New(r.config.Linter)
-	lt.Lint(vcl, ctx)
-
-	for k, v := range lt.Lexers() {
-		r.lexers[k] = v
+	vcl, lt, err := r.lint(ctx, main, session)
+	if err != nil {
+		return nil, err
 	}
 
 	// If runner is running as stat mode, prevent to output lint result
@@ -239,8 +341,8 @@ func (r *Runner) run(ctx *context.Context, main *resolver.VCL, mode RunMode) (*p
 			if pe.Token.File != "" {
 				file = "in " + pe.Token.File + " "
 			}
-			// Nothing to print to stdout if JSON mode is enabled, exit early.
-			if r.config.Json {
+			// Nothing to print to stdout if machine readable format is enabled, exit early.
+			if r.format != LintFormatText {
 				r.parseErrors[pe.Token.File] = pe
 			} else {
 				r.printParseError(lt.FatalError.Lexer, file, pe)
@@ -249,25 +351,13 @@ func (r *Runner) run(ctx *context.Context, main *resolver.VCL, mode RunMode) (*p
 		return nil, ErrParser
 	}
 
-	if len(lt.Errors) > 0 {
-		for _, err := range lt.Errors {
-			le, ok := err.(*linter.LintError)
-			if !ok {
-				continue
-			}
-			// check severity with overrides
-			severity := le.Severity
-			if v, ok := r.overrides[string(le.Rule)]; ok {
-				severity = v
-			}
+	// Merge lint errors which are reported from custom rule plugins
+	lt.Errors = append(lt.Errors, r.runRulePlugins(ctx, main.Name, vcl)...)
 
-			// Store all but ignored linter errors
-			if r.config.Json && severity != linter.IGNORE {
-				r.lintErrors[le.Token.File] = append(r.lintErrors[le.Token.File], le)
-			}
-			r.printLinterError(r.lexers[main.Name], severity, le)
-		}
+	if cacheResult {
+		session.SaveResult(ctx.Resolver(), main.Name, lt.Errors)
 	}
+	r.reportLintErrors(main.Name, lt.Errors)
 
 	return &plugin.VCL{
 		File: main.Name,
@@ -275,24 +365,171 @@ func (r *Runner) run(ctx *context.Context, main *resolver.VCL, mode RunMode) (*p
 	}, nil
 }
 
+// Report cached lint result if all loaded modules have not been changed since the last linting
+func (r *Runner) runCachedLint(ctx *context.Context, main *resolver.VCL, session *lintCacheSession) (*plugin.VCL, bool) {
+	lintErrors, modules, ok := r.cache.LoadResult(ctx.Resolver(), main)
+	if !ok {
+		return nil, false
+	}
+	vcl, err := r.parseMainVCL(main, session)
+	if err != nil {
+		return nil, false
+	}
+	for name, content := range modules {
+		if _, ok := r.lexers[name]; !ok {
+			r.lexers[name] = lexer.NewFromLines(content, lexer.WithFile(name))
+		}
+	}
+
+	errs := make([]error, len(lintErrors))
+	for i := range lintErrors {
+		errs[i] = lintErrors[i]
+	}
+	r.reportLintErrors(main.Name, errs)
+
+	return &plugin.VCL{
+		File: main.Name,
+		AST:  vcl,
+	}, true
+}
+
+func (r *Runner) reportLintErrors(main string, errs []error) {
+	for _, err := range errs {
+		le, ok := err.(*linter.LintError)
+		if !ok {
+			continue
+		}
+		severity := r.severity(le)
+		if severity != linter.IGNORE && r.matchBaseline(main, le) {
+			continue
+		}
+
+		// Store all but ignored linter errors
+		if r.format != LintFormatText && severity != linter.IGNORE {
+			r.lintErrors[le.Token.File] = append(r.lintErrors[le.Token.File], le)
+		}
+		r.printLinterError(r.lexers[main], severity, le)
+	}
+}
+
+// Record lint error to the baseline if needed, and report whether the error is suppressed by the baseline
+func (r *Runner) matchBaseline(main string, le *linter.LintError) bool {
+	if r.baseline == nil && r.recorded == nil {
+		return false
+	}
+	lx := r.lexers[main]
+	if le.Token.File != "" {
+		lx = r.lexers[le.Token.File]
+	}
+	fingerprint := baselineFingerprint(lx, le)
+
+	if r.recorded != nil {
+		r.recorded.Add(r.service, fingerprint, le)
+	}
+	key := baselineKey{
+		service:     r.service,
+		rule:        string(le.Rule),
+		file:        baselineFile(le.Token.File),
+		fingerprint: fingerprint,
+	}
+	if r.baseline[key] > 0 {
+		r.baseline[key]--
+		r.suppressed++
+		return true
+	}
+	return false
+}
+
+func (r *Runner) runRulePlugins(ctx *context.Context, file string, vcl *ast.VCL) []error {
+	if len(r.rulePlugins) == 0 {
+		return nil
+	}
+
+	var errs []error
+	input, err := plugin.EncodeRuleInput(&plugin.VCL{File: file, AST: vcl}, plugin.NewContextSummary(ctx))
+	if err != nil {
+		return append(errs, &linter.LintError{
+			Severity: linter.ERROR,
+			Message:  fmt.Sprintf("Failed to encode VCL for rule plugins: %s", err),
+		})
+	}
+	ignored := linter.NewIgnoredLines(vcl)
+	for _, p := range r.rulePlugins {
+		lintErrors, err := p.Execute(input)
+		// Plugin failure is reported as lint error in order to be displayed in any output formats
+		if err != nil {
+			errs = append(errs, &linter.LintError{
+				Severity: linter.ERROR,
+				Message:  err.Error(),
+				Rule:     linter.Rule(p.name),
+			})
+			continue
+		}
+		for _, le := range lintErrors {
+			// Respect falco-ignore comments as well as builtin rules
+			if ignored.Contains(le.Token) {
+				continue
+			}
+			errs = append(errs, le)
+		}
+	}
+	return errs
+}
+
+// Parse main VCL with remote snippets and lint it
+func (r *Runner) lint(ctx *context.Context, main *resolver.VCL, session *lintCacheSession) (*ast.VCL, *linter.Linter, error) {
+	vcl, err := r.parseMainVCL(main, session)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// If remote snippets exists, prepare parse and prepend to main VCL
+	if r.snippets != nil {
+		for _, snip := range r.snippets.EmbedSnippets() {
+			s, err := r.parseVCL(snip.Name, snip.Data)
+			if err != nil {
+				return nil, nil, err
+			}
+			vcl.Statements = append(s.Statements, vcl.Statements...)
+		}
+	}
+
+	var options []linter.Option
+	if session != nil {
+		options = append(options, linter.WithParseCache(session))
+	}
+	lt := linter.New(r.config.Linter, options...)
+	lt.Lint(vcl, ctx)
+
+	for k, v := range lt.Lexers() {
+		r.lexers[k] = v
+	}
+	return vcl, lt, nil
+}
+
+// Parse main VCL, or load parsed AST from the cache if the content is not changed
+func (r *Runner) parseMainVCL(main *resolver.VCL, session *lintCacheSession) (*ast.VCL, error) {
+	if session == nil {
+		return r.parseVCL(main.Name, main.Data)
+	}
+	if statements, ok := session.Load(main.Name, main.Data, false); ok {
+		r.lexers[main.Name] = lexer.NewFromLines(main.Data, lexer.WithFile(main.Name))
+		return &ast.VCL{Statements: statements}, nil
+	}
+	vcl, err := r.parseVCL(main.Name, main.Data)
+	if err != nil {
+		return nil, err
+	}
+	session.Save(main.Name, main.Data, false, vcl.Statements)
+	return vcl, nil
+}
+
 func (r *Runner) parseVCL(name, code string) (*ast.VCL, error) {
 	lx := lexer.NewFromString(code, lexer.WithFile(name))
 	p := parser.New(lx)
 	vcl, err := p.ParseVCL()
 	if err != nil {
-		lx.NewLine()
-		pe, ok := errors.Cause(err).(*parser.ParseError)
-		if ok {
-			var file string
-			if pe.Token.File != "" {
-				file = "in " + pe.Token.File + " "
-			}
-			// Nothing to print to stdout if JSON mode is enabled, exit early.
-			if r.config.Json {
-				r.parseErrors[pe.Token.File] = pe
-			}
-			r.printParseError(lx, file, pe)
-		}
+		r.handleParseError(lx, err)
 		return nil, ErrParser
 	}
 
@@ -301,6 +538,38 @@ func (r *Runner) parseVCL(name, code string) (*ast.VCL, error) {
 	return vcl, nil
 }
 
+// Snippet VCL is a piece of subroutine code which is included inside subroutine
+func (r *Runner) parseSnippetVCL(name, code string) ([]ast.Statement, error) {
+	lx := lexer.NewFromString(code, lexer.WithFile(name))
+	p := parser.New(lx)
+	statements, err := p.ParseSnippetVCL()
+	if err != nil {
+		r.handleParseError(lx, err)
+		return nil, ErrParser
+	}
+
+	lx.NewLine()
+	r.lexers[name] = lx
+	return statements, nil
+}
+
+func (r *Runner) handleParseError(lx *lexer.Lexer, err error) {
+	lx.NewLine()
+	pe, ok := errors.Cause(err).(*parser.ParseError)
+	if !ok {
+		return
+	}
+	var file string
+	if pe.Token.File != "" {
+		file = "in " + pe.Token.File + " "
+	}
+	// Nothing to print to stdout if machine readable format is enabled, exit early.
+	if r.format != LintFormatText {
+		r.parseErrors[pe.Token.File] = pe
+	}
+	r.printParseError(lx, file, pe)
+}
+
 func (r *Runner) printParseError(lx *lexer.Lexer, file string, err *parser.ParseError) {
 	r.message(red, ":boom: %s\n%sat line %d, position %d\n", err.Message, file, err.Token.Line, err.Token.Position)
 
@@ -325,6 +594,14 @@ func (r *Runner) printParseError(lx *lexer.Lexer, file string, err *parser.Parse
 	}
 }
 
+// Check severity with overrides
+func (r *Runner) severity(le *linter.LintError) linter.Severity {
+	if v, ok := r.overrides[string(le.Rule)]; ok {
+		return v
+	}
+	return le.Severity
+}
+
 func (r *Runner) printLinterError(lx *lexer.Lexer, severity linter.Severity, err *linter.LintError) {
 	var rule, file string
 
@@ -422,6 +699,193 @@ func (r *Runner) Stats(rslv resolver.Resolver) (*StatsResult, error) {
 	return stats, nil
 }
 
+func (r *Runner) Format(rslv resolver.Resolver) ([]*FormatResult, error) {
+	main, err := rslv.MainVCL()
+	if err != nil {
+		return nil, err
+	}
+
+	vcl, err := r.parseVCL(main.Name, main.Data)
+	if err != nil {
+		return nil, err
+	}
+
+	f := formatter.New()
+	results := []*FormatResult{
+		{
+			File:      main.Name,
+			Original:  main.Data,
+			Formatted: f.Format(vcl),
+		},
+	}
+
+	// Format all included modules as well
+	formatted := map[string]struct{}{
+		main.Name: {},
+	}
+	included, err := r.formatIncludes(rslv, f, vcl.Statements, true, formatted)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(results, included...), nil
+}
+
+func (r *Runner) formatIncludes(
+	rslv resolver.Resolver,
+	f *formatter.Formatter,
+	statements []ast.Statement,
+	isRoot bool, // if true, module is included on root
+	formatted map[string]struct{},
+) ([]*FormatResult, error) {
+
+	var results []*FormatResult
+	for _, stmt := range statements {
+		var nested []ast.Statement
+		switch t := stmt.(type) {
+		case *ast.IncludeStatement:
+			// Fastly managed snippets could not be formatted because they are not files
+			if strings.HasPrefix(t.Module.Value, "snippet::") {
+				continue
+			}
+			module, err := rslv.Resolve(t)
+			if err != nil {
+				return nil, err
+			}
+			if _, ok := formatted[module.Name]; ok {
+				continue
+			}
+			formatted[module.Name] = struct{}{}
+
+			result := &FormatResult{
+				File:     module.Name,
+				Original: module.Data,
+			}
+			var moduleStatements []ast.Statement
+			if isRoot {
+				vcl, err := r.parseVCL(module.Name, module.Data)
+				if err != nil {
+					return nil, err
+				}
+				result.Formatted = f.Format(vcl)
+				moduleStatements = vcl.Statements
+			} else {
+				moduleStatements, err = r.parseSnippetVCL(module.Name, module.Data)
+				if err != nil {
+					return nil, err
+				}
+				result.Formatted = f.FormatSnippet(moduleStatements)
+			}
+			results = append(results, result)
+
+			included, err := r.formatIncludes(rslv, f, moduleStatements, isRoot, formatted)
+			if err != nil {
+				return nil, err
+			}
+			results = append(results, included...)
+			continue
+		case *ast.SubroutineDeclaration:
+			nested = t.Block.Statements
+		case *ast.BlockStatement:
+			nested = t.Statements
+		case *ast.IfStatement:
+			nested = append(nested, t.Consequence.Statements...)
+			for _, a := range t.Another {
+				nested = append(nested, a.Consequence.Statements...)
+			}
+			if t.Alternative != nil {
+				nested = append(nested, t.Alternative.Statements...)
+			}
+		case *ast.SwitchStatement:
+			for _, c := range t.Cases {
+				nested = append(nested, c.Statements...)
+			}
+		default:
+			continue
+		}
+
+		// Statements inside subroutine includes snippet module
+		included, err := r.formatIncludes(rslv, f, nested, false, formatted)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, included...)
+	}
+
+	return results, nil
+}
+
+// Fix lints VCL and applies automatic fixes of lint errors for each file
+func (r *Runner) Fix(rslv resolver.Resolver) ([]*FixResult, error) {
+	// Automatic fixes could be applied only for local files
+	if _, ok := rslv.(*resolver.FileResolver); !ok {
+		return nil, errors.New("Automatic fix is only supported for local VCL files")
+	}
+
+	options := []context.Option{context.WithResolver(rslv)}
+	// Remote snippets must be linted together, otherwise declarations which are used in the snippets are reported as unused
+	skips := make(map[string]struct{})
+	if r.snippets != nil {
+		options = append(options, context.WithSnippets(r.snippets))
+		for _, snip := range r.snippets.EmbedSnippets() {
+			skips[snip.Name] = struct{}{}
+		}
+	}
+
+	main, err := rslv.MainVCL()
+	if err != nil {
+		return nil, err
+	}
+	_, lt, err := r.lint(context.New(options...), main, nil)
+	if err != nil {
+		return nil, err
+	}
+	if lt.FatalError != nil {
+		return nil, lt.FatalError.Error
+	}
+
+	var files []string
+	fixes := make(map[string][]*linter.Fix)
+	for _, err := range lt.Errors {
+		le, ok := err.(*linter.LintError)
+		if !ok || le.Fix == nil || r.severity(le) == linter.IGNORE {
+			continue
+		}
+		file := le.Token.File
+		// Snippets are not files so we could not fix them
+		if _, ok := skips[file]; ok || file == "" || strings.HasPrefix(file, "snippet::") {
+			continue
+		}
+		if _, ok := fixes[file]; !ok {
+			files = append(files, file)
+		}
+		fixes[file] = append(fixes[file], le.Fix)
+	}
+	sort.Strings(files)
+
+	var results []*FixResult
+	for _, file := range files {
+		original, err := os.ReadFile(file)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		fixed, applied, err := linter.ApplyFixes(string(original), fixes[file])
+		if err != nil {
+			return nil, fmt.Errorf("Failed to fix %s: %w", file, err)
+		}
+		if len(applied) == 0 {
+			continue
+		}
+		results = append(results, &FixResult{
+			File:     file,
+			Original: string(original),
+			Fixed:    fixed,
+			Fixes:    applied,
+		})
+	}
+	return results, nil
+}
+
 func (r *Runner) Simulate(rslv resolver.Resolver) error {
 	sc := r.config.Simulator
 	options := []icontext.Option{
@@ -440,6 +904,12 @@ func (r *Runner) Simulate(rslv resolver.Resolver) error {
 	}
 
 	i := interpreter.New(options...)
+	i.Proxy = sc.IsProxy
+	if c, err := r.simulatorCache(sc); err != nil {
+		return errors.WithStack(err)
+	} else if c != nil {
+		i.SetCache(c)
+	}
 
 	// If debugger flag is on, run debugger mode
 	if sc.IsDebug {
@@ -455,9 +925,33 @@ func (r *Runner) Simulate(rslv resolver.Resolver) error {
 		Addr:    fmt.Sprintf(":%d", sc.Port),
 	}
 	writeln(green, "Simulator server starts on 0.0.0.0:%d", sc.Port)
+	if sc.IsProxy {
+		writeln(white, "Proxy mode is enabled, trace of each request is served on %s[id]", interpreter.TracePathPrefix)
+	}
 	return s.ListenAndServe()
 }
 
+// Create cache of the simulator from the configuration, returns nil if default in-memory cache is enough
+func (r *Runner) simulatorCache(sc *config.SimulatorConfig) (*icache.Cache, error) {
+	if sc.CacheDir == "" && sc.CacheCapacity == 0 {
+		return nil, nil
+	}
+	options := []icache.Option{
+		icache.WithCapacity(sc.CacheCapacity),
+		icache.WithErrorHandler(func(err error) {
+			writeln(yellow, "Failed to update cache storage: %s", err)
+		}),
+	}
+	if sc.CacheDir != "" {
+		storage, err := icache.NewDiskStorage(sc.CacheDir)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		options = append(options, icache.WithStorage(storage))
+	}
+	return icache.New(options...), nil
+}
+
 func (r *Runner) Test(rslv resolver.Resolver) (*tester.TestFactory, error) {
 	tc := r.config.Testing
 	options := []icontext.Option{
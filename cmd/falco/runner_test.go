@@ -5,6 +5,7 @@ import (
 	"path/filepath"
 	"testing"
 
+	"github.com/google/go-cmp/cmp"
 	"github.com/ysugimoto/falco/config"
 	"github.com/ysugimoto/falco/linter"
 	"github.com/ysugimoto/falco/resolver"
@@ -357,6 +358,30 @@ func TestTester(t *testing.T) {
 			filter: "*assertion.test.vcl",
 			passes: 5,
 		},
+		{
+			name:   "rate limiting test",
+			main:   "../../examples/testing/ratelimit.vcl",
+			filter: "*ratelimit.test.vcl",
+			passes: 5,
+		},
+		{
+			name:   "request lifecycle test",
+			main:   "../../examples/testing/lifecycle.vcl",
+			filter: "*lifecycle.test.vcl",
+			passes: 12,
+		},
+		{
+			name:   "setup and teardown hooks test",
+			main:   "../../examples/testing/hooks.vcl",
+			filter: "*hooks.test.vcl",
+			passes: 5,
+		},
+		{
+			name:   "table-driven test",
+			main:   "../../examples/testing/each.vcl",
+			filter: "*each.test.vcl",
+			passes: 10,
+		},
 	}
 
 	for _, tt := range tests {
@@ -409,3 +434,78 @@ func TestTester(t *testing.T) {
 		})
 	}
 }
+
+func TestRunnerFix(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"main.vcl": `include "backends";
+
+sub vcl_recv {
+  set req.backend = F_origin;
+  return;
+}
+`,
+		"backends.vcl": `backend F_origin {
+  .host = "example.com";
+}
+
+backend F_unused {
+  .host = "example.com";
+}
+`,
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatalf("Failed to write %s: %s", name, err)
+		}
+	}
+
+	c := &config.Config{
+		IncludePaths: []string{dir},
+		Linter:       &config.LinterConfig{Fix: true},
+	}
+	resolvers, err := resolver.NewFileResolvers(filepath.Join(dir, "main.vcl"), c.IncludePaths)
+	if err != nil {
+		t.Fatalf("Unexpected resolver creation error: %s", err)
+	}
+	r, err := NewRunner(c, nil)
+	if err != nil {
+		t.Fatalf("Unexpected runner creation error: %s", err)
+	}
+	results, err := r.Fix(resolvers[0])
+	if err != nil {
+		t.Fatalf("Unexpected error running Fix(): %s", err)
+	}
+
+	expects := []*FixResult{
+		{
+			File: filepath.Join(dir, "backends.vcl"),
+			Fixed: `backend F_origin {
+  .host = "example.com";
+}
+`,
+		},
+		{
+			File: filepath.Join(dir, "main.vcl"),
+			Fixed: `include "backends";
+
+sub vcl_recv {
+  #FASTLY RECV
+  set req.backend = F_origin;
+  return(lookup);
+}
+`,
+		},
+	}
+	if len(results) != len(expects) {
+		t.Fatalf("Expected %d fixed files, got %d", len(expects), len(results))
+	}
+	for i := range expects {
+		if results[i].File != expects[i].File {
+			t.Errorf("Expected fixed file %s, got %s", expects[i].File, results[i].File)
+		}
+		if diff := cmp.Diff(expects[i].Fixed, results[i].Fixed); diff != "" {
+			t.Errorf("Fixed content mismatch for %s, diff=%s", expects[i].File, diff)
+		}
+	}
+}
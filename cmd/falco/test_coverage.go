@@ -0,0 +1,95 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/pkg/errors"
+	"github.com/ysugimoto/falco/interpreter/coverage"
+)
+
+const (
+	coverageLcovFile      = "lcov.info"
+	coverageCoberturaFile = "cobertura.xml"
+)
+
+// Write lcov and Cobertura XML coverage reports to the directory for the CI coverage tools
+func writeCoverageReports(dir string, report *coverage.Report) ([]string, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	writers := []struct {
+		file  string
+		write func(fp *os.File) error
+	}{
+		{
+			file: coverageLcovFile,
+			write: func(fp *os.File) error {
+				return report.WriteLcov(fp)
+			},
+		},
+		{
+			file: coverageCoberturaFile,
+			write: func(fp *os.File) error {
+				return report.WriteCobertura(fp, time.Now())
+			},
+		},
+	}
+
+	var files []string
+	for _, w := range writers {
+		file := filepath.Join(dir, w.file)
+		fp, err := os.Create(file)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		if err := w.write(fp); err != nil {
+			fp.Close() // nolint:errcheck
+			return nil, errors.WithStack(err)
+		}
+		if err := fp.Close(); err != nil {
+			return nil, errors.WithStack(err)
+		}
+		files = append(files, file)
+	}
+	return files, nil
+}
+
+func coverageColor(c coverage.Counter) *color.Color {
+	switch rate := c.Rate(); {
+	case rate >= 0.8:
+		return green
+	case rate >= 0.5:
+		return yellow
+	default:
+		return red
+	}
+}
+
+func formatCoverage(c coverage.Counter) string {
+	return fmt.Sprintf("%6.2f%% (%d/%d)", c.Rate()*100, c.Covered, c.Total)
+}
+
+// Print coverage summary per file and subroutine
+func printCoverageSummary(report *coverage.Report) {
+	writeln(white, "\nCoverage:")
+	for _, f := range report.Files {
+		write(white, "  %s  ", f.Name)
+		write(coverageColor(f.Statements), "statements %s", formatCoverage(f.Statements))
+		writeln(coverageColor(f.Branches), "  branches %s", formatCoverage(f.Branches))
+
+		for _, s := range f.Subroutines {
+			write(white, "    %-32s ", s.Name)
+			write(coverageColor(s.Statements), "statements %s", formatCoverage(s.Statements))
+			writeln(coverageColor(s.Branches), "  branches %s", formatCoverage(s.Branches))
+		}
+	}
+	write(white, "  Total  ")
+	write(coverageColor(report.Subroutines), "subroutines %s", formatCoverage(report.Subroutines))
+	write(coverageColor(report.Statements), "  statements %s", formatCoverage(report.Statements))
+	writeln(coverageColor(report.Branches), "  branches %s", formatCoverage(report.Branches))
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/ysugimoto/falco/config"
+	"github.com/ysugimoto/falco/interpreter/coverage"
+	"github.com/ysugimoto/falco/resolver"
+)
+
+func TestTesterCoverage(t *testing.T) {
+	main := "../../examples/testing/lifecycle.vcl"
+	c := &config.Config{
+		Linter: &config.LinterConfig{
+			VerboseWarning: true,
+		},
+		Testing: &config.TestConfig{
+			Filter:   "*lifecycle.test.vcl",
+			Coverage: true,
+		},
+		Commands: config.Commands{"test", main},
+	}
+	resolvers, err := resolver.NewFileResolvers(main, c.IncludePaths)
+	if err != nil {
+		t.Fatalf("Unexpected resolver creation error: %s", err)
+	}
+	r, err := NewRunner(c, nil)
+	if err != nil {
+		t.Fatalf("Unexpected runner creation error: %s", err)
+	}
+	ret, err := r.Test(resolvers[0])
+	if err != nil {
+		t.Fatalf("Unexpected testing error: %s", err)
+	}
+	if ret.Coverage == nil {
+		t.Fatalf("Expected coverage is reported")
+	}
+	if diff := cmp.Diff(coverage.Counter{Covered: 10, Total: 10}, ret.Coverage.Statements); diff != "" {
+		t.Errorf("Unexpected statement coverage, diff=%s", diff)
+	}
+	if diff := cmp.Diff(coverage.Counter{Covered: 4, Total: 4}, ret.Coverage.Branches); diff != "" {
+		t.Errorf("Unexpected branch coverage, diff=%s", diff)
+	}
+
+	dir := filepath.Join(t.TempDir(), "coverage")
+	files, err := writeCoverageReports(dir, ret.Coverage)
+	if err != nil {
+		t.Fatalf("Unexpected error writing coverage reports: %s", err)
+	}
+	expect := []string{
+		filepath.Join(dir, coverageLcovFile),
+		filepath.Join(dir, coverageCoberturaFile),
+	}
+	if diff := cmp.Diff(expect, files); diff != "" {
+		t.Errorf("Unexpected coverage report files, diff=%s", diff)
+	}
+	for _, file := range files {
+		if info, err := os.Stat(file); err != nil || info.Size() == 0 {
+			t.Errorf("Coverage report %s is not written", file)
+		}
+	}
+}
@@ -0,0 +1,306 @@
+package main
+
+import (
+	"bufio"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/ysugimoto/falco/config"
+	ife "github.com/ysugimoto/falco/interpreter/function/errors"
+	"github.com/ysugimoto/falco/tester"
+	"github.com/ysugimoto/falco/token"
+)
+
+type TestReporter string
+
+const (
+	TestReporterNone  TestReporter = ""
+	TestReporterJUnit TestReporter = "junit"
+	TestReporterTAP   TestReporter = "tap"
+)
+
+func parseTestReporter(c string) (TestReporter, error) {
+	switch r := TestReporter(strings.ToLower(c)); r {
+	case TestReporterNone, TestReporterJUnit, TestReporterTAP:
+		return r, nil
+	default:
+		return "", fmt.Errorf("Unsupported test reporter: %s, must be one of junit or tap", c)
+	}
+}
+
+// Report file path which is used when --reporter-file option is not specified
+func (r TestReporter) defaultFile() string {
+	switch r {
+	case TestReporterJUnit:
+		return "falco-test.xml"
+	case TestReporterTAP:
+		return "falco-test.tap"
+	default:
+		return ""
+	}
+}
+
+// Write test results to the file in the reporter format, returns written file path
+func writeTestReport(c *config.TestConfig, factory *tester.TestFactory) (string, error) {
+	reporter, err := parseTestReporter(c.Reporter)
+	if err != nil {
+		return "", err
+	}
+	if reporter == TestReporterNone {
+		return "", nil
+	}
+
+	file := c.ReporterFile
+	if file == "" {
+		file = reporter.defaultFile()
+	}
+	fp, err := os.Create(file)
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+
+	switch reporter {
+	case TestReporterJUnit:
+		err = reportJUnit(fp, factory)
+	case TestReporterTAP:
+		err = reportTAP(fp, factory)
+	}
+	if err != nil {
+		fp.Close() // nolint:errcheck
+		return "", err
+	}
+	if err := fp.Close(); err != nil {
+		return "", errors.WithStack(err)
+	}
+	return file, nil
+}
+
+// testFailure is common representation of failed test case for reporting
+type testFailure struct {
+	Type    string
+	Message string
+	Actual  string
+	File    string
+	Line    int
+	Source  string
+	IsError bool // true if the test raises unexpected error or hook is failed, not an assertion failure
+}
+
+func newTestFailure(r *tester.TestResult, err error) *testFailure {
+	// Failure in the hook subroutine is reported as an error with the cause in the hook
+	if he, ok := err.(*tester.HookError); ok {
+		f := newTestFailure(r, he.Err)
+		f.Type = "HookError"
+		f.Message = fmt.Sprintf("%s hook %s is failed: %s", he.Type, he.Name, f.Message)
+		f.IsError = true
+		return f
+	}
+
+	f := &testFailure{
+		Message: err.Error(),
+		File:    relativePath(r.Filename),
+		IsError: true,
+	}
+
+	var tok token.Token
+	switch e := err.(type) {
+	case *ife.AssertionError:
+		f.Type = "AssertionError"
+		f.Message = e.Message
+		if e.Actual != nil {
+			f.Actual = e.Actual.String()
+		}
+		f.IsError = false
+		tok = e.Token
+	case *ife.TestingError:
+		f.Type = "TestingError"
+		f.Message = e.Message
+		tok = e.Token
+	default:
+		f.Type = "Error"
+		return f
+	}
+
+	if tok.File != "" {
+		f.File = relativePath(tok.File)
+	}
+	f.Line = tok.Line
+	if r.Lexer != nil {
+		if line, ok := r.Lexer.GetLine(tok.Line); ok {
+			f.Source = strings.TrimSpace(line)
+		}
+	}
+	return f
+}
+
+// Detail text of the failure which is displayed in CI
+func (f *testFailure) detail() string {
+	var lines []string
+	lines = append(lines, f.Message)
+	if f.Actual != "" {
+		lines = append(lines, "Actual Value: "+f.Actual)
+	}
+	if f.Line > 0 {
+		lines = append(lines, fmt.Sprintf("at %s:%d", f.File, f.Line))
+	}
+	if f.Source != "" {
+		lines = append(lines, "  "+f.Source)
+	}
+	return strings.Join(lines, "\n")
+}
+
+func testCaseName(c *tester.TestCase) string {
+	return fmt.Sprintf("[%s] %s", c.Scope, c.Name)
+}
+
+func msecToSeconds(msec int64) string {
+	return strconv.FormatFloat(float64(msec)/1000, 'f', 3, 64)
+}
+
+// JUnit XML report which is rendered natively by GitLab, Jenkins, Buildkite and so on.
+// Each testing file is reported as a testsuite, and each test subroutine and scope is reported as a testcase.
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Name     string           `xml:"name,attr"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Errors   int              `xml:"errors,attr"`
+	Time     string           `xml:"time,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name     string          `xml:"name,attr"`
+	Tests    int             `xml:"tests,attr"`
+	Failures int             `xml:"failures,attr"`
+	Errors   int             `xml:"errors,attr"`
+	Time     string          `xml:"time,attr"`
+	Cases    []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	File      string        `xml:"file,attr"`
+	Line      int           `xml:"line,attr,omitempty"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+	Error     *junitFailure `xml:"error,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Body    string `xml:",chardata"`
+}
+
+func reportJUnit(w io.Writer, factory *tester.TestFactory) error {
+	report := junitTestSuites{Name: "falco"}
+	var total int64
+
+	for _, r := range factory.Results {
+		file := relativePath(r.Filename)
+		suite := junitTestSuite{Name: file}
+		var elapsed int64
+
+		for _, c := range r.Cases {
+			tc := junitTestCase{
+				Name:      testCaseName(c),
+				ClassName: file,
+				File:      file,
+				Time:      msecToSeconds(c.Time),
+			}
+			if c.Error != nil {
+				f := newTestFailure(r, c.Error)
+				tc.Line = f.Line
+				failure := &junitFailure{
+					Message: f.Message,
+					Type:    f.Type,
+					Body:    f.detail(),
+				}
+				if f.IsError {
+					tc.Error = failure
+					suite.Errors++
+				} else {
+					tc.Failure = failure
+					suite.Failures++
+				}
+			}
+			suite.Tests++
+			elapsed += c.Time
+			suite.Cases = append(suite.Cases, tc)
+		}
+		suite.Time = msecToSeconds(elapsed)
+
+		report.Tests += suite.Tests
+		report.Failures += suite.Failures
+		report.Errors += suite.Errors
+		total += elapsed
+		report.Suites = append(report.Suites, suite)
+	}
+	report.Time = msecToSeconds(total)
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return errors.WithStack(err)
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(report); err != nil {
+		return errors.WithStack(err)
+	}
+	_, err := io.WriteString(w, "\n")
+	return errors.WithStack(err)
+}
+
+// TAP version 13 report, failure details are written as YAML diagnostic block.
+// https://testanything.org/tap-version-13-specification.html
+func reportTAP(w io.Writer, factory *tester.TestFactory) error {
+	buf := bufio.NewWriter(w)
+
+	var total int
+	for _, r := range factory.Results {
+		total += len(r.Cases)
+	}
+	fmt.Fprintln(buf, "TAP version 13")
+	fmt.Fprintf(buf, "1..%d\n", total)
+
+	var n int
+	for _, r := range factory.Results {
+		file := relativePath(r.Filename)
+		for _, c := range r.Cases {
+			n++
+			if c.Error == nil {
+				fmt.Fprintf(buf, "ok %d - %s %s\n", n, file, testCaseName(c))
+				continue
+			}
+			fmt.Fprintf(buf, "not ok %d - %s %s\n", n, file, testCaseName(c))
+
+			f := newTestFailure(r, c.Error)
+			fmt.Fprintln(buf, "  ---")
+			fmt.Fprintf(buf, "  message: %s\n", strconv.Quote(f.Message))
+			if f.IsError {
+				fmt.Fprintln(buf, "  severity: error")
+			} else {
+				fmt.Fprintln(buf, "  severity: fail")
+			}
+			if f.Actual != "" {
+				fmt.Fprintf(buf, "  actual: %s\n", strconv.Quote(f.Actual))
+			}
+			if f.Line > 0 {
+				fmt.Fprintln(buf, "  at:")
+				fmt.Fprintf(buf, "    file: %s\n", strconv.Quote(f.File))
+				fmt.Fprintf(buf, "    line: %d\n", f.Line)
+			}
+			if f.Source != "" {
+				fmt.Fprintf(buf, "  source: %s\n", strconv.Quote(f.Source))
+			}
+			fmt.Fprintln(buf, "  ...")
+		}
+	}
+	return errors.WithStack(buf.Flush())
+}
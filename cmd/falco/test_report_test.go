@@ -0,0 +1,300 @@
+package main
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/ysugimoto/falco/config"
+	ife "github.com/ysugimoto/falco/interpreter/function/errors"
+	"github.com/ysugimoto/falco/resolver"
+	"github.com/ysugimoto/falco/tester"
+)
+
+const reporterTestMainVCL = `sub vcl_recv {
+  set req.http.Foo = "foo";
+}
+`
+
+func runReporterTest(t *testing.T, testVCL string) (*tester.TestFactory, string) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"main.vcl":      reporterTestMainVCL,
+		"main.test.vcl": testVCL,
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatalf("Failed to write %s: %s", name, err)
+		}
+	}
+
+	main := filepath.Join(dir, "main.vcl")
+	c := &config.Config{
+		Linter: &config.LinterConfig{},
+		Testing: &config.TestConfig{
+			Filter: "*.test.vcl",
+		},
+		Commands: config.Commands{"test", main},
+	}
+	resolvers, err := resolver.NewFileResolvers(main, c.IncludePaths)
+	if err != nil {
+		t.Fatalf("Unexpected resolver creation error: %s", err)
+	}
+	r, err := NewRunner(c, nil)
+	if err != nil {
+		t.Fatalf("Unexpected runner creation error: %s", err)
+	}
+	factory, err := r.Test(resolvers[0])
+	if err != nil {
+		t.Fatalf("Unexpected testing error: %s", err)
+	}
+	return factory, filepath.Join(dir, "main.test.vcl")
+}
+
+const reporterTestVCL = `// @scope: recv
+sub test_pass {
+  testing.call_subroutine("vcl_recv");
+  assert.equal(req.http.Foo, "foo");
+}
+
+// @scope: recv
+// @suite: failing suite
+sub test_fail {
+  testing.call_subroutine("vcl_recv");
+  assert.equal(req.http.Foo, "bar");
+}
+`
+
+func TestReportJUnit(t *testing.T) {
+	factory, file := runReporterTest(t, reporterTestVCL)
+
+	var buf bytes.Buffer
+	if err := reportJUnit(&buf, factory); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	var report junitTestSuites
+	if err := xml.Unmarshal(buf.Bytes(), &report); err != nil {
+		t.Fatalf("Failed to unmarshal JUnit XML: %s", err)
+	}
+	if report.Tests != 2 || report.Failures != 1 || report.Errors != 0 {
+		t.Errorf("Unexpected testsuites counts: %+v", report)
+	}
+	if len(report.Suites) != 1 || report.Suites[0].Name != file {
+		t.Fatalf("Expected one testsuite for %s, got %+v", file, report.Suites)
+	}
+
+	cases := report.Suites[0].Cases
+	if diff := cmp.Diff([]string{"[RECV] test_pass", "[RECV] failing suite"}, []string{cases[0].Name, cases[1].Name}); diff != "" {
+		t.Errorf("Unexpected testcase names, diff=%s", diff)
+	}
+	if cases[0].Failure != nil {
+		t.Errorf("Expected passed testcase has no failure")
+	}
+	failure := cases[1].Failure
+	if failure == nil {
+		t.Fatalf("Expected failed testcase has failure")
+	}
+	if failure.Type != "AssertionError" || cases[1].Line != 11 {
+		t.Errorf("Unexpected failure: %+v, line=%d", failure, cases[1].Line)
+	}
+	for _, expect := range []string{"Actual Value: foo", "main.test.vcl:11", `assert.equal(req.http.Foo, "bar");`} {
+		if !strings.Contains(failure.Body, expect) {
+			t.Errorf("Failure detail should contain %s, got %s", expect, failure.Body)
+		}
+	}
+}
+
+func TestReportTAP(t *testing.T) {
+	factory, file := runReporterTest(t, reporterTestVCL)
+
+	var buf bytes.Buffer
+	if err := reportTAP(&buf, factory); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	assertion, ok := factory.Results[0].Cases[1].Error.(*ife.AssertionError)
+	if !ok {
+		t.Fatalf("Expected assertion error, got %v", factory.Results[0].Cases[1].Error)
+	}
+	expect := strings.Join([]string{
+		"TAP version 13",
+		"1..2",
+		"ok 1 - " + file + " [RECV] test_pass",
+		"not ok 2 - " + file + " [RECV] failing suite",
+		"  ---",
+		"  message: " + strconv.Quote(assertion.Message),
+		"  severity: fail",
+		`  actual: "foo"`,
+		"  at:",
+		"    file: " + strconv.Quote(file),
+		"    line: 11",
+		`  source: "assert.equal(req.http.Foo, \"bar\");"`,
+		"  ...",
+		"",
+	}, "\n")
+	if diff := cmp.Diff(expect, buf.String()); diff != "" {
+		t.Errorf("Unexpected TAP output, diff=%s", diff)
+	}
+}
+
+func TestUnsupportedTestReporter(t *testing.T) {
+	if _, err := parseTestReporter("xunit"); err == nil {
+		t.Errorf("Expected error for unsupported reporter")
+	}
+}
+
+func TestReportHookFailure(t *testing.T) {
+	factory, _ := runReporterTest(t, `// @before_each
+sub setup {
+  assert.equal(req.http.Foo, "foo");
+}
+
+// @scope: recv
+sub test_recv {
+  testing.call_subroutine("vcl_recv");
+}
+`)
+
+	err := factory.Results[0].Cases[0].Error
+	he, ok := err.(*tester.HookError)
+	if !ok {
+		t.Fatalf("Expected hook error, got %v", err)
+	}
+	if he.Type != tester.BeforeEach || he.Name != "setup" {
+		t.Errorf("Unexpected hook error: %+v", he)
+	}
+
+	var buf bytes.Buffer
+	if err := reportJUnit(&buf, factory); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	var report junitTestSuites
+	if err := xml.Unmarshal(buf.Bytes(), &report); err != nil {
+		t.Fatalf("Failed to unmarshal JUnit XML: %s", err)
+	}
+	tc := report.Suites[0].Cases[0]
+	if tc.Failure != nil || tc.Error == nil || tc.Error.Type != "HookError" {
+		t.Errorf("Expected hook failure is reported as error, got %+v", tc)
+	}
+	if tc.Line != 3 {
+		t.Errorf("Expected line of the assertion in the hook, got %d", tc.Line)
+	}
+}
+
+func TestReportTableDrivenCases(t *testing.T) {
+	factory, file := runReporterTest(t, `table foo_cases STRING {
+  "foo": "foo",
+}
+
+// @scope: recv
+// @each: var.header = "foo", var.count = 1
+// @each: var.header = "bar, baz", var.count = -1
+// @each: foo_cases
+sub test_each {
+  testing.call_subroutine("vcl_recv");
+  assert.equal(req.http.Foo, var.header);
+}
+`)
+
+	var buf bytes.Buffer
+	if err := reportTAP(&buf, factory); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	var names []string
+	for _, line := range strings.Split(buf.String(), "\n") {
+		if strings.HasPrefix(line, "ok ") || strings.HasPrefix(line, "not ok ") {
+			names = append(names, line)
+		}
+	}
+	// Table items are bound to var.key and var.value so var.header is not declared in the third case
+	expect := []string{
+		"ok 1 - " + file + ` [RECV] test_each [var.header="foo", var.count=1]`,
+		"not ok 2 - " + file + ` [RECV] test_each [var.header="bar, baz", var.count=-1]`,
+		"not ok 3 - " + file + ` [RECV] test_each [var.key="foo", var.value="foo"]`,
+	}
+	if diff := cmp.Diff(expect, names); diff != "" {
+		t.Errorf("Unexpected table-driven test cases, diff=%s", diff)
+	}
+}
+
+const hookCountTestVCL = `table each_cases STRING {
+  "a": "1",
+  "b": "2",
+}
+
+// @before_all
+sub setup_all {
+  if (req.http.X-Before-All) {
+    set req.http.X-Before-All = "called twice";
+  } else {
+    set req.http.X-Before-All = "1";
+  }
+}
+
+// @before_each
+sub setup_each {
+  if (req.http.X-Each) {
+    set req.http.X-Each = req.http.X-Each "e";
+  } else {
+    set req.http.X-Each = "e";
+  }
+}
+
+// @after_all
+sub teardown_all {
+  assert.equal(req.http.X-Before-All, "1");
+  assert.equal(req.http.X-Each, "%s");
+}
+
+// @scope: recv
+sub test_first {
+  assert.equal(req.http.X-Before-All, "1");
+  assert.equal(req.http.X-Each, "e");
+}
+
+// @scope: recv
+// @each: each_cases
+sub test_each {
+  assert.equal(req.http.X-Before-All, "1");
+}
+
+// @scope: recv
+sub test_last {
+  assert.equal(req.http.X-Before-All, "1");
+  assert.equal(req.http.X-Each, "eeee");
+}
+`
+
+func TestHooksRunOncePerFile(t *testing.T) {
+	t.Run("all hooks run once around all test cases", func(t *testing.T) {
+		factory, _ := runReporterTest(t, fmt.Sprintf(hookCountTestVCL, "eeee"))
+		cases := factory.Results[0].Cases
+		if len(cases) != 4 {
+			t.Fatalf("Expected 4 test cases, got %d", len(cases))
+		}
+		for _, c := range cases {
+			if c.Error != nil {
+				t.Errorf("Test case %s raises error: %s", c.Name, c.Error)
+			}
+		}
+	})
+
+	t.Run("after_all failure is reported on the last test case", func(t *testing.T) {
+		factory, _ := runReporterTest(t, fmt.Sprintf(hookCountTestVCL, "e"))
+		cases := factory.Results[0].Cases
+		for _, c := range cases[:len(cases)-1] {
+			if c.Error != nil {
+				t.Errorf("Test case %s raises error: %s", c.Name, c.Error)
+			}
+		}
+		he, ok := cases[len(cases)-1].Error.(*tester.HookError)
+		if !ok || he.Type != tester.AfterAll {
+			t.Errorf("Expected after_all hook error on the last test case, got %v", cases[len(cases)-1].Error)
+		}
+	})
+}
@@ -10,6 +10,7 @@ import (
 type Transformer struct {
 	command string
 	bin     string
+	output  io.Writer
 }
 
 func NewTransformer(name string) (*Transformer, error) {
@@ -21,6 +22,7 @@ func NewTransformer(name string) (*Transformer, error) {
 	return &Transformer{
 		command: command,
 		bin:     bin,
+		output:  output,
 	}, nil
 }
 
@@ -34,8 +36,8 @@ func (t *Transformer) Execute(d io.Reader) error {
 }
 
 func (t *Transformer) Write(v []byte) (int, error) {
-	write(magenta, "["+t.command+"] ")
-	write(white, string(v))
+	fprint(t.output, magenta, "["+t.command+"] ")
+	fprint(t.output, white, string(v))
 
 	return len(v), nil
 }
@@ -14,12 +14,23 @@ func (c Commands) At(n int) string {
 }
 
 var needValueOptions = map[string]struct{}{
-	"-I":             {},
-	"--include_path": {},
-	"-t":             {},
-	"--transformer":  {},
-	"-f":             {},
-	"--filter":       {},
+	"-I":               {},
+	"--include_path":   {},
+	"-t":               {},
+	"--transformer":    {},
+	"-f":               {},
+	"--filter":         {},
+	"--format":         {},
+	"--plugin":         {},
+	"--parallel":       {},
+	"--baseline":       {},
+	"--write-baseline": {},
+	"--lint-cache-dir": {},
+	"--cache-dir":      {},
+	"--cache-capacity": {},
+	"--coverage-dir":   {},
+	"--reporter":       {},
+	"--reporter-file":  {},
 }
 
 func parseCommands(args []string) Commands {
@@ -18,6 +18,19 @@ type OverrideBackend struct {
 	Unhealthy bool   `yaml:"unhealthy" default:"false"`
 }
 
+// Custom lint rule which is declared in configuration
+type CustomRule struct {
+	Name          string   `yaml:"name"`
+	Message       string   `yaml:"message"`
+	Severity      string   `yaml:"severity"`
+	Node          string   `yaml:"node"`
+	Ident         string   `yaml:"ident"`
+	Function      string   `yaml:"function"`
+	Scopes        []string `yaml:"scopes"`
+	ExcludeScopes []string `yaml:"exclude_scopes"`
+	Require       bool     `yaml:"require"`
+}
+
 // Linter configuration
 type LinterConfig struct {
 	VerboseLevel            string              `yaml:"verbose"`
@@ -26,14 +39,29 @@ type LinterConfig struct {
 	Rules                   map[string]string   `yaml:"rules"`
 	EnforceSubroutineScopes map[string][]string `yaml:"enforce_subroutine_scopes"`
 	IgnoreSubroutines       []string            `yaml:"ignore_subroutines"`
+	Format                  string              `cli:"format" yaml:"format" default:"text"`
+	Fix                     bool                `cli:"fix"` // Enable only in CLI option
+	Plugins                 []string            `cli:"plugin" yaml:"plugins"`
+	PluginDir               string              `yaml:"plugin_dir"`
+	CustomRules             []*CustomRule       `yaml:"custom_rules"`
+	Cache                   bool                `cli:"cache" yaml:"cache"`
+	CacheDir                string              `cli:"lint-cache-dir" yaml:"cache_dir"`
+	Parallel                int                 `cli:"parallel" yaml:"parallel"`
+	Baseline                string              `cli:"baseline" yaml:"baseline"`
+	WriteBaseline           string              `cli:"write-baseline"` // Enable only in CLI option
 }
 
 // Simulator configuration
 type SimulatorConfig struct {
 	Port         int      `cli:"p,port" yaml:"port" default:"3124"`
 	IsDebug      bool     `cli:"debug"` // Enable only in CLI option
+	IsProxy      bool     `cli:"proxy"` // Enable only in CLI option
 	IncludePaths []string // Copy from root field
 
+	// Cache configuration
+	CacheDir      string `cli:"cache-dir" yaml:"cache_dir"`
+	CacheCapacity int    `cli:"cache-capacity" yaml:"cache_capacity"`
+
 	// Override Request configuration
 	OverrideRequest *RequestConfig
 }
@@ -45,10 +73,24 @@ type TestConfig struct {
 	IncludePaths []string // Copy from root field
 	OverrideHost string   `yaml:"host"`
 
+	// Coverage configuration
+	Coverage    bool   `cli:"coverage" yaml:"coverage"`
+	CoverageDir string `cli:"coverage-dir" yaml:"coverage_dir" default:"coverage"`
+
+	// Reporter configuration, result is written to the file in addition to console output
+	Reporter     string `cli:"reporter" yaml:"reporter"`
+	ReporterFile string `cli:"reporter-file" yaml:"reporter_file"`
+
 	// Override Request configuration
 	OverrideRequest *RequestConfig
 }
 
+// Format configuration
+type FormatConfig struct {
+	Overwrite bool `cli:"w,write"`
+	Check     bool `cli:"check"`
+}
+
 type Config struct {
 	// Root configurations
 	IncludePaths []string `cli:"I,include_path" yaml:"include_paths"`
@@ -79,6 +121,8 @@ type Config struct {
 	Simulator *SimulatorConfig `yaml:"simulator"`
 	// Testing configuration
 	Testing *TestConfig `yaml:"testing"`
+	// Format configuration
+	Format *FormatConfig `yaml:"format"`
 }
 
 func New(args []string) (*Config, error) {
@@ -32,6 +32,31 @@ func TestConfigFromCLI(t *testing.T) {
 		"-r",
 		"-V",
 		"--json",
+		"--format",
+		"sarif",
+		"--fix",
+		"--plugin",
+		"timeout",
+		"--cache",
+		"--lint-cache-dir",
+		".falco-lint-cache",
+		"--parallel",
+		"4",
+		"--baseline",
+		"old.json",
+		"--write-baseline",
+		"new.json",
+		"--cache-dir",
+		".falco-cache",
+		"--cache-capacity",
+		"100",
+		"--coverage",
+		"--coverage-dir",
+		"reports",
+		"--reporter",
+		"junit",
+		"--reporter-file",
+		"junit.xml",
 		"lint",
 	}
 	c, err := New(args)
@@ -52,17 +77,32 @@ func TestConfigFromCLI(t *testing.T) {
 			VerboseLevel:   "",
 			VerboseWarning: true,
 			VerboseInfo:    true,
+			Format:         "sarif",
+			Fix:            true,
+			Plugins:        []string{"timeout"},
+			Cache:          true,
+			CacheDir:       ".falco-lint-cache",
+			Parallel:       4,
+			Baseline:       "old.json",
+			WriteBaseline:  "new.json",
 		},
 		Simulator: &SimulatorConfig{
 			Port:            3124,
 			IncludePaths:    []string{"."},
+			CacheDir:        ".falco-cache",
+			CacheCapacity:   100,
 			OverrideRequest: &RequestConfig{},
 		},
 		Testing: &TestConfig{
 			Filter:          "*.test.vcl",
 			IncludePaths:    []string{"."},
+			Coverage:        true,
+			CoverageDir:     "reports",
+			Reporter:        "junit",
+			ReporterFile:    "junit.xml",
 			OverrideRequest: &RequestConfig{},
 		},
+		Format:           &FormatConfig{},
 		OverrideBackends: make(map[string]*OverrideBackend),
 	}
 
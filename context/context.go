@@ -663,6 +663,11 @@ func (c *Context) Unset(name string) error {
 	return nil
 }
 
+// Functions returns all builtin function definitions
+func (c *Context) Functions() Functions {
+	return c.functions
+}
+
 func (c *Context) GetFunction(name string) (*BuiltinFunction, error) {
 	first, remains := splitName(name)
 
@@ -150,7 +150,8 @@ func (c *Console) startDebugServer(port int) {
 		c.activate()
 		defer c.deactivate()
 
-		c.interpreter.ServeHTTP(w, r)
+		// Debugger inspects the interpreter state while processing, so process on the interpreter itself
+		c.interpreter.Serve(w, r)
 	})
 
 	s := &http.Server{
@@ -0,0 +1,182 @@
+package formatter
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+const diffContextLines = 3
+
+type diffOp int
+
+const (
+	diffEqual diffOp = iota
+	diffDelete
+	diffInsert
+)
+
+type diffLine struct {
+	op   diffOp
+	text string
+}
+
+// Diff returns unified diff string between original and formatted source.
+// If both are the same, returns empty string.
+func Diff(file, original, formatted string) string {
+	if original == formatted {
+		return ""
+	}
+
+	lines := diffLines(splitLines(original), splitLines(formatted))
+
+	var buf bytes.Buffer
+	buf.WriteString(fmt.Sprintf("--- %s\n", file))
+	buf.WriteString(fmt.Sprintf("+++ %s (formatted)\n", file))
+
+	// Walk lines and make hunks which contain changed lines with surrounded context lines
+	for i := 0; i < len(lines); {
+		if lines[i].op == diffEqual {
+			i++
+			continue
+		}
+		start := i - diffContextLines
+		if start < 0 {
+			start = 0
+		}
+		// Extend hunk end while changes are found within context distance
+		end := i
+		for end < len(lines) {
+			if lines[end].op != diffEqual {
+				end++
+				continue
+			}
+			next := end
+			for next < len(lines) && lines[next].op == diffEqual {
+				next++
+			}
+			if next == len(lines) || next-end > diffContextLines*2 {
+				break
+			}
+			end = next
+		}
+		end += diffContextLines
+		if end > len(lines) {
+			end = len(lines)
+		}
+
+		buf.WriteString(formatHunk(lines, start, end))
+		i = end
+	}
+
+	return buf.String()
+}
+
+func formatHunk(lines []diffLine, start, end int) string {
+	// Calculate line numbers for both sides
+	var oldStart, newStart int
+	for _, l := range lines[:start] {
+		if l.op != diffInsert {
+			oldStart++
+		}
+		if l.op != diffDelete {
+			newStart++
+		}
+	}
+
+	var oldCount, newCount int
+	var body bytes.Buffer
+	for _, l := range lines[start:end] {
+		switch l.op {
+		case diffEqual:
+			oldCount++
+			newCount++
+			body.WriteString(" " + l.text + "\n")
+		case diffDelete:
+			oldCount++
+			body.WriteString("-" + l.text + "\n")
+		case diffInsert:
+			newCount++
+			body.WriteString("+" + l.text + "\n")
+		}
+	}
+
+	return fmt.Sprintf(
+		"@@ -%s +%s @@\n%s",
+		hunkRange(oldStart, oldCount),
+		hunkRange(newStart, newCount),
+		body.String(),
+	)
+}
+
+func hunkRange(start, count int) string {
+	if count == 0 {
+		return fmt.Sprintf("%d,0", start)
+	}
+	return fmt.Sprintf("%d,%d", start+1, count)
+}
+
+func splitLines(s string) []string {
+	lines := strings.Split(s, "\n")
+	// Trim last empty line which is produced by final line feed
+	if len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// Calculate line based diff via longest common subsequence.
+// Common prefix and suffix lines are trimmed first because formatting changes are usually small.
+func diffLines(a, b []string) []diffLine {
+	var prefix, suffix []diffLine
+	for len(a) > 0 && len(b) > 0 && a[0] == b[0] {
+		prefix = append(prefix, diffLine{op: diffEqual, text: a[0]})
+		a, b = a[1:], b[1:]
+	}
+	for len(a) > 0 && len(b) > 0 && a[len(a)-1] == b[len(b)-1] {
+		suffix = append([]diffLine{{op: diffEqual, text: a[len(a)-1]}}, suffix...)
+		a, b = a[:len(a)-1], b[:len(b)-1]
+	}
+
+	// table[i][j] is the LCS length of a[i:] and b[j:]
+	table := make([][]int32, len(a)+1)
+	for i := range table {
+		table[i] = make([]int32, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				table[i][j] = table[i+1][j+1] + 1
+			} else if table[i+1][j] >= table[i][j+1] {
+				table[i][j] = table[i+1][j]
+			} else {
+				table[i][j] = table[i][j+1]
+			}
+		}
+	}
+
+	lines := prefix
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			lines = append(lines, diffLine{op: diffEqual, text: a[i]})
+			i++
+			j++
+		case table[i+1][j] >= table[i][j+1]:
+			lines = append(lines, diffLine{op: diffDelete, text: a[i]})
+			i++
+		default:
+			lines = append(lines, diffLine{op: diffInsert, text: b[j]})
+			j++
+		}
+	}
+	for ; i < len(a); i++ {
+		lines = append(lines, diffLine{op: diffDelete, text: a[i]})
+	}
+	for ; j < len(b); j++ {
+		lines = append(lines, diffLine{op: diffInsert, text: b[j]})
+	}
+
+	return append(lines, suffix...)
+}
@@ -0,0 +1,38 @@
+package formatter
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestDiff(t *testing.T) {
+	t.Run("no difference", func(t *testing.T) {
+		if diff := Diff("main.vcl", "foo\n", "foo\n"); diff != "" {
+			t.Errorf("Expected empty diff, got=%s", diff)
+		}
+	})
+
+	t.Run("unified diff", func(t *testing.T) {
+		original := "a\nb\nc\nd\ne\nf\ng\nh\ni\nj\nk\nl\n"
+		formatted := "a\nB\nc\nd\ne\nf\ng\nh\ni\nj\nk\nl\nm\n"
+		expect := `--- main.vcl
++++ main.vcl (formatted)
+@@ -1,5 +1,5 @@
+ a
+-b
++B
+ c
+ d
+ e
+@@ -10,3 +10,4 @@
+ j
+ k
+ l
++m
+`
+		if diff := cmp.Diff(expect, Diff("main.vcl", original, formatted)); diff != "" {
+			t.Errorf("Diff result mismatch, diff=%s", diff)
+		}
+	})
+}
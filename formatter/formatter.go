@@ -0,0 +1,512 @@
+package formatter
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/ysugimoto/falco/ast"
+	"github.com/ysugimoto/falco/token"
+)
+
+// Formatter prints VCL AST with canonical layout.
+// Each ast node has String() method but it is for debugging purpose,
+// so formatter walks the AST by itself to decide indentation, spacing around operators
+// and comment placement stably.
+type Formatter struct {
+	indent string
+}
+
+func New() *Formatter {
+	return &Formatter{
+		indent: "  ",
+	}
+}
+
+// Format formats root VCL
+func (f *Formatter) Format(vcl *ast.VCL) string {
+	return f.formatStatements(vcl.Statements, 0, true)
+}
+
+// FormatSnippet formats VCL snippet that is included inside subroutine
+func (f *Formatter) FormatSnippet(statements []ast.Statement) string {
+	return f.formatStatements(statements, 0, false)
+}
+
+func (f *Formatter) formatStatements(statements []ast.Statement, nest int, isRoot bool) string {
+	var buf bytes.Buffer
+
+	for i, stmt := range statements {
+		if i > 0 {
+			if isRoot {
+				// Put a blank line between root declarations except continuous include statements
+				if _, ok := stmt.(*ast.IncludeStatement); !ok {
+					buf.WriteString("\n")
+				} else if _, ok := statements[i-1].(*ast.IncludeStatement); !ok {
+					buf.WriteString("\n")
+				}
+			} else if firstLine(stmt)-lastLine(statements[i-1]) > 1 {
+				// Keep a single blank line which user put between statements
+				buf.WriteString("\n")
+			}
+		}
+		buf.WriteString(f.formatStatement(stmt, nest))
+	}
+
+	return buf.String()
+}
+
+func (f *Formatter) indentOf(nest int) string {
+	return strings.Repeat(f.indent, nest)
+}
+
+// Leading comments are placed on their own lines before the node
+func (f *Formatter) leading(cs ast.Comments, nest int) string {
+	var buf bytes.Buffer
+	for _, c := range cs {
+		buf.WriteString(f.indentOf(nest) + strings.TrimRight(c.Value, " \t\r") + "\n")
+	}
+	return buf.String()
+}
+
+// Trailing comments are placed at the end of line with a single space
+func (f *Formatter) trailing(cs ast.Comments) string {
+	if len(cs) == 0 {
+		return ""
+	}
+	comments := make([]string, len(cs))
+	for i, c := range cs {
+		comments[i] = strings.TrimRight(c.Value, " \t\r")
+	}
+	return " " + strings.Join(comments, " ")
+}
+
+// Inline comments are placed inside the line, e.g. between expressions.
+// Line comment must be converted to block comment not to comment out following code.
+func (f *Formatter) inline(cs ast.Comments) string {
+	if len(cs) == 0 {
+		return ""
+	}
+	comments := make([]string, len(cs))
+	for i, c := range cs {
+		comments[i] = toBlockComment(c.Value)
+	}
+	return strings.Join(comments, " ")
+}
+
+func toBlockComment(v string) string {
+	v = strings.TrimRight(v, " \t\r")
+	switch {
+	case strings.HasPrefix(v, "//"):
+		return "/* " + strings.TrimSpace(strings.TrimPrefix(v, "//")) + " */"
+	case strings.HasPrefix(v, "#"):
+		return "/* " + strings.TrimSpace(strings.TrimPrefix(v, "#")) + " */"
+	default:
+		return v
+	}
+}
+
+//nolint:gocyclo,funlen
+func (f *Formatter) formatStatement(stmt ast.Statement, nest int) string {
+	var buf bytes.Buffer
+	meta := stmt.GetMeta()
+
+	buf.WriteString(f.leading(meta.Leading, nest))
+	buf.WriteString(f.indentOf(nest))
+
+	switch t := stmt.(type) {
+	// Declarations
+	case *ast.AclDeclaration:
+		buf.WriteString(f.formatAclDeclaration(t))
+	case *ast.BackendDeclaration:
+		buf.WriteString(f.formatBackendDeclaration(t))
+	case *ast.DirectorDeclaration:
+		buf.WriteString(f.formatDirectorDeclaration(t))
+	case *ast.TableDeclaration:
+		buf.WriteString(f.formatTableDeclaration(t))
+	case *ast.SubroutineDeclaration:
+		buf.WriteString("sub " + f.formatExpression(t.Name))
+		if t.ReturnType != nil {
+			buf.WriteString(" " + f.formatExpression(t.ReturnType))
+		}
+		buf.WriteString(" " + f.formatBlock(t.Block, nest))
+	case *ast.PenaltyboxDeclaration:
+		buf.WriteString("penaltybox " + f.formatExpression(t.Name) + " " + f.formatBlock(t.Block, nest))
+	case *ast.RatecounterDeclaration:
+		buf.WriteString("ratecounter " + f.formatExpression(t.Name) + " " + f.formatBlock(t.Block, nest))
+
+	// Statements
+	case *ast.BlockStatement:
+		buf.WriteString(f.formatBlock(t, nest))
+	case *ast.ImportStatement:
+		buf.WriteString("import " + f.formatExpression(t.Name) + ";")
+	case *ast.IncludeStatement:
+		buf.WriteString("include " + f.formatExpression(t.Module) + ";")
+	case *ast.SetStatement:
+		buf.WriteString("set " + f.formatExpression(t.Ident) + " " + t.Operator.Operator + " " + f.formatExpression(t.Value) + ";")
+	case *ast.AddStatement:
+		buf.WriteString("add " + f.formatExpression(t.Ident) + " " + t.Operator.Operator + " " + f.formatExpression(t.Value) + ";")
+	case *ast.UnsetStatement:
+		buf.WriteString("unset " + f.formatExpression(t.Ident) + ";")
+	case *ast.RemoveStatement:
+		buf.WriteString("remove " + f.formatExpression(t.Ident) + ";")
+	case *ast.CallStatement:
+		buf.WriteString("call " + f.formatExpression(t.Subroutine) + ";")
+	case *ast.DeclareStatement:
+		buf.WriteString("declare local " + f.formatExpression(t.Name) + " " + f.formatExpression(t.ValueType) + ";")
+	case *ast.ErrorStatement:
+		buf.WriteString("error")
+		if t.Code != nil {
+			buf.WriteString(" " + f.formatExpression(t.Code))
+		}
+		if t.Argument != nil {
+			buf.WriteString(" " + f.formatExpression(t.Argument))
+		}
+		buf.WriteString(";")
+	case *ast.EsiStatement:
+		buf.WriteString("esi;")
+	case *ast.LogStatement:
+		buf.WriteString("log " + f.formatExpression(t.Value) + ";")
+	case *ast.RestartStatement:
+		buf.WriteString("restart;")
+	case *ast.ReturnStatement:
+		if t.ReturnExpression != nil {
+			buf.WriteString("return(" + f.formatExpression(*t.ReturnExpression) + ");")
+		} else {
+			buf.WriteString("return;")
+		}
+	case *ast.SyntheticStatement:
+		buf.WriteString("synthetic " + f.formatExpression(t.Value) + ";")
+	case *ast.SyntheticBase64Statement:
+		buf.WriteString("synthetic.base64 " + f.formatExpression(t.Value) + ";")
+	case *ast.IfStatement:
+		buf.WriteString(f.formatIfStatement(t, nest))
+	case *ast.SwitchStatement:
+		buf.WriteString(f.formatSwitchStatement(t, nest))
+	case *ast.BreakStatement:
+		buf.WriteString("break;")
+	case *ast.FallthroughStatement:
+		buf.WriteString("fallthrough;")
+	case *ast.GotoStatement:
+		buf.WriteString("goto " + f.formatExpression(t.Destination) + ";")
+	case *ast.GotoDestinationStatement:
+		buf.WriteString(t.Name.Value)
+	case *ast.FunctionCallStatement:
+		// Function ident shares comments with the statement which are already printed as statement comments
+		buf.WriteString(f.formatFunctionCall(t.Function.Value, t.Arguments) + ";")
+	default:
+		// Unknown statement, fallback to node's stringer
+		buf.WriteString(strings.TrimSpace(stmt.String()))
+	}
+
+	buf.WriteString(f.trailing(meta.Trailing))
+	buf.WriteString("\n")
+
+	return buf.String()
+}
+
+// Format block statement, starts with "{" and ends with "}" without line feed
+func (f *Formatter) formatBlock(block *ast.BlockStatement, nest int) string {
+	var buf bytes.Buffer
+
+	// Empty block is printed in a single line
+	if len(block.Statements) == 0 && len(block.Leading) == 0 && len(block.Infix) == 0 {
+		return "{}" + f.trailing(block.Trailing)
+	}
+
+	buf.WriteString("{")
+	// Comments between declaration name and LEFT_BRACE are placed after the brace
+	buf.WriteString(f.trailing(block.Leading))
+	buf.WriteString("\n")
+	buf.WriteString(f.formatStatements(block.Statements, nest+1, false))
+	buf.WriteString(f.leading(block.Infix, nest+1))
+	buf.WriteString(f.indentOf(nest) + "}")
+	buf.WriteString(f.trailing(block.Trailing))
+
+	return buf.String()
+}
+
+func (f *Formatter) formatIfStatement(stmt *ast.IfStatement, nest int) string {
+	var buf bytes.Buffer
+
+	buf.WriteString("if (" + f.formatExpression(stmt.Condition) + ") ")
+	buf.WriteString(f.formatBlock(stmt.Consequence, nest))
+
+	for _, a := range stmt.Another {
+		if len(a.Leading) > 0 {
+			buf.WriteString("\n")
+			buf.WriteString(f.leading(a.Leading, nest))
+			buf.WriteString(f.indentOf(nest))
+		} else {
+			buf.WriteString(" ")
+		}
+		buf.WriteString("else if (" + f.formatExpression(a.Condition) + ") ")
+		buf.WriteString(f.formatBlock(a.Consequence, nest))
+		buf.WriteString(f.trailing(a.Trailing))
+	}
+
+	if stmt.Alternative != nil {
+		if len(stmt.AlternativeComments) > 0 {
+			buf.WriteString("\n")
+			buf.WriteString(f.leading(stmt.AlternativeComments, nest))
+			buf.WriteString(f.indentOf(nest))
+		} else {
+			buf.WriteString(" ")
+		}
+		buf.WriteString("else ")
+		buf.WriteString(f.formatBlock(stmt.Alternative, nest))
+	}
+
+	return buf.String()
+}
+
+func (f *Formatter) formatSwitchStatement(stmt *ast.SwitchStatement, nest int) string {
+	var buf bytes.Buffer
+
+	buf.WriteString("switch (" + f.formatExpression(stmt.Control) + ") {\n")
+	for _, c := range stmt.Cases {
+		buf.WriteString(f.leading(c.Leading, nest))
+		buf.WriteString(f.indentOf(nest))
+		if c.Test != nil {
+			buf.WriteString("case ")
+			if c.Test.Operator == "~" {
+				buf.WriteString("~ ")
+			}
+			buf.WriteString(f.formatExpression(c.Test.Right) + ":")
+		} else {
+			buf.WriteString("default:")
+		}
+		buf.WriteString(f.trailing(c.Trailing))
+		buf.WriteString("\n")
+		buf.WriteString(f.formatStatements(c.Statements, nest+1, false))
+	}
+	buf.WriteString(f.leading(stmt.Infix, nest))
+	buf.WriteString(f.indentOf(nest) + "}")
+
+	return buf.String()
+}
+
+func (f *Formatter) formatAclDeclaration(acl *ast.AclDeclaration) string {
+	var buf bytes.Buffer
+
+	buf.WriteString("acl " + f.formatExpression(acl.Name) + " {\n")
+	for _, cidr := range acl.CIDRs {
+		buf.WriteString(f.leading(cidr.Leading, 1))
+		buf.WriteString(f.indentOf(1))
+		if cidr.Inverse != nil && cidr.Inverse.Value {
+			buf.WriteString("!")
+		}
+		buf.WriteString(`"` + cidr.IP.Value + `"`)
+		if cidr.Mask != nil {
+			buf.WriteString("/" + f.formatExpression(cidr.Mask))
+		}
+		buf.WriteString(";")
+		buf.WriteString(f.trailing(cidr.Trailing))
+		buf.WriteString("\n")
+	}
+	buf.WriteString(f.leading(acl.Infix, 1))
+	buf.WriteString("}")
+
+	return buf.String()
+}
+
+func (f *Formatter) formatBackendDeclaration(b *ast.BackendDeclaration) string {
+	var buf bytes.Buffer
+
+	buf.WriteString("backend " + f.formatExpression(b.Name) + " {\n")
+	for _, prop := range b.Properties {
+		buf.WriteString(f.formatBackendProperty(prop, 1))
+	}
+	buf.WriteString(f.leading(b.Infix, 1))
+	buf.WriteString("}")
+
+	return buf.String()
+}
+
+func (f *Formatter) formatBackendProperty(prop *ast.BackendProperty, nest int) string {
+	var buf bytes.Buffer
+
+	buf.WriteString(f.leading(prop.Leading, nest))
+	buf.WriteString(f.indentOf(nest) + "." + f.formatExpression(prop.Key) + " = ")
+	if probe, ok := prop.Value.(*ast.BackendProbeObject); ok {
+		buf.WriteString("{\n")
+		for _, v := range probe.Values {
+			buf.WriteString(f.formatBackendProperty(v, nest+1))
+		}
+		buf.WriteString(f.leading(probe.Infix, nest+1))
+		buf.WriteString(f.indentOf(nest) + "}")
+		buf.WriteString(f.trailing(probe.Trailing))
+	} else {
+		buf.WriteString(f.formatExpression(prop.Value) + ";")
+	}
+	buf.WriteString(f.trailing(prop.Trailing))
+	buf.WriteString("\n")
+
+	return buf.String()
+}
+
+func (f *Formatter) formatDirectorDeclaration(d *ast.DirectorDeclaration) string {
+	var buf bytes.Buffer
+
+	buf.WriteString("director " + f.formatExpression(d.Name))
+	if d.DirectorType != nil {
+		buf.WriteString(" " + f.formatExpression(d.DirectorType))
+	}
+	buf.WriteString(" {\n")
+	for _, prop := range d.Properties {
+		switch t := prop.(type) {
+		case *ast.DirectorProperty:
+			buf.WriteString(f.leading(t.Leading, 1))
+			buf.WriteString(f.indentOf(1) + "." + f.formatExpression(t.Key) + " = " + f.formatExpression(t.Value) + ";")
+			buf.WriteString(f.trailing(t.Trailing))
+		case *ast.DirectorBackendObject:
+			buf.WriteString(f.leading(t.Leading, 1))
+			buf.WriteString(f.indentOf(1) + "{")
+			for _, v := range t.Values {
+				buf.WriteString(" ." + f.formatExpression(v.Key) + " = " + f.formatExpression(v.Value) + ";")
+				if len(v.Trailing) > 0 {
+					buf.WriteString(" " + f.inline(v.Trailing))
+				}
+			}
+			buf.WriteString(" }")
+			buf.WriteString(f.trailing(t.Trailing))
+		}
+		buf.WriteString("\n")
+	}
+	buf.WriteString(f.leading(d.Infix, 1))
+	buf.WriteString("}")
+
+	return buf.String()
+}
+
+func (f *Formatter) formatTableDeclaration(t *ast.TableDeclaration) string {
+	var buf bytes.Buffer
+
+	buf.WriteString("table " + f.formatExpression(t.Name))
+	if t.ValueType != nil {
+		buf.WriteString(" " + f.formatExpression(t.ValueType))
+	}
+	if len(t.Properties) == 0 && len(t.Infix) == 0 {
+		buf.WriteString(" {}")
+		return buf.String()
+	}
+	buf.WriteString(" {\n")
+	for _, prop := range t.Properties {
+		buf.WriteString(f.leading(prop.Leading, 1))
+		buf.WriteString(f.indentOf(1) + f.formatExpression(prop.Key) + ": " + f.formatExpression(prop.Value) + ",")
+		buf.WriteString(f.trailing(prop.Trailing))
+		buf.WriteString("\n")
+	}
+	buf.WriteString(f.leading(t.Infix, 1))
+	buf.WriteString("}")
+
+	return buf.String()
+}
+
+func (f *Formatter) formatFunctionCall(fn string, args []ast.Expression) string {
+	var buf bytes.Buffer
+
+	buf.WriteString(fn + "(")
+	for i, arg := range args {
+		if i > 0 {
+			buf.WriteString(", ")
+		}
+		buf.WriteString(f.formatExpression(arg))
+	}
+	buf.WriteString(")")
+
+	return buf.String()
+}
+
+// Format expression in a single line
+func (f *Formatter) formatExpression(expr ast.Expression) string {
+	var buf bytes.Buffer
+	meta := expr.GetMeta()
+
+	if v := f.inline(meta.Leading); v != "" {
+		buf.WriteString(v + " ")
+	}
+
+	switch t := expr.(type) {
+	case *ast.Ident:
+		buf.WriteString(t.Value)
+	case *ast.IP:
+		buf.WriteString(t.Value)
+	case *ast.Boolean:
+		buf.WriteString(fmt.Sprintf("%t", t.Value))
+	case *ast.Integer:
+		buf.WriteString(literalOr(t.Meta, token.INT, fmt.Sprint(t.Value)))
+	case *ast.Float:
+		buf.WriteString(literalOr(t.Meta, token.FLOAT, fmt.Sprint(t.Value)))
+	case *ast.RTime:
+		buf.WriteString(t.Value)
+	case *ast.String:
+		buf.WriteString(formatString(t))
+	case *ast.PrefixExpression:
+		buf.WriteString(t.Operator + f.formatExpression(t.Right))
+	case *ast.PostfixExpression:
+		buf.WriteString(f.formatExpression(t.Left) + t.Operator)
+	case *ast.GroupedExpression:
+		buf.WriteString("(" + f.formatExpression(t.Right) + ")")
+	case *ast.InfixExpression:
+		buf.WriteString(f.formatInfixExpression(t))
+	case *ast.IfExpression:
+		buf.WriteString("if(" +
+			f.formatExpression(t.Condition) + ", " +
+			f.formatExpression(t.Consequence) + ", " +
+			f.formatExpression(t.Alternative) + ")",
+		)
+	case *ast.FunctionCallExpression:
+		buf.WriteString(f.formatFunctionCall(f.formatExpression(t.Function), t.Arguments))
+	default:
+		buf.WriteString(expr.String())
+	}
+
+	if v := f.inline(meta.Trailing); v != "" {
+		buf.WriteString(" " + v)
+	}
+
+	return buf.String()
+}
+
+func (f *Formatter) formatInfixExpression(expr *ast.InfixExpression) string {
+	left := f.formatExpression(expr.Left)
+
+	// String concatenation is parsed as infix expression with "+" operator.
+	// Explicit "+" operator token is parsed as prefix expression for the right expression
+	// so we need to unwrap it.
+	if expr.Operator == "+" {
+		if expr.Token.Type != token.PLUS {
+			// Implicit concatenation without "+" operator
+			return left + " " + f.formatExpression(expr.Right)
+		}
+		if prefix, ok := expr.Right.(*ast.PrefixExpression); ok && prefix.Operator == "+" {
+			right := f.formatExpression(prefix.Right)
+			if v := f.inline(prefix.Leading); v != "" {
+				right = v + " " + right
+			}
+			return left + " + " + right
+		}
+	}
+
+	return left + " " + expr.Operator + " " + f.formatExpression(expr.Right)
+}
+
+// Keep the original literal as much as possible. It is important not to change
+// escape sequences, float precision, and string quotes.
+func literalOr(meta *ast.Meta, tokenType token.TokenType, fallback string) string {
+	if meta.Token.Type == tokenType && meta.Token.Literal != "" {
+		return meta.Token.Literal
+	}
+	return fallback
+}
+
+func formatString(s *ast.String) string {
+	if s.Token.Type != token.STRING {
+		return `"` + s.Value + `"`
+	}
+	if s.Token.Offset == 4 { // offset=4 means bracket string
+		return `{"` + s.Token.Literal + `"}`
+	}
+	return `"` + s.Token.Literal + `"`
+}
@@ -0,0 +1,233 @@
+package formatter
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/ysugimoto/falco/lexer"
+	"github.com/ysugimoto/falco/parser"
+)
+
+func assertFormat(t *testing.T, input, expect string) {
+	vcl, err := parser.New(lexer.NewFromString(input)).ParseVCL()
+	if err != nil {
+		t.Errorf("unexpected parser error: %s", err)
+		t.FailNow()
+	}
+	formatted := New().Format(vcl)
+	if diff := cmp.Diff(expect, formatted); diff != "" {
+		t.Errorf("Formatted result mismatch, diff=%s", diff)
+	}
+
+	// Formatting must be idempotent
+	vcl, err = parser.New(lexer.NewFromString(formatted)).ParseVCL()
+	if err != nil {
+		t.Errorf("unexpected parser error for formatted VCL: %s", err)
+		t.FailNow()
+	}
+	if diff := cmp.Diff(formatted, New().Format(vcl)); diff != "" {
+		t.Errorf("Formatting is not idempotent, diff=%s", diff)
+	}
+}
+
+func TestFormatDeclarations(t *testing.T) {
+	t.Run("acl", func(t *testing.T) {
+		input := `acl internal {
+    "192.168.0.1"; // office
+  !"10.0.0.0"/8;
+  # last
+}`
+		expect := `acl internal {
+  "192.168.0.1"; // office
+  !"10.0.0.0"/8;
+  # last
+}
+`
+		assertFormat(t, input, expect)
+	})
+
+	t.Run("backend", func(t *testing.T) {
+		input := `backend F_origin {
+    .host = "example.com";
+    .probe = {
+       .request = "GET / HTTP/1.1" "Host: example.com";
+       .interval = 10s;
+    }
+}`
+		expect := `backend F_origin {
+  .host = "example.com";
+  .probe = {
+    .request = "GET / HTTP/1.1" "Host: example.com";
+    .interval = 10s;
+  }
+}
+`
+		assertFormat(t, input, expect)
+	})
+
+	t.Run("director", func(t *testing.T) {
+		input := `director d random {
+  .quorum   = 50%;
+  {   .backend = F_origin;   .weight = 1; }
+}`
+		expect := `director d random {
+  .quorum = 50%;
+  { .backend = F_origin; .weight = 1; }
+}
+`
+		assertFormat(t, input, expect)
+	})
+
+	t.Run("table", func(t *testing.T) {
+		input := `table t STRING {
+  "a": "b", // comment
+  "c":"d"
+}
+table empty {}`
+		expect := `table t STRING {
+  "a": "b", // comment
+  "c": "d",
+}
+
+table empty {}
+`
+		assertFormat(t, input, expect)
+	})
+
+	t.Run("include and import", func(t *testing.T) {
+		input := `import boltsort;
+include "foo";
+include "bar"
+sub vcl_recv {}`
+		expect := `import boltsort;
+
+include "foo";
+include "bar";
+
+sub vcl_recv {}
+`
+		assertFormat(t, input, expect)
+	})
+}
+
+func TestFormatStatements(t *testing.T) {
+	t.Run("indentation and spacing", func(t *testing.T) {
+		input := `sub vcl_recv {
+#FASTLY recv
+      declare   local var.x INTEGER;
+  set req.http.X = "a"+"b" req.http.Y   ;
+
+      set var.x  +=  1;
+  std.collect(req.http.Cookie  );
+  return (lookup);
+}`
+		expect := `sub vcl_recv {
+  #FASTLY recv
+  declare local var.x INTEGER;
+  set req.http.X = "a" + "b" req.http.Y;
+
+  set var.x += 1;
+  std.collect(req.http.Cookie);
+  return(lookup);
+}
+`
+		assertFormat(t, input, expect)
+	})
+
+	t.Run("if statement", func(t *testing.T) {
+		input := `sub vcl_recv {
+  if (req.http.A&&!req.http.B || (req.http.C=="1")) {
+    set req.http.D = "1"; // trailing
+  }
+  else if (req.http.D ~ "foo") { esi; }
+  // before else
+  else {
+    # nothing
+  }
+}`
+		expect := `sub vcl_recv {
+  if (req.http.A && !req.http.B || (req.http.C == "1")) {
+    set req.http.D = "1"; // trailing
+  } else if (req.http.D ~ "foo") {
+    esi;
+  }
+  // before else
+  else {
+    # nothing
+  }
+}
+`
+		assertFormat(t, input, expect)
+	})
+
+	t.Run("switch statement", func(t *testing.T) {
+		input := `sub vcl_recv {
+switch (req.http.Foo) {
+case "a":
+log "a";
+break;
+case ~"^b":
+log {"b"};
+fallthrough;
+default:
+break;
+}
+}`
+		expect := `sub vcl_recv {
+  switch (req.http.Foo) {
+  case "a":
+    log "a";
+    break;
+  case ~ "^b":
+    log {"b"};
+    fallthrough;
+  default:
+    break;
+  }
+}
+`
+		assertFormat(t, input, expect)
+	})
+
+	t.Run("comments of function call statement", func(t *testing.T) {
+		input := `sub vcl_recv {
+#FASTLY RECV
+// note
+std.collect(req.http.Cookie); // trailing
+}`
+		expect := `sub vcl_recv {
+  #FASTLY RECV
+  // note
+  std.collect(req.http.Cookie); // trailing
+}
+`
+		assertFormat(t, input, expect)
+	})
+
+	t.Run("inline comments are converted to block comment", func(t *testing.T) {
+		input := `sub vcl_recv {
+  set req.http.X = req.http.A # comment
+    req.http.B;
+}`
+		expect := `sub vcl_recv {
+  set req.http.X = req.http.A /* comment */ req.http.B;
+}
+`
+		assertFormat(t, input, expect)
+	})
+}
+
+func TestFormatSnippet(t *testing.T) {
+	statements, err := parser.New(lexer.NewFromString(`set req.http.A = "1";
+    esi;`)).ParseSnippetVCL()
+	if err != nil {
+		t.Errorf("unexpected parser error: %s", err)
+		t.FailNow()
+	}
+	expect := `set req.http.A = "1";
+esi;
+`
+	if diff := cmp.Diff(expect, New().FormatSnippet(statements)); diff != "" {
+		t.Errorf("Formatted result mismatch, diff=%s", diff)
+	}
+}
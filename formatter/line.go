@@ -0,0 +1,119 @@
+package formatter
+
+import (
+	"github.com/ysugimoto/falco/ast"
+)
+
+// Line helpers are used to detect blank lines between statements in the original source.
+// Note that AST does not hold the closing brace token, so we assume that
+// the closing brace of the block is placed on the next line of the last statement.
+
+func firstLine(stmt ast.Statement) int {
+	meta := stmt.GetMeta()
+	if len(meta.Leading) > 0 {
+		return meta.Leading[0].Token.Line
+	}
+	return meta.Token.Line
+}
+
+func lastLine(stmt ast.Statement) int {
+	meta := stmt.GetMeta()
+	line := metaLastLine(meta)
+
+	switch t := stmt.(type) {
+	case *ast.BlockStatement:
+		line = max(line, blockLastLine(t))
+	case *ast.IfStatement:
+		line = max(line, blockLastLine(t.Consequence))
+		for _, a := range t.Another {
+			line = max(line, blockLastLine(a.Consequence))
+		}
+		if t.Alternative != nil {
+			line = max(line, blockLastLine(t.Alternative))
+		}
+	case *ast.SwitchStatement:
+		for _, c := range t.Cases {
+			for _, s := range c.Statements {
+				line = max(line, lastLine(s))
+			}
+		}
+		line++
+	case *ast.SetStatement:
+		line = max(line, expressionLastLine(t.Value))
+	case *ast.AddStatement:
+		line = max(line, expressionLastLine(t.Value))
+	case *ast.ErrorStatement:
+		if t.Argument != nil {
+			line = max(line, expressionLastLine(t.Argument))
+		}
+	case *ast.LogStatement:
+		line = max(line, expressionLastLine(t.Value))
+	case *ast.SyntheticStatement:
+		line = max(line, expressionLastLine(t.Value))
+	case *ast.SyntheticBase64Statement:
+		line = max(line, expressionLastLine(t.Value))
+	case *ast.ReturnStatement:
+		if t.ReturnExpression != nil {
+			line = max(line, expressionLastLine(*t.ReturnExpression))
+		}
+	case *ast.FunctionCallStatement:
+		for _, arg := range t.Arguments {
+			line = max(line, expressionLastLine(arg))
+		}
+	}
+
+	return line
+}
+
+func blockLastLine(block *ast.BlockStatement) int {
+	line := metaLastLine(block.Meta)
+	for _, s := range block.Statements {
+		line = max(line, lastLine(s))
+	}
+	for _, c := range block.Infix {
+		line = max(line, c.Token.Line)
+	}
+	if len(block.Statements) > 0 || len(block.Infix) > 0 {
+		line++ // closing brace line
+	}
+	for _, c := range block.Trailing {
+		line = max(line, c.Token.Line)
+	}
+	return line
+}
+
+func expressionLastLine(expr ast.Expression) int {
+	line := metaLastLine(expr.GetMeta())
+
+	switch t := expr.(type) {
+	case *ast.PrefixExpression:
+		line = max(line, expressionLastLine(t.Right))
+	case *ast.PostfixExpression:
+		line = max(line, expressionLastLine(t.Left))
+	case *ast.GroupedExpression:
+		line = max(line, expressionLastLine(t.Right))
+	case *ast.InfixExpression:
+		line = max(line, expressionLastLine(t.Left), expressionLastLine(t.Right))
+	case *ast.IfExpression:
+		line = max(
+			line,
+			expressionLastLine(t.Condition),
+			expressionLastLine(t.Consequence),
+			expressionLastLine(t.Alternative),
+		)
+	case *ast.FunctionCallExpression:
+		for _, arg := range t.Arguments {
+			line = max(line, expressionLastLine(arg))
+		}
+	}
+
+	return line
+}
+
+func metaLastLine(meta *ast.Meta) int {
+	line := meta.Token.Line
+	for _, c := range meta.Trailing {
+		line = max(line, c.Token.Line)
+	}
+	return line
+}
@@ -1,7 +1,13 @@
-// Falco's interpreter cacheing is simply in-memory
+// Falco's interpreter cacheing is in-memory by default, and objects could be persisted by the storage
 package cache
 
 import (
+	"bytes"
+	"container/list"
+	"context"
+	"io"
+	"sort"
+	"strings"
 	"sync"
 	"time"
 
@@ -10,6 +16,9 @@ import (
 
 const (
 	LocalDatacenterString = "cache-localsimulator-FALCO"
+
+	// Default wait limit of the collapsed request, same as the default first_byte_timeout of the backend
+	DefaultCollapseTimeout = 15 * time.Second
 )
 
 type CacheItem struct {
@@ -19,6 +28,19 @@ type CacheItem struct {
 	Hits      int
 	LastUsed  time.Duration
 
+	// Stale windows after the object is expired
+	StaleWhileRevalidate time.Duration
+	StaleIfError         time.Duration
+
+	// Surrogate keys from the backend response to purge the object by key
+	SurrogateKeys []string
+
+	// Marker which is stored when vcl_fetch returns pass, requests for the hash are passed while it lives
+	HitForPass bool
+
+	// ESI is processed on every delivery when esi statement is executed in vcl_fetch
+	ESI bool
+
 	// private
 	requestedTime time.Time
 }
@@ -27,32 +49,131 @@ func (i *CacheItem) Update(d time.Duration) {
 	i.Expires = i.EntryTime.Add(d)
 }
 
+// Stale object could be served while revalidating until the window is passed, capped by maxStale
+func (i *CacheItem) CanServeWhileRevalidate(now time.Time, maxStale time.Duration) bool {
+	return now.Before(i.Expires.Add(min(i.StaleWhileRevalidate, maxStale)))
+}
+
+// Stale object could be served when the origin is failed until the window is passed, capped by maxStale
+func (i *CacheItem) CanServeIfError(now time.Time, maxStale time.Duration) bool {
+	return now.Before(i.Expires.Add(min(i.StaleIfError, maxStale)))
+}
+
+// Object is removed from the cache after both of stale windows are passed
+func (i *CacheItem) staleExpires() time.Time {
+	return i.Expires.Add(max(i.StaleWhileRevalidate, i.StaleIfError))
+}
+
 type Cache struct {
-	storage sync.Map
+	storage Storage
+	// Maximum number of objects, least recently used object is evicted when exceeded. Zero means unlimited
+	capacity int
+	// Recently used hashes are placed in front
+	lru      *list.List
+	elements map[string]*list.Element
+	// Called when the storage operation is failed
+	onError func(err error)
+
+	// Guard for updating cache item state and reading response body
+	mu sync.Mutex
+	// Hashes which are revalidating in background
+	revalidating map[string]struct{}
+	// Surrogate key -> hashes of the objects
+	keys map[string]map[string]struct{}
+	// Hashes which are being fetched, the channel is closed when the fetch is finished
+	busy map[string]chan struct{}
+	// Maximum duration that collapsed requests wait for the leader
+	collapseTimeout time.Duration
 }
 
-func New() *Cache {
-	return &Cache{}
+type Option func(c *Cache)
+
+// Use the storage instead of in-memory storage
+func WithStorage(s Storage) Option {
+	return func(c *Cache) {
+		c.storage = s
+	}
+}
+
+func WithCapacity(capacity int) Option {
+	return func(c *Cache) {
+		c.capacity = capacity
+	}
+}
+
+func WithCollapseTimeout(timeout time.Duration) Option {
+	return func(c *Cache) {
+		c.collapseTimeout = timeout
+	}
+}
+
+func WithErrorHandler(fn func(err error)) Option {
+	return func(c *Cache) {
+		c.onError = fn
+	}
+}
+
+func New(options ...Option) *Cache {
+	c := &Cache{
+		storage:      NewMemoryStorage(),
+		lru:          list.New(),
+		elements:     make(map[string]*list.Element),
+		onError:      func(err error) {},
+		revalidating: make(map[string]struct{}),
+		keys:         make(map[string]map[string]struct{}),
+		busy:         make(map[string]chan struct{}),
+
+		collapseTimeout: DefaultCollapseTimeout,
+	}
+	for i := range options {
+		options[i](c)
+	}
+
+	// Build indexes of the objects which have been stored in the storage, older object is evicted first
+	type stored struct {
+		hash string
+		item *CacheItem
+	}
+	var items []stored
+	c.storage.Range(func(hash string, item *CacheItem) bool {
+		items = append(items, stored{hash: hash, item: item})
+		return true
+	})
+	sort.Slice(items, func(i, j int) bool {
+		return items[i].item.EntryTime.After(items[j].item.EntryTime)
+	})
+	for _, v := range items {
+		c.elements[v.hash] = c.lru.PushBack(v.hash)
+		c.index(v.hash, v.item)
+	}
+	c.evict()
+	return c
 }
 
 func (c *Cache) Set(hash string, item *CacheItem) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if old := c.load(hash); old != nil {
+		c.delete(hash, old)
+	}
 	item.requestedTime = item.EntryTime
-	c.storage.Store(hash, item)
+	if item.Response != nil {
+		item.SurrogateKeys = strings.Fields(item.Response.Header.Get("Surrogate-Key"))
+	}
+	c.index(hash, item)
+	c.elements[hash] = c.lru.PushFront(hash)
+	c.save(hash, item)
+	c.evict()
 }
 
+// Get returns fresh cache item, stale item is not returned
 func (c *Cache) Get(hash string) *CacheItem {
-	// Load and cast to *CacheItem
-	v, ok := c.storage.Load(hash)
-	if !ok {
-		return nil
-	}
-	item, ok := v.(*CacheItem)
-	if !ok {
-		return nil
-	}
-	// Check expiration
-	if time.Now().After(item.Expires) {
-		c.storage.Delete(hash)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	item := c.load(hash)
+	if item == nil || time.Now().After(item.Expires) {
 		return nil
 	}
 
@@ -60,18 +181,271 @@ func (c *Cache) Get(hash string) *CacheItem {
 	item.Hits++
 	item.LastUsed = time.Since(item.requestedTime)
 	item.requestedTime = time.Now()
+	c.lru.MoveToFront(c.elements[hash])
+	c.save(hash, item)
+
+	return item.copy()
+}
+
+// Stale returns the cache item which is expired but still in the stale windows
+func (c *Cache) Stale(hash string) *CacheItem {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	item := c.load(hash)
+	if item == nil || !time.Now().After(item.Expires) {
+		return nil
+	}
+	c.lru.MoveToFront(c.elements[hash])
+	return item.copy()
+}
+
+// Update modifies the stored cache item, e.g. TTL and grace are changed in vcl_hit
+func (c *Cache) Update(hash string, fn func(item *CacheItem)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if item := c.load(hash); item != nil {
+		fn(item)
+		c.save(hash, item)
+	}
+}
+
+// Entry is the summary of the cached object for inspection
+type Entry struct {
+	Hash          string   `json:"hash"`
+	Status        int      `json:"status"`
+	TTL           float64  `json:"ttl"` // remaining TTL in seconds, negative value means the object is stale
+	Hits          int      `json:"hits"`
+	SurrogateKeys []string `json:"surrogate_keys"`
+	HitForPass    bool     `json:"hit_for_pass"`
+}
+
+// Entries returns summaries of all cached objects in recently used order
+func (c *Cache) Entries() []Entry {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	entries := []Entry{}
+	for e := c.lru.Front(); e != nil; {
+		// Get next element before load because expired object is removed from the list
+		next := e.Next()
+		hash := e.Value.(string) // nolint:errcheck
+		if item := c.load(hash); item != nil {
+			entry := Entry{
+				Hash:          hash,
+				TTL:           item.Expires.Sub(now).Seconds(),
+				Hits:          item.Hits,
+				SurrogateKeys: item.SurrogateKeys,
+				HitForPass:    item.HitForPass,
+			}
+			if entry.SurrogateKeys == nil {
+				entry.SurrogateKeys = []string{}
+			}
+			if item.Response != nil {
+				entry.Status = item.Response.StatusCode
+			}
+			entries = append(entries, entry)
+		}
+		e = next
+	}
+	return entries
+}
+
+// Purge removes the object of the hash.
+// Soft purge marks the object as stale instead so that it could be served in the stale windows.
+func (c *Cache) Purge(hash string, soft bool) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.purge(hash, soft)
+}
+
+// PurgeKey purges all objects which are tagged with the surrogate key, returns number of purged objects
+func (c *Cache) PurgeKey(key string, soft bool) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var purged int
+	for hash := range c.keys[key] {
+		if c.purge(hash, soft) {
+			purged++
+		}
+	}
+	return purged
+}
+
+// PurgeAll removes all objects, returns number of purged objects
+func (c *Cache) PurgeAll() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var hashes []string
+	c.storage.Range(func(hash string, item *CacheItem) bool {
+		hashes = append(hashes, hash)
+		return true
+	})
+	var purged int
+	for _, hash := range hashes {
+		if c.purge(hash, false) {
+			purged++
+		}
+	}
+	return purged
+}
+
+// Caller must hold the lock
+func (c *Cache) purge(hash string, soft bool) bool {
+	item := c.load(hash)
+	if item == nil {
+		return false
+	}
+	if !soft {
+		c.delete(hash, item)
+		return true
+	}
+	if now := time.Now(); item.Expires.After(now) {
+		item.Expires = now
+		c.save(hash, item)
+	}
+	return true
+}
+
+// Collapse concurrent misses for the same hash.
+// The first request becomes the leader which fetches from the origin and must call done after the cache is updated.
+// Other requests wait until the leader calls done, and then should look up the cache again.
+// Waiting is limited by the collapse timeout and the request context, e.g. the leader's origin requests
+// the same hash to the simulator itself. Then the request is also treated as leader and fetches from the origin.
+func (c *Cache) Collapse(ctx context.Context, hash string) (done func(), leader bool) {
+	c.mu.Lock()
+	if ch, ok := c.busy[hash]; ok {
+		c.mu.Unlock()
+		timer := time.NewTimer(c.collapseTimeout)
+		defer timer.Stop()
+
+		select {
+		case <-ch:
+			return func() {}, false
+		case <-timer.C:
+		case <-ctx.Done():
+		}
+		return func() {}, true
+	}
+	ch := make(chan struct{})
+	c.busy[hash] = ch
+	c.mu.Unlock()
+
+	return func() {
+		c.mu.Lock()
+		delete(c.busy, hash)
+		c.mu.Unlock()
+		close(ch)
+	}, true
+}
+
+// Mark the hash as revalidating. Returns false if it is already revalidating
+// because only one background fetch should run for the same object
+func (c *Cache) BeginRevalidate(hash string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, ok := c.revalidating[hash]; ok {
+		return false
+	}
+	c.revalidating[hash] = struct{}{}
+	return true
+}
+
+func (c *Cache) EndRevalidate(hash string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.revalidating, hash)
+}
+
+// Load the item, the item is deleted if all stale windows are passed.
+// Caller must hold the lock
+func (c *Cache) load(hash string) *CacheItem {
+	item := c.storage.Load(hash)
+	if item == nil {
+		return nil
+	}
+	if time.Now().After(item.staleExpires()) {
+		c.delete(hash, item)
+		return nil
+	}
 	return item
 }
 
-// Fastly follows its own cache freshness rules
-// see: https://developer.fastly.com/learning/concepts/cache-freshness/
-var unCacheableStatusCodes = []int{200, 203, 300, 301, 302, 404, 410}
+// Store the item to the storage. Caller must hold the lock
+func (c *Cache) save(hash string, item *CacheItem) {
+	if err := c.storage.Store(hash, item); err != nil {
+		c.onError(err)
+	}
+}
+
+// Add surrogate key indexes of the item. Caller must hold the lock
+func (c *Cache) index(hash string, item *CacheItem) {
+	for _, key := range item.SurrogateKeys {
+		if _, ok := c.keys[key]; !ok {
+			c.keys[key] = make(map[string]struct{})
+		}
+		c.keys[key][hash] = struct{}{}
+	}
+}
+
+// Delete the item and its indexes. Caller must hold the lock
+func (c *Cache) delete(hash string, item *CacheItem) {
+	if err := c.storage.Delete(hash); err != nil {
+		c.onError(err)
+	}
+	if e, ok := c.elements[hash]; ok {
+		c.lru.Remove(e)
+		delete(c.elements, hash)
+	}
+	for _, key := range item.SurrogateKeys {
+		delete(c.keys[key], hash)
+		if len(c.keys[key]) == 0 {
+			delete(c.keys, key)
+		}
+	}
+}
 
-func IsCacheableStatusCode(statusCode int) bool {
-	for _, v := range unCacheableStatusCodes {
-		if v == statusCode {
-			return true
+// Evict least recently used objects until the number of objects fits in the capacity. Caller must hold the lock
+func (c *Cache) evict() {
+	for c.capacity > 0 && c.lru.Len() > c.capacity {
+		hash := c.lru.Back().Value.(string) // nolint:errcheck
+		if item := c.storage.Load(hash); item != nil {
+			c.delete(hash, item)
+		} else {
+			c.lru.Remove(c.lru.Back())
+			delete(c.elements, hash)
 		}
 	}
-	return false
+}
+
+// Cache item is shared between concurrent requests so return the copy of it
+func (i *CacheItem) copy() *CacheItem {
+	copied := *i
+	copied.Response = cloneResponse(i.Response)
+	return &copied
+}
+
+func cloneResponse(resp *http.Response) *http.Response {
+	if resp == nil {
+		return nil
+	}
+	var buf bytes.Buffer
+	if resp.Body != nil {
+		buf.ReadFrom(resp.Body) // nolint: errcheck
+	}
+	// rewind body reader
+	resp.Body = io.NopCloser(bytes.NewReader(buf.Bytes()))
+
+	cloned := *resp
+	cloned.Header = resp.Header.Clone()
+	cloned.Trailer = resp.Trailer.Clone()
+	cloned.Body = io.NopCloser(bytes.NewReader(buf.Bytes()))
+	return &cloned
 }
@@ -0,0 +1,157 @@
+package cache
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Default TTL which is used when the backend response does not have any freshness information
+const DefaultTTL = 2 * time.Minute
+
+// Fastly caches responses which have these status codes by default.
+// Other responses are cacheable only when they have explicit freshness information
+// see: https://developer.fastly.com/learning/concepts/cache-freshness/
+var cacheableStatusCodes = map[int]struct{}{
+	http.StatusOK:                   {},
+	http.StatusNonAuthoritativeInfo: {},
+	http.StatusMultipleChoices:      {},
+	http.StatusMovedPermanently:     {},
+	http.StatusFound:                {},
+	http.StatusNotFound:             {},
+	http.StatusGone:                 {},
+}
+
+func IsCacheableStatusCode(statusCode int) bool {
+	_, ok := cacheableStatusCodes[statusCode]
+	return ok
+}
+
+// Freshness is the calculated cache strategy of the backend response,
+// which are initial values of beresp.cacheable, beresp.ttl, beresp.stale_while_revalidate and beresp.stale_if_error
+type Freshness struct {
+	Cacheable            bool
+	TTL                  time.Duration
+	StaleWhileRevalidate time.Duration
+	StaleIfError         time.Duration
+}
+
+// Calculate freshness of the backend response following Fastly's rules:
+//
+//  1. Surrogate-Control: max-age
+//  2. Cache-Control: s-maxage
+//  3. Cache-Control: max-age
+//  4. Expires (relative to Date header)
+//  5. Default TTL
+//
+// Age header is subtracted from max-age and s-maxage because the response could have been cached in the upstream.
+// Expires is an absolute time so Age is not subtracted from it.
+// Surrogate-Control is only for Fastly so Cache-Control directives are ignored when Surrogate-Control has max-age.
+func CalculateFreshness(resp *http.Response, now time.Time) Freshness {
+	sc := parseDirectives(resp.Header.Values("Surrogate-Control"))
+	cc := parseDirectives(resp.Header.Values("Cache-Control"))
+
+	var f Freshness
+	ttl, explicit := explicitTTL(resp, sc, cc, now)
+	if explicit {
+		f.TTL = max(ttl, 0)
+	} else {
+		f.TTL = DefaultTTL
+	}
+	f.Cacheable = IsCacheableStatusCode(resp.StatusCode) || explicit
+
+	// Private or non-storable responses are never cached
+	if _, ok := sc["no-store"]; ok {
+		f.Cacheable, f.TTL = false, 0
+	}
+	if _, ok := sc["max-age"]; !ok {
+		for _, name := range []string{"private", "no-store", "no-cache"} {
+			if _, ok := cc[name]; ok {
+				f.Cacheable, f.TTL = false, 0
+			}
+		}
+	}
+	// Response which sets cookie should not be shared with other users
+	if resp.Header.Get("Set-Cookie") != "" {
+		f.Cacheable = false
+	}
+
+	// Stale windows, Surrogate-Control takes precedence as well
+	// ref: https://developer.fastly.com/learning/concepts/stale/
+	for _, d := range []map[string]string{sc, cc} {
+		if v, ok := seconds(d, "stale-while-revalidate"); ok && f.StaleWhileRevalidate == 0 {
+			f.StaleWhileRevalidate = v
+		}
+		if v, ok := seconds(d, "stale-if-error"); ok && f.StaleIfError == 0 {
+			f.StaleIfError = v
+		}
+	}
+	return f
+}
+
+func explicitTTL(resp *http.Response, sc, cc map[string]string, now time.Time) (time.Duration, bool) {
+	if v, ok := seconds(sc, "max-age"); ok {
+		return v - age(resp), true
+	}
+	if v, ok := seconds(cc, "s-maxage"); ok {
+		return v - age(resp), true
+	}
+	if v, ok := seconds(cc, "max-age"); ok {
+		return v - age(resp), true
+	}
+	if v := resp.Header.Get("Expires"); v != "" {
+		// Invalid Expires value like "0" means already expired
+		expires, err := http.ParseTime(v)
+		if err != nil {
+			return 0, true
+		}
+		date := now
+		if d, err := http.ParseTime(resp.Header.Get("Date")); err == nil {
+			date = d
+		}
+		return expires.Sub(date), true
+	}
+	return 0, false
+}
+
+// Age header value, invalid value is treated as zero
+func age(resp *http.Response) time.Duration {
+	v, err := strconv.ParseInt(strings.TrimSpace(resp.Header.Get("Age")), 10, 64)
+	if err != nil || v < 0 {
+		return 0
+	}
+	return time.Duration(v) * time.Second
+}
+
+// Parse comma separated directives like "public, max-age=60" to lower-cased key and value map.
+// First one is used when the same directive is specified multiple times.
+func parseDirectives(values []string) map[string]string {
+	directives := make(map[string]string)
+	for _, value := range values {
+		for _, directive := range strings.Split(value, ",") {
+			key, val, _ := strings.Cut(strings.TrimSpace(directive), "=")
+			key = strings.ToLower(strings.TrimSpace(key))
+			if key == "" {
+				continue
+			}
+			if _, ok := directives[key]; !ok {
+				directives[key] = strings.Trim(strings.TrimSpace(val), `"`)
+			}
+		}
+	}
+	return directives
+}
+
+// Get delta-seconds value of the directive
+func seconds(directives map[string]string, key string) (time.Duration, bool) {
+	v, ok := directives[key]
+	if !ok {
+		return 0, false
+	}
+	sec, err := strconv.ParseInt(v, 10, 64)
+	if err != nil || sec < 0 {
+		return 0, false
+	}
+	return time.Duration(sec) * time.Second, true
+}
@@ -0,0 +1,137 @@
+package cache
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestCalculateFreshness(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	date := now.Format(http.TimeFormat)
+
+	tests := []struct {
+		name    string
+		status  int
+		headers map[string]string
+		expect  Freshness
+	}{
+		{
+			name:   "default TTL",
+			status: http.StatusOK,
+			expect: Freshness{Cacheable: true, TTL: DefaultTTL},
+		},
+		{
+			name:    "Surrogate-Control takes precedence over Cache-Control",
+			status:  http.StatusOK,
+			headers: map[string]string{"Surrogate-Control": "max-age=300", "Cache-Control": "s-maxage=60, max-age=30"},
+			expect:  Freshness{Cacheable: true, TTL: 300 * time.Second},
+		},
+		{
+			name:    "s-maxage takes precedence over max-age",
+			status:  http.StatusOK,
+			headers: map[string]string{"Cache-Control": "public, max-age=30, s-maxage=60"},
+			expect:  Freshness{Cacheable: true, TTL: 60 * time.Second},
+		},
+		{
+			name:    "max-age which is not the first directive",
+			status:  http.StatusOK,
+			headers: map[string]string{"Cache-Control": "public, max-age=30"},
+			expect:  Freshness{Cacheable: true, TTL: 30 * time.Second},
+		},
+		{
+			name:    "Expires relative to Date",
+			status:  http.StatusOK,
+			headers: map[string]string{"Date": date, "Expires": now.Add(time.Hour).Format(http.TimeFormat)},
+			expect:  Freshness{Cacheable: true, TTL: time.Hour},
+		},
+		{
+			name:    "invalid Expires is already expired",
+			status:  http.StatusOK,
+			headers: map[string]string{"Expires": "0"},
+			expect:  Freshness{Cacheable: true},
+		},
+		{
+			name:    "Age is subtracted",
+			status:  http.StatusOK,
+			headers: map[string]string{"Cache-Control": "max-age=60", "Age": "20"},
+			expect:  Freshness{Cacheable: true, TTL: 40 * time.Second},
+		},
+		{
+			name:    "Age is greater than max-age",
+			status:  http.StatusOK,
+			headers: map[string]string{"Cache-Control": "max-age=60", "Age": "120"},
+			expect:  Freshness{Cacheable: true},
+		},
+		{
+			name:    "Age is not subtracted from Expires",
+			status:  http.StatusOK,
+			headers: map[string]string{"Date": date, "Expires": now.Add(time.Hour).Format(http.TimeFormat), "Age": "600"},
+			expect:  Freshness{Cacheable: true, TTL: time.Hour},
+		},
+		{
+			name:    "Age is subtracted from s-maxage",
+			status:  http.StatusOK,
+			headers: map[string]string{"Cache-Control": "s-maxage=60", "Expires": "0", "Age": "20"},
+			expect:  Freshness{Cacheable: true, TTL: 40 * time.Second},
+		},
+		{
+			name:    "private response",
+			status:  http.StatusOK,
+			headers: map[string]string{"Cache-Control": "private, max-age=60"},
+			expect:  Freshness{},
+		},
+		{
+			name:    "no-store response",
+			status:  http.StatusOK,
+			headers: map[string]string{"Cache-Control": "no-store"},
+			expect:  Freshness{},
+		},
+		{
+			name:    "no-cache is ignored when Surrogate-Control has max-age",
+			status:  http.StatusOK,
+			headers: map[string]string{"Cache-Control": "no-cache", "Surrogate-Control": "max-age=60"},
+			expect:  Freshness{Cacheable: true, TTL: 60 * time.Second},
+		},
+		{
+			name:    "Surrogate-Control no-store",
+			status:  http.StatusOK,
+			headers: map[string]string{"Surrogate-Control": "no-store", "Cache-Control": "max-age=60"},
+			expect:  Freshness{},
+		},
+		{
+			name:    "response which sets cookie",
+			status:  http.StatusOK,
+			headers: map[string]string{"Cache-Control": "max-age=60", "Set-Cookie": "session=foo"},
+			expect:  Freshness{TTL: 60 * time.Second},
+		},
+		{
+			name:   "uncacheable status code",
+			status: http.StatusInternalServerError,
+			expect: Freshness{TTL: DefaultTTL},
+		},
+		{
+			name:    "uncacheable status code with explicit freshness",
+			status:  http.StatusNoContent,
+			headers: map[string]string{"Cache-Control": "max-age=60"},
+			expect:  Freshness{Cacheable: true, TTL: 60 * time.Second},
+		},
+		{
+			name:    "stale windows",
+			status:  http.StatusOK,
+			headers: map[string]string{"Surrogate-Control": "max-age=60, stale-if-error=86400", "Cache-Control": "stale-while-revalidate=30, stale-if-error=60"},
+			expect:  Freshness{Cacheable: true, TTL: 60 * time.Second, StaleWhileRevalidate: 30 * time.Second, StaleIfError: 86400 * time.Second},
+		},
+	}
+
+	for _, tt := range tests {
+		resp := &http.Response{StatusCode: tt.status, Header: http.Header{}}
+		for k, v := range tt.headers {
+			resp.Header.Set(k, v)
+		}
+		f := CalculateFreshness(resp, now)
+		if f != tt.expect {
+			t.Errorf("%s: expect %+v, got %+v", tt.name, tt.expect, f)
+		}
+	}
+}
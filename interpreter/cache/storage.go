@@ -0,0 +1,199 @@
+package cache
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Storage is the pluggable backend of cache objects.
+// Cache calls storage methods with holding its lock so implementations do not need to be goroutine safe.
+// Store is also called when the stored item is mutated, e.g. hit count is incremented.
+type Storage interface {
+	Load(hash string) *CacheItem
+	Store(hash string, item *CacheItem) error
+	Delete(hash string) error
+	Range(fn func(hash string, item *CacheItem) bool)
+}
+
+// MemoryStorage stores cache objects in-memory, objects are lost when the process is exited
+type MemoryStorage struct {
+	items map[string]*CacheItem
+}
+
+func NewMemoryStorage() *MemoryStorage {
+	return &MemoryStorage{
+		items: make(map[string]*CacheItem),
+	}
+}
+
+func (s *MemoryStorage) Load(hash string) *CacheItem {
+	return s.items[hash]
+}
+
+func (s *MemoryStorage) Store(hash string, item *CacheItem) error {
+	s.items[hash] = item
+	return nil
+}
+
+func (s *MemoryStorage) Delete(hash string) error {
+	delete(s.items, hash)
+	return nil
+}
+
+func (s *MemoryStorage) Range(fn func(hash string, item *CacheItem) bool) {
+	for hash, item := range s.items {
+		if !fn(hash, item) {
+			return
+		}
+	}
+}
+
+// DiskStorage persists cache objects to the directory so that they are kept across the simulator restart.
+// Objects are also kept in-memory and the files are written through.
+type DiskStorage struct {
+	*MemoryStorage
+	dir string
+}
+
+// Create disk storage and load objects which have been persisted in the directory
+func NewDiskStorage(dir string) (*DiskStorage, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	s := &DiskStorage{
+		MemoryStorage: NewMemoryStorage(),
+		dir:           dir,
+	}
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	for _, file := range files {
+		buf, err := os.ReadFile(file)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		var v diskItem
+		if err := json.Unmarshal(buf, &v); err != nil {
+			return nil, errors.Wrapf(err, "Failed to load cache object %s", file)
+		}
+		s.items[v.Hash] = v.cacheItem()
+	}
+	return s, nil
+}
+
+func (s *DiskStorage) Store(hash string, item *CacheItem) error {
+	s.items[hash] = item
+
+	buf, err := json.Marshal(newDiskItem(hash, item))
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	// Write to the temporary file and rename it to avoid leaving a broken file
+	tmp, err := os.CreateTemp(s.dir, ".tmp-*")
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer os.Remove(tmp.Name()) // nolint:errcheck
+	if _, err := tmp.Write(buf); err != nil {
+		tmp.Close() // nolint:errcheck
+		return errors.WithStack(err)
+	}
+	if err := tmp.Close(); err != nil {
+		return errors.WithStack(err)
+	}
+	return errors.WithStack(os.Rename(tmp.Name(), s.filename(hash)))
+}
+
+func (s *DiskStorage) Delete(hash string) error {
+	delete(s.items, hash)
+	if err := os.Remove(s.filename(hash)); err != nil && !os.IsNotExist(err) {
+		return errors.WithStack(err)
+	}
+	return nil
+}
+
+// Hash may contain any characters so use digest of it as filename
+func (s *DiskStorage) filename(hash string) string {
+	sum := sha256.Sum256([]byte(hash))
+	return filepath.Join(s.dir, hex.EncodeToString(sum[:])+".json")
+}
+
+// Serializable representation of the cache item
+type diskItem struct {
+	Hash                 string        `json:"hash"`
+	StatusCode           int           `json:"status_code"`
+	Header               http.Header   `json:"header"`
+	Body                 []byte        `json:"body"`
+	Expires              time.Time     `json:"expires"`
+	EntryTime            time.Time     `json:"entry_time"`
+	Hits                 int           `json:"hits"`
+	StaleWhileRevalidate time.Duration `json:"stale_while_revalidate"`
+	StaleIfError         time.Duration `json:"stale_if_error"`
+	SurrogateKeys        []string      `json:"surrogate_keys"`
+	HitForPass           bool          `json:"hit_for_pass"`
+	ESI                  bool          `json:"esi"`
+}
+
+func newDiskItem(hash string, item *CacheItem) *diskItem {
+	v := &diskItem{
+		Hash:                 hash,
+		Expires:              item.Expires,
+		EntryTime:            item.EntryTime,
+		Hits:                 item.Hits,
+		StaleWhileRevalidate: item.StaleWhileRevalidate,
+		StaleIfError:         item.StaleIfError,
+		SurrogateKeys:        item.SurrogateKeys,
+		HitForPass:           item.HitForPass,
+		ESI:                  item.ESI,
+	}
+	if resp := item.Response; resp != nil {
+		v.StatusCode = resp.StatusCode
+		v.Header = resp.Header
+		if resp.Body != nil {
+			var buf bytes.Buffer
+			buf.ReadFrom(resp.Body) // nolint: errcheck
+			// rewind body reader
+			resp.Body = io.NopCloser(bytes.NewReader(buf.Bytes()))
+			v.Body = buf.Bytes()
+		}
+	}
+	return v
+}
+
+func (v *diskItem) cacheItem() *CacheItem {
+	item := &CacheItem{
+		Expires:              v.Expires,
+		EntryTime:            v.EntryTime,
+		Hits:                 v.Hits,
+		StaleWhileRevalidate: v.StaleWhileRevalidate,
+		StaleIfError:         v.StaleIfError,
+		SurrogateKeys:        v.SurrogateKeys,
+		HitForPass:           v.HitForPass,
+		ESI:                  v.ESI,
+		requestedTime:        v.EntryTime,
+	}
+	if v.StatusCode > 0 {
+		item.Response = &http.Response{
+			StatusCode:    v.StatusCode,
+			Status:        http.StatusText(v.StatusCode),
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        v.Header,
+			Body:          io.NopCloser(bytes.NewReader(v.Body)),
+			ContentLength: int64(len(v.Body)),
+		}
+	}
+	return item
+}
@@ -0,0 +1,95 @@
+package cache
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func newTestItem(body string, header http.Header) *CacheItem {
+	now := time.Now()
+	return &CacheItem{
+		Response: &http.Response{
+			StatusCode: http.StatusOK,
+			Header:     header,
+			Body:       io.NopCloser(bytes.NewReader([]byte(body))),
+		},
+		Expires:   now.Add(time.Minute),
+		EntryTime: now,
+	}
+}
+
+func hashes(c *Cache) []string {
+	var ret []string
+	for _, e := range c.Entries() {
+		ret = append(ret, e.Hash)
+	}
+	return ret
+}
+
+func TestDiskStorage(t *testing.T) {
+	dir := t.TempDir()
+	storage, err := NewDiskStorage(dir)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	c := New(WithStorage(storage))
+	c.Set("/foo", newTestItem("foo", http.Header{"Surrogate-Key": {"product"}}))
+	c.Set("/bar", newTestItem("bar", http.Header{}))
+	c.Set("/baz", newTestItem("baz", http.Header{"Surrogate-Key": {"product"}}))
+	c.Get("/foo")
+	if !c.Purge("/bar", false) {
+		t.Errorf("Expected /bar is purged")
+	}
+
+	// Simulate restart
+	storage, err = NewDiskStorage(dir)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	c = New(WithStorage(storage))
+	item := c.Get("/foo")
+	if item == nil {
+		t.Fatalf("Expected /foo is persisted")
+	}
+	if item.Hits != 2 {
+		t.Errorf("Expected hit count is persisted, got %d", item.Hits)
+	}
+	body, err := io.ReadAll(item.Response.Body)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if string(body) != "foo" {
+		t.Errorf("Expected body is persisted, got %s", string(body))
+	}
+	if c.Get("/bar") != nil {
+		t.Errorf("Expected purged object is not persisted")
+	}
+	// Surrogate key indexes are rebuilt
+	if n := c.PurgeKey("product", false); n != 2 {
+		t.Errorf("Expected 2 objects are purged by surrogate key, got %d", n)
+	}
+}
+
+func TestCacheCapacity(t *testing.T) {
+	c := New(WithCapacity(2))
+	c.Set("/foo", newTestItem("foo", http.Header{}))
+	c.Set("/bar", newTestItem("bar", http.Header{}))
+	// /foo becomes most recently used
+	c.Get("/foo")
+	c.Set("/baz", newTestItem("baz", http.Header{}))
+
+	if diff := cmp.Diff([]string{"/baz", "/foo"}, hashes(c)); diff != "" {
+		t.Errorf("Least recently used object is not evicted, diff=%s", diff)
+	}
+	if c.PurgeAll() != 2 {
+		t.Errorf("Expected 2 objects are purged")
+	}
+	if len(c.Entries()) != 0 {
+		t.Errorf("Expected all objects are purged")
+	}
+}
@@ -0,0 +1,53 @@
+package interpreter
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/rs/xid"
+	"github.com/ysugimoto/falco/interpreter/cache"
+)
+
+// Admin endpoint to inspect cached objects
+const CachePath = "/__falco/cache"
+
+// Replace the cache, e.g. use persistent storage or limit the capacity.
+// The cache is shared with forked interpreters so this must be called before serving requests.
+func (i *Interpreter) SetCache(c *cache.Cache) {
+	i.cache = c
+}
+
+// Serve admin cache endpoint:
+//
+//	GET    /__falco/cache             : list cached objects
+//	DELETE /__falco/cache?hash={hash} : delete the object of the hash
+//	DELETE /__falco/cache             : delete all objects
+func (i *Interpreter) serveCache(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		enc.Encode(i.cache.Entries()) // nolint:errcheck
+	case http.MethodDelete:
+		if !r.URL.Query().Has("hash") {
+			purged := i.cache.PurgeAll()
+			i.Debugger.Message(fmt.Sprintf("Delete all cache objects (deleted: %d)", purged))
+		} else {
+			hash := r.URL.Query().Get("hash")
+			if !i.cache.Purge(hash, false) {
+				http.Error(w, "cache object is not found", http.StatusNotFound)
+				return
+			}
+			i.Debugger.Message(fmt.Sprintf("Delete cache object %s", hash))
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		w.Write(purgeResult(xid.New().String())) // nolint:errcheck
+	default:
+		w.Header().Set("Allow", "GET, DELETE")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
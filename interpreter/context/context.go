@@ -9,6 +9,7 @@ import (
 	"github.com/ysugimoto/falco/ast"
 	"github.com/ysugimoto/falco/config"
 	"github.com/ysugimoto/falco/interpreter/cache"
+	"github.com/ysugimoto/falco/interpreter/ratelimit"
 	"github.com/ysugimoto/falco/interpreter/value"
 	"github.com/ysugimoto/falco/resolver"
 	"github.com/ysugimoto/falco/snippets"
@@ -52,7 +53,7 @@ type Context struct {
 	Subroutines         map[string]*ast.SubroutineDeclaration
 	Penaltyboxes        map[string]*ast.PenaltyboxDeclaration
 	Ratecounters        map[string]*ast.RatecounterDeclaration
-	Gotos               map[string]*ast.GotoStatement
+	Gotos               map[string]*ast.GotoDestinationStatement
 	SubroutineFunctions map[string]*ast.SubroutineDeclaration
 	OriginalHost        string
 
@@ -70,6 +71,13 @@ type Context struct {
 	RequestEndTime   time.Time
 	RequestStartTime time.Time
 	CacheHitItem     *cache.CacheItem
+	// Expired object which could be delivered by return(deliver_stale)
+	StaleItem *cache.CacheItem
+
+	// Ratecounter and penaltybox states are shared between requests.
+	// Ratecounter variables refer to the entry which is incremented last in the request.
+	Ratelimit          *ratelimit.Ratelimit
+	RatecounterEntries map[string]string
 
 	// Interpreter states, following variables could be set in each subroutine directives
 	Restarts                            int
@@ -82,7 +90,9 @@ type Context struct {
 	Stale                               *value.Boolean
 	StaleIsError                        *value.Boolean
 	StaleIsRevalidating                 *value.Boolean
-	StaleContents                       *value.String
+	StaleExists                         *value.Boolean
+	IsBackgroundFetch                   *value.Boolean
+	IsPurge                             *value.Boolean
 	FastlyError                         *value.String
 	ClientIdentity                      *value.String
 	ClientGeoIpOverride                 *value.String
@@ -98,6 +108,7 @@ type Context struct {
 	HashIgnoreBusy                      *value.Boolean
 	SegmentedCacheingBlockSize          *value.Integer
 	ESILevel                            *value.Integer
+	TopURL                              *value.String // URL of the top-level request, only set for ESI sub-requests
 	WafAnomalyScore                     *value.Integer
 	WafBlocked                          *value.Boolean
 	WafCounter                          *value.Integer
@@ -129,7 +140,6 @@ type Context struct {
 	BackendResponsePCI                  *value.Boolean
 	BackendResponseResponse             *value.String
 	BackendResponseSaintMode            *value.RTime
-	BackendResponseStaleIfError         *value.RTime
 	BackendResponseStaleWhileRevalidate *value.RTime
 	BackendResponseStatus               *value.Integer
 	BackendResponseTTL                  *value.RTime
@@ -144,6 +154,8 @@ type Context struct {
 	ReturnState     *value.String
 	FixedTime       *time.Time
 	SubroutineCalls map[string]int
+	// Subroutine names in the order they are called
+	VisitedSubroutines []string
 
 	// Regex captured values like "re.group.N" and local declared variables are volatile,
 	// reset this when process is outgoing for each subroutines
@@ -158,6 +170,8 @@ type Context struct {
 	// However, Fastly document says the esi will be triggered when esi statement is executed in FETCH directive.
 	// see: https://developer.fastly.com/reference/vcl/statements/esi/
 	TriggerESI bool
+	// Request is passed in vcl_recv or by hit-for-pass, the backend response must not be cached
+	PassRequest bool
 }
 
 func New(options ...Option) *Context {
@@ -168,7 +182,7 @@ func New(options ...Option) *Context {
 		Subroutines:         make(map[string]*ast.SubroutineDeclaration),
 		Penaltyboxes:        make(map[string]*ast.PenaltyboxDeclaration),
 		Ratecounters:        make(map[string]*ast.RatecounterDeclaration),
-		Gotos:               make(map[string]*ast.GotoStatement),
+		Gotos:               make(map[string]*ast.GotoDestinationStatement),
 		SubroutineFunctions: make(map[string]*ast.SubroutineDeclaration),
 		OverrideBackends:    make(map[string]*config.OverrideBackend),
 
@@ -182,7 +196,9 @@ func New(options ...Option) *Context {
 		Stale:                           &value.Boolean{},
 		StaleIsError:                    &value.Boolean{},
 		StaleIsRevalidating:             &value.Boolean{},
-		StaleContents:                   &value.String{},
+		StaleExists:                     &value.Boolean{},
+		IsBackgroundFetch:               &value.Boolean{},
+		IsPurge:                         &value.Boolean{},
 		FastlyError:                     &value.String{},
 		ClientGeoIpOverride:             &value.String{},
 		ClientSocketCongestionAlgorithm: &value.String{Value: "cubic"},
@@ -197,6 +213,7 @@ func New(options ...Option) *Context {
 		HashIgnoreBusy:                  &value.Boolean{},
 		SegmentedCacheingBlockSize:      &value.Integer{},
 		ESILevel:                        &value.Integer{},
+		TopURL:                          &value.String{},
 		RequestHash:                     &value.String{},
 
 		// The format of the request ID is not documented.
@@ -235,7 +252,6 @@ func New(options ...Option) *Context {
 		BackendResponsePCI:                  &value.Boolean{},
 		BackendResponseResponse:             &value.String{},
 		BackendResponseSaintMode:            &value.RTime{},
-		BackendResponseStaleIfError:         &value.RTime{},
 		BackendResponseStaleWhileRevalidate: &value.RTime{},
 		BackendResponseStatus:               &value.Integer{},
 		BackendResponseTTL:                  &value.RTime{},
@@ -248,6 +264,8 @@ func New(options ...Option) *Context {
 
 		RegexMatchedValues: make(map[string]*value.String),
 		SubroutineCalls:    make(map[string]int),
+		Ratelimit:          ratelimit.New(),
+		RatecounterEntries: make(map[string]string),
 	}
 
 	// collect options
@@ -0,0 +1,176 @@
+package coverage
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const coberturaDocType = `<!DOCTYPE coverage SYSTEM "http://cobertura.sourceforge.net/xml/coverage-04.dtd">`
+
+type coberturaCoverage struct {
+	XMLName         xml.Name           `xml:"coverage"`
+	LineRate        string             `xml:"line-rate,attr"`
+	BranchRate      string             `xml:"branch-rate,attr"`
+	LinesCovered    int                `xml:"lines-covered,attr"`
+	LinesValid      int                `xml:"lines-valid,attr"`
+	BranchesCovered int                `xml:"branches-covered,attr"`
+	BranchesValid   int                `xml:"branches-valid,attr"`
+	Complexity      string             `xml:"complexity,attr"`
+	Version         string             `xml:"version,attr"`
+	Timestamp       int64              `xml:"timestamp,attr"`
+	Sources         []string           `xml:"sources>source"`
+	Packages        []coberturaPackage `xml:"packages>package"`
+}
+
+type coberturaPackage struct {
+	Name       string           `xml:"name,attr"`
+	LineRate   string           `xml:"line-rate,attr"`
+	BranchRate string           `xml:"branch-rate,attr"`
+	Complexity string           `xml:"complexity,attr"`
+	Classes    []coberturaClass `xml:"classes>class"`
+}
+
+type coberturaClass struct {
+	Name       string            `xml:"name,attr"`
+	Filename   string            `xml:"filename,attr"`
+	LineRate   string            `xml:"line-rate,attr"`
+	BranchRate string            `xml:"branch-rate,attr"`
+	Complexity string            `xml:"complexity,attr"`
+	Methods    []coberturaMethod `xml:"methods>method"`
+	Lines      []coberturaLine   `xml:"lines>line"`
+}
+
+type coberturaMethod struct {
+	Name       string          `xml:"name,attr"`
+	Signature  string          `xml:"signature,attr"`
+	LineRate   string          `xml:"line-rate,attr"`
+	BranchRate string          `xml:"branch-rate,attr"`
+	Complexity string          `xml:"complexity,attr"`
+	Lines      []coberturaLine `xml:"lines>line"`
+}
+
+type coberturaLine struct {
+	Number            int    `xml:"number,attr"`
+	Hits              int    `xml:"hits,attr"`
+	Branch            bool   `xml:"branch,attr"`
+	ConditionCoverage string `xml:"condition-coverage,attr,omitempty"`
+}
+
+// Line and branch counters of lines
+func countLines(lines []*LineReport) (Counter, Counter) {
+	var lc, bc Counter
+	for _, l := range lines {
+		lc.add(l.Hits)
+		for _, b := range l.Branches {
+			for _, hits := range b.Hits {
+				bc.add(hits)
+			}
+		}
+	}
+	return lc, bc
+}
+
+func rate(c Counter) string {
+	return fmt.Sprintf("%.4f", c.Rate())
+}
+
+func coberturaLines(lines []*LineReport) []coberturaLine {
+	ret := make([]coberturaLine, 0, len(lines))
+	for _, l := range lines {
+		line := coberturaLine{Number: l.Line, Hits: l.Hits}
+		if len(l.Branches) > 0 {
+			_, bc := countLines([]*LineReport{l})
+			line.Branch = true
+			line.ConditionCoverage = fmt.Sprintf("%d%% (%d/%d)", bc.Covered*100/bc.Total, bc.Covered, bc.Total)
+		}
+		ret = append(ret, line)
+	}
+	return ret
+}
+
+// Write report as Cobertura XML format, files are grouped to the package per directory
+// see: https://github.com/cobertura/cobertura/blob/master/cobertura/src/site/htdocs/xml/coverage-04.dtd
+func (r *Report) WriteCobertura(w io.Writer, timestamp time.Time) error {
+	packages := make(map[string]*coberturaPackage)
+	lines := make(map[string][]*LineReport)
+	var allLines []*LineReport
+
+	for _, f := range r.Files {
+		dir := filepath.Dir(f.Name)
+		if _, ok := packages[dir]; !ok {
+			packages[dir] = &coberturaPackage{Name: dir, Complexity: "0"}
+		}
+		lines[dir] = append(lines[dir], f.Lines...)
+		allLines = append(allLines, f.Lines...)
+
+		lc, bc := countLines(f.Lines)
+		class := coberturaClass{
+			Name:       f.Name,
+			Filename:   f.Name,
+			LineRate:   rate(lc),
+			BranchRate: rate(bc),
+			Complexity: "0",
+			Lines:      coberturaLines(f.Lines),
+		}
+		for _, s := range f.Subroutines {
+			var subLines []*LineReport
+			for _, l := range f.Lines {
+				if l.Subroutine == s.Name {
+					subLines = append(subLines, l)
+				}
+			}
+			lc, bc := countLines(subLines)
+			class.Methods = append(class.Methods, coberturaMethod{
+				Name:       s.Name,
+				LineRate:   rate(lc),
+				BranchRate: rate(bc),
+				Complexity: "0",
+				Lines:      coberturaLines(subLines),
+			})
+		}
+		packages[dir].Classes = append(packages[dir].Classes, class)
+	}
+
+	lc, bc := countLines(allLines)
+	v := coberturaCoverage{
+		LineRate:        rate(lc),
+		BranchRate:      rate(bc),
+		LinesCovered:    lc.Covered,
+		LinesValid:      lc.Total,
+		BranchesCovered: bc.Covered,
+		BranchesValid:   bc.Total,
+		Complexity:      "0",
+		Version:         "falco",
+		Timestamp:       timestamp.Unix(),
+		Sources:         []string{"."},
+	}
+	names := make([]string, 0, len(packages))
+	for name := range packages {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		p := packages[name]
+		lc, bc := countLines(lines[name])
+		p.LineRate = rate(lc)
+		p.BranchRate = rate(bc)
+		v.Packages = append(v.Packages, *p)
+	}
+
+	if _, err := io.WriteString(w, xml.Header+coberturaDocType+"\n"); err != nil {
+		return errors.WithStack(err)
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(v); err != nil {
+		return errors.WithStack(err)
+	}
+	_, err := io.WriteString(w, "\n")
+	return errors.WithStack(err)
+}
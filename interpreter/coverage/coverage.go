@@ -0,0 +1,212 @@
+package coverage
+
+import (
+	"sync"
+
+	"github.com/ysugimoto/falco/ast"
+)
+
+// Location of the node in VCL files
+type Location struct {
+	File     string
+	Line     int
+	Position int
+}
+
+// Nodes which are not parsed from source like boilerplate macros do not have location
+func locationOf(node ast.Node) (Location, bool) {
+	meta := node.GetMeta()
+	if meta == nil {
+		return Location{}, false
+	}
+	return Location{
+		File:     meta.Token.File,
+		Line:     meta.Token.Line,
+		Position: meta.Token.Position,
+	}, true
+}
+
+type subroutineEntry struct {
+	name string
+	line int
+	hits int
+}
+
+type statementEntry struct {
+	subroutine string
+	hits       int
+}
+
+// Branches of if or switch statement.
+// if statement has branches of consequence, each else-if and else (even if it is omitted),
+// switch statement has branches of each case and default (even if it is omitted).
+type branchEntry struct {
+	subroutine string
+	hits       []int
+}
+
+// Coverage collects statement, branch and subroutine hits of the VCL program.
+// Only registered nodes are recorded so that statements in testing VCL are never counted.
+// All methods are safe to call with nil receiver, then nothing is recorded.
+type Coverage struct {
+	mu          sync.Mutex
+	subroutines map[Location]*subroutineEntry
+	statements  map[Location]*statementEntry
+	branches    map[Location]*branchEntry
+}
+
+func New() *Coverage {
+	return &Coverage{
+		subroutines: make(map[Location]*subroutineEntry),
+		statements:  make(map[Location]*statementEntry),
+		branches:    make(map[Location]*branchEntry),
+	}
+}
+
+// Register subroutines and their statements in root statements of the program
+func (c *Coverage) Register(statements []ast.Statement) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, stmt := range statements {
+		sub, ok := stmt.(*ast.SubroutineDeclaration)
+		if !ok {
+			continue
+		}
+		loc, ok := locationOf(sub)
+		if !ok {
+			continue
+		}
+		if _, ok := c.subroutines[loc]; !ok {
+			c.subroutines[loc] = &subroutineEntry{
+				name: sub.Name.Value,
+				line: sub.GetMeta().Token.Line,
+			}
+		}
+		c.register(sub.Name.Value, sub.Block.Statements)
+	}
+}
+
+// Register statements of registered subroutine which are resolved on runtime like include statement
+func (c *Coverage) RegisterSubroutine(sub *ast.SubroutineDeclaration, statements []ast.Statement) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	loc, ok := locationOf(sub)
+	if !ok {
+		return
+	}
+	if _, ok := c.subroutines[loc]; ok {
+		c.register(sub.Name.Value, statements)
+	}
+}
+
+func (c *Coverage) register(subroutine string, statements []ast.Statement) {
+	for _, stmt := range statements {
+		switch t := stmt.(type) {
+		case *ast.IncludeStatement, *ast.GotoDestinationStatement:
+			// Not executable statements
+			continue
+		case *ast.BlockStatement:
+			c.register(subroutine, t.Statements)
+		case *ast.IfStatement:
+			c.registerBranch(subroutine, t, len(t.Another)+2)
+			c.register(subroutine, t.Consequence.Statements)
+			for _, another := range t.Another {
+				c.register(subroutine, another.Consequence.Statements)
+			}
+			if t.Alternative != nil {
+				c.register(subroutine, t.Alternative.Statements)
+			}
+		case *ast.SwitchStatement:
+			size := len(t.Cases)
+			if t.Default == -1 {
+				size++
+			}
+			c.registerBranch(subroutine, t, size)
+			for _, cs := range t.Cases {
+				c.register(subroutine, cs.Statements)
+			}
+		}
+
+		loc, ok := locationOf(stmt)
+		if !ok {
+			continue
+		}
+		if _, ok := c.statements[loc]; !ok {
+			c.statements[loc] = &statementEntry{subroutine: subroutine}
+		}
+	}
+}
+
+func (c *Coverage) registerBranch(subroutine string, stmt ast.Statement, size int) {
+	loc, ok := locationOf(stmt)
+	if !ok {
+		return
+	}
+	if _, ok := c.branches[loc]; !ok {
+		c.branches[loc] = &branchEntry{
+			subroutine: subroutine,
+			hits:       make([]int, size),
+		}
+	}
+}
+
+// Record subroutine is called
+func (c *Coverage) Subroutine(sub *ast.SubroutineDeclaration) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	loc, ok := locationOf(sub)
+	if !ok {
+		return
+	}
+	if v, ok := c.subroutines[loc]; ok {
+		v.hits++
+	}
+}
+
+// Record statement is executed
+func (c *Coverage) Statement(stmt ast.Statement) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	loc, ok := locationOf(stmt)
+	if !ok {
+		return
+	}
+	if v, ok := c.statements[loc]; ok {
+		v.hits++
+	}
+}
+
+// Record the branch of if or switch statement is taken.
+// For if statement, index 0 is consequence, following indexes are else-if and the last one is else.
+// For switch statement, index is case offset and the last one is omitted default case.
+func (c *Coverage) Branch(stmt ast.Statement, index int) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	loc, ok := locationOf(stmt)
+	if !ok {
+		return
+	}
+	if v, ok := c.branches[loc]; ok && index >= 0 && index < len(v.hits) {
+		v.hits[index]++
+	}
+}
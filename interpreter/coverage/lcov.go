@@ -0,0 +1,60 @@
+package coverage
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/pkg/errors"
+)
+
+// Write report as lcov tracefile format
+// see: https://github.com/linux-test-project/lcov/blob/master/man/geninfo.1
+func (r *Report) WriteLcov(w io.Writer) error {
+	buf := bufio.NewWriter(w)
+	for _, f := range r.Files {
+		fmt.Fprintln(buf, "TN:")
+		fmt.Fprintf(buf, "SF:%s\n", f.Name)
+
+		var functions Counter
+		for _, s := range f.Subroutines {
+			fmt.Fprintf(buf, "FN:%d,%s\n", s.Line, s.Name)
+		}
+		for _, s := range f.Subroutines {
+			fmt.Fprintf(buf, "FNDA:%d,%s\n", s.Hits, s.Name)
+			functions.add(s.Hits)
+		}
+		fmt.Fprintf(buf, "FNF:%d\n", functions.Total)
+		fmt.Fprintf(buf, "FNH:%d\n", functions.Covered)
+
+		var branches Counter
+		var block int
+		for _, l := range f.Lines {
+			for _, b := range l.Branches {
+				for index, hits := range b.Hits {
+					// "-" indicates the statement which has the branches has never been executed
+					taken := "-"
+					if b.Evaluated {
+						taken = strconv.Itoa(hits)
+					}
+					fmt.Fprintf(buf, "BRDA:%d,%d,%d,%s\n", l.Line, block, index, taken)
+					branches.add(hits)
+				}
+				block++
+			}
+		}
+		fmt.Fprintf(buf, "BRF:%d\n", branches.Total)
+		fmt.Fprintf(buf, "BRH:%d\n", branches.Covered)
+
+		var lines Counter
+		for _, l := range f.Lines {
+			fmt.Fprintf(buf, "DA:%d,%d\n", l.Line, l.Hits)
+			lines.add(l.Hits)
+		}
+		fmt.Fprintf(buf, "LF:%d\n", lines.Total)
+		fmt.Fprintf(buf, "LH:%d\n", lines.Covered)
+		fmt.Fprintln(buf, "end_of_record")
+	}
+	return errors.WithStack(buf.Flush())
+}
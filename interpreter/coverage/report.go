@@ -0,0 +1,197 @@
+package coverage
+
+import (
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Counter holds the number of covered items of total
+type Counter struct {
+	Covered int `json:"covered"`
+	Total   int `json:"total"`
+}
+
+func (c *Counter) add(hits int) {
+	c.Total++
+	if hits > 0 {
+		c.Covered++
+	}
+}
+
+func (c *Counter) merge(v Counter) {
+	c.Covered += v.Covered
+	c.Total += v.Total
+}
+
+// Rate returns the covered ratio in 0.0 - 1.0, returns 1.0 if there is nothing to cover
+func (c Counter) Rate() float64 {
+	if c.Total == 0 {
+		return 1
+	}
+	return float64(c.Covered) / float64(c.Total)
+}
+
+// Report is the coverage result aggregated per file and subroutine
+type Report struct {
+	Files       []*FileReport `json:"files"`
+	Subroutines Counter       `json:"subroutines"`
+	Statements  Counter       `json:"statements"`
+	Branches    Counter       `json:"branches"`
+}
+
+type FileReport struct {
+	Name        string              `json:"name"`
+	Subroutines []*SubroutineReport `json:"subroutines"`
+	Statements  Counter             `json:"statements"`
+	Branches    Counter             `json:"branches"`
+	Lines       []*LineReport       `json:"-"`
+}
+
+type SubroutineReport struct {
+	Name       string  `json:"name"`
+	Line       int     `json:"line"`
+	Hits       int     `json:"hits"`
+	Statements Counter `json:"statements"`
+	Branches   Counter `json:"branches"`
+}
+
+// LineReport is the coverage of a line, hits is the maximum count of statements in the line
+type LineReport struct {
+	Line       int
+	Hits       int
+	Subroutine string
+	Branches   []*BranchReport
+}
+
+// BranchReport holds hit counts for each branch of if or switch statement.
+// Evaluated is false when the statement itself has never been executed.
+type BranchReport struct {
+	Hits      []int
+	Evaluated bool
+}
+
+// Create coverage report. Filenames are reported as relative path from base directory if possible.
+func (c *Coverage) Report(base string) *Report {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	files := make(map[string]*FileReport)
+	subroutines := make(map[[2]string]*SubroutineReport)
+	lines := make(map[string]map[int]*LineReport)
+
+	// Reserved subroutines may be concatenated from multiple files so hits are summarized by name
+	hits := make(map[string]int)
+	for _, v := range c.subroutines {
+		hits[v.name] += v.hits
+	}
+
+	fileReport := func(file string) *FileReport {
+		if v, ok := files[file]; ok {
+			return v
+		}
+		files[file] = &FileReport{Name: relativePath(base, file)}
+		lines[file] = make(map[int]*LineReport)
+		return files[file]
+	}
+	subroutineReport := func(file, name string) *SubroutineReport {
+		key := [2]string{file, name}
+		if v, ok := subroutines[key]; ok {
+			return v
+		}
+		v := &SubroutineReport{Name: name, Hits: hits[name]}
+		f := fileReport(file)
+		f.Subroutines = append(f.Subroutines, v)
+		subroutines[key] = v
+		return v
+	}
+	lineReport := func(file string, line int, subroutine string) *LineReport {
+		fileReport(file)
+		if v, ok := lines[file][line]; ok {
+			return v
+		}
+		v := &LineReport{Line: line, Subroutine: subroutine}
+		lines[file][line] = v
+		return v
+	}
+
+	for loc, v := range c.subroutines {
+		subroutineReport(loc.File, v.name).Line = v.line
+	}
+	for loc, v := range c.statements {
+		s := subroutineReport(loc.File, v.subroutine)
+		s.Statements.add(v.hits)
+		if s.Line == 0 || loc.Line < s.Line {
+			// Subroutine is declared in another file, point to the first statement in this file
+			s.Line = loc.Line
+		}
+		l := lineReport(loc.File, loc.Line, v.subroutine)
+		l.Hits = max(l.Hits, v.hits)
+	}
+	// Sort branches by location in order to number them stably
+	locations := make([]Location, 0, len(c.branches))
+	for loc := range c.branches {
+		locations = append(locations, loc)
+	}
+	sort.Slice(locations, func(i, j int) bool {
+		a, b := locations[i], locations[j]
+		if a.File != b.File {
+			return a.File < b.File
+		}
+		if a.Line != b.Line {
+			return a.Line < b.Line
+		}
+		return a.Position < b.Position
+	})
+	for _, loc := range locations {
+		v := c.branches[loc]
+		s := subroutineReport(loc.File, v.subroutine)
+		for _, h := range v.hits {
+			s.Branches.add(h)
+		}
+		l := lineReport(loc.File, loc.Line, v.subroutine)
+		branch := &BranchReport{Hits: append([]int{}, v.hits...)}
+		if stmt, ok := c.statements[loc]; ok {
+			branch.Evaluated = stmt.hits > 0
+		}
+		l.Branches = append(l.Branches, branch)
+	}
+
+	report := &Report{}
+	for file, f := range files {
+		for _, l := range lines[file] {
+			f.Lines = append(f.Lines, l)
+		}
+		sort.Slice(f.Lines, func(i, j int) bool {
+			return f.Lines[i].Line < f.Lines[j].Line
+		})
+		sort.Slice(f.Subroutines, func(i, j int) bool {
+			return f.Subroutines[i].Line < f.Subroutines[j].Line
+		})
+		for _, s := range f.Subroutines {
+			f.Statements.merge(s.Statements)
+			f.Branches.merge(s.Branches)
+		}
+		report.Files = append(report.Files, f)
+		report.Statements.merge(f.Statements)
+		report.Branches.merge(f.Branches)
+	}
+	sort.Slice(report.Files, func(i, j int) bool {
+		return report.Files[i].Name < report.Files[j].Name
+	})
+	for _, h := range hits {
+		report.Subroutines.add(h)
+	}
+	return report
+}
+
+func relativePath(base, file string) string {
+	if base == "" || !filepath.IsAbs(file) {
+		return file
+	}
+	rel, err := filepath.Rel(base, file)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return file
+	}
+	return rel
+}
@@ -0,0 +1,146 @@
+package coverage
+
+import (
+	"bytes"
+	"encoding/xml"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/ysugimoto/falco/ast"
+	"github.com/ysugimoto/falco/lexer"
+	"github.com/ysugimoto/falco/parser"
+)
+
+const testVCL = `sub vcl_recv {
+  if (req.http.Foo) {
+    set req.http.Bar = "1";
+  }
+  set req.http.Baz = "1";
+}
+
+sub vcl_deliver {
+  set resp.http.Foo = "1";
+}
+`
+
+func newTestCoverage(t *testing.T) *Coverage {
+	vcl, err := parser.New(lexer.NewFromString(testVCL, lexer.WithFile("/path/to/main.vcl"))).ParseVCL()
+	if err != nil {
+		t.Fatalf("Unexpected parse error: %s", err)
+	}
+	c := New()
+	c.Register(vcl.Statements)
+
+	// Simulate vcl_recv is called once and if condition is false
+	recv := vcl.Statements[0].(*ast.SubroutineDeclaration)
+	c.Subroutine(recv)
+	for _, stmt := range recv.Block.Statements {
+		c.Statement(stmt)
+	}
+	c.Branch(recv.Block.Statements[0], 1)
+	return c
+}
+
+func TestReport(t *testing.T) {
+	report := newTestCoverage(t).Report("/path")
+
+	expect := &Report{
+		Files: []*FileReport{
+			{
+				Name: "to/main.vcl",
+				Subroutines: []*SubroutineReport{
+					{
+						Name:       "vcl_recv",
+						Line:       1,
+						Hits:       1,
+						Statements: Counter{Covered: 2, Total: 3},
+						Branches:   Counter{Covered: 1, Total: 2},
+					},
+					{
+						Name:       "vcl_deliver",
+						Line:       8,
+						Statements: Counter{Covered: 0, Total: 1},
+					},
+				},
+				Statements: Counter{Covered: 2, Total: 4},
+				Branches:   Counter{Covered: 1, Total: 2},
+				Lines: []*LineReport{
+					{Line: 2, Hits: 1, Subroutine: "vcl_recv", Branches: []*BranchReport{{Hits: []int{0, 1}, Evaluated: true}}},
+					{Line: 3, Hits: 0, Subroutine: "vcl_recv"},
+					{Line: 5, Hits: 1, Subroutine: "vcl_recv"},
+					{Line: 9, Hits: 0, Subroutine: "vcl_deliver"},
+				},
+			},
+		},
+		Subroutines: Counter{Covered: 1, Total: 2},
+		Statements:  Counter{Covered: 2, Total: 4},
+		Branches:    Counter{Covered: 1, Total: 2},
+	}
+	if diff := cmp.Diff(expect, report); diff != "" {
+		t.Errorf("Unexpected coverage report, diff=%s", diff)
+	}
+}
+
+func TestWriteLcov(t *testing.T) {
+	var buf bytes.Buffer
+	if err := newTestCoverage(t).Report("/path").WriteLcov(&buf); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	expect := strings.Join([]string{
+		"TN:",
+		"SF:to/main.vcl",
+		"FN:1,vcl_recv",
+		"FN:8,vcl_deliver",
+		"FNDA:1,vcl_recv",
+		"FNDA:0,vcl_deliver",
+		"FNF:2",
+		"FNH:1",
+		"BRDA:2,0,0,0",
+		"BRDA:2,0,1,1",
+		"BRF:2",
+		"BRH:1",
+		"DA:2,1",
+		"DA:3,0",
+		"DA:5,1",
+		"DA:9,0",
+		"LF:4",
+		"LH:2",
+		"end_of_record",
+		"",
+	}, "\n")
+	if diff := cmp.Diff(expect, buf.String()); diff != "" {
+		t.Errorf("Unexpected lcov output, diff=%s", diff)
+	}
+}
+
+func TestWriteCobertura(t *testing.T) {
+	var buf bytes.Buffer
+	if err := newTestCoverage(t).Report("/path").WriteCobertura(&buf, time.Unix(1700000000, 0)); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	var v coberturaCoverage
+	if err := xml.Unmarshal(buf.Bytes(), &v); err != nil {
+		t.Fatalf("Failed to unmarshal Cobertura XML: %s", err)
+	}
+	if v.LineRate != "0.5000" || v.BranchRate != "0.5000" || v.Timestamp != 1700000000 {
+		t.Errorf("Unexpected coverage attributes: %+v", v)
+	}
+	if len(v.Packages) != 1 || len(v.Packages[0].Classes) != 1 {
+		t.Fatalf("Expected one package and class, got %+v", v.Packages)
+	}
+	class := v.Packages[0].Classes[0]
+	if class.Filename != "to/main.vcl" || len(class.Methods) != 2 {
+		t.Errorf("Unexpected class: %+v", class)
+	}
+	expect := []coberturaLine{
+		{Number: 2, Hits: 1, Branch: true, ConditionCoverage: "50% (1/2)"},
+		{Number: 3, Hits: 0},
+		{Number: 5, Hits: 1},
+	}
+	if diff := cmp.Diff(expect, class.Methods[0].Lines); diff != "" {
+		t.Errorf("Unexpected vcl_recv lines, diff=%s", diff)
+	}
+}
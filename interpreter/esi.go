@@ -2,126 +2,300 @@ package interpreter
 
 import (
 	"bytes"
-	"context"
+	"fmt"
 	"io"
 	"net/http"
 	"net/url"
-	"path/filepath"
-	"regexp"
 	"strings"
-	"time"
 
+	"github.com/pkg/errors"
 	"github.com/ysugimoto/falco/interpreter/exception"
+	"github.com/ysugimoto/falco/interpreter/value"
 )
 
-var (
-	esiIncludeRegex = regexp.MustCompile(`<esi:include\s*src=['"]([^'"]+)['"]\s*/?\s*>`)
-	esiRemoveStart  = []byte("<esi:remove>")
-	esiRemoveEnd    = []byte("</esi:remove>")
+// Fastly limits the depth of nested ESI includes
+// see: https://docs.fastly.com/en/guides/using-edge-side-includes
+const maxESIDepth = 5
+
+const (
+	esiOnErrorContinue = "continue"
+
+	esiTagPrefix   = "<esi:"
+	esiCommentOpen = "<!--esi"
+	esiCommentEnd  = "-->"
+	cdataOpen      = "<![CDATA["
+	cdataEnd       = "]]>"
 )
 
-func (i *Interpreter) executeESI() error {
-	resp := i.ctx.Response
-	if resp == nil {
-		return exception.System("Client Response is nil")
-	}
+type esiNodeType int
 
-	var respBody bytes.Buffer
-	if _, err := respBody.ReadFrom(resp.Body); err != nil {
-		return err
+const (
+	esiText esiNodeType = iota
+	esiInclude
+)
+
+type esiNode struct {
+	Type esiNodeType
+	// Text node
+	Text []byte
+	// Include node
+	Src     string
+	Alt     string
+	OnError string
+}
+
+// Parse ESI document to text and include nodes.
+// Supported tags are <esi:include>, <esi:remove>, <esi:comment> and <!--esi ... --> as Fastly supports,
+// other ESI tags are kept as text.
+// When allowInsideCData is false, ESI tags inside CDATA sections are not processed.
+func parseESI(body []byte, allowInsideCData bool) ([]*esiNode, error) {
+	var nodes []*esiNode
+	text := func(b []byte) {
+		if len(b) > 0 {
+			nodes = append(nodes, &esiNode{Type: esiText, Text: b})
+		}
 	}
 
-	body := respBody.Bytes()
-	req := i.ctx.Request
-	ctx := i.ctx.Request.Context()
-	var parsed []byte
-	for {
-		match := esiIncludeRegex.FindSubmatchIndex(body)
-		if len(match) == 0 {
+	for len(body) > 0 {
+		index := nextESIMarker(body, allowInsideCData)
+		if index == -1 {
+			text(body)
 			break
 		}
-		previous := body[0:match[0]]
-		src := body[match[2]:match[3]]
-		body = body[match[1]:]
-		parsed = append(parsed, previous...)
-
-		// resolve inclusion
-		cloned := req.Clone(ctx)
-		partial, err := executeEsiInclude(ctx, cloned, src)
-		if err != nil {
-			// If ESI inclusion failed, find <esi:remove> tag and use its nodeText
-			index := bytes.Index(body, esiRemoveStart)
-			if index == -1 {
-				break
+		text(body[:index])
+		body = body[index:]
+
+		switch {
+		case bytes.HasPrefix(body, []byte(cdataOpen)):
+			// CDATA section is output as it is
+			end := bytes.Index(body, []byte(cdataEnd))
+			if end == -1 {
+				text(body)
+				return nodes, nil
+			}
+			text(body[:end+len(cdataEnd)])
+			body = body[end+len(cdataEnd):]
+		case bytes.HasPrefix(body, []byte(esiCommentOpen)):
+			// Content of <!--esi ... --> is processed as ESI document
+			end := bytes.Index(body, []byte(esiCommentEnd))
+			if end == -1 {
+				return nil, fmt.Errorf("Syntax error: does not seem to close %s", esiCommentEnd)
+			}
+			inner, err := parseESI(body[len(esiCommentOpen):end], allowInsideCData)
+			if err != nil {
+				return nil, err
+			}
+			nodes = append(nodes, inner...)
+			body = body[end+len(esiCommentEnd):]
+		default:
+			node, rest, err := parseESITag(body)
+			if err != nil {
+				return nil, err
+			}
+			if node != nil {
+				nodes = append(nodes, node)
 			}
-			body = body[index+len(esiRemoveStart):]
+			body = rest
+		}
+	}
+	return nodes, nil
+}
+
+// Find the position of the next ESI tag, ESI comment or CDATA section
+func nextESIMarker(body []byte, allowInsideCData bool) int {
+	markers := []string{esiTagPrefix, esiCommentOpen}
+	if !allowInsideCData {
+		markers = append(markers, cdataOpen)
+	}
+	index := -1
+	for _, marker := range markers {
+		if i := bytes.Index(body, []byte(marker)); i != -1 && (index == -1 || i < index) {
+			index = i
+		}
+	}
+	return index
+}
+
+// Parse an ESI tag which starts at the beginning of body, returns parsed node and the rest of body
+func parseESITag(body []byte) (*esiNode, []byte, error) {
+	end := bytes.IndexByte(body, '>')
+	if end == -1 {
+		return nil, nil, fmt.Errorf("Syntax error: does not seem to close %s tag", esiTagPrefix)
+	}
+	tag := string(body[len(esiTagPrefix):end])
+	rest := body[end+1:]
+	selfClosing := strings.HasSuffix(tag, "/")
+	tag = strings.TrimSuffix(tag, "/")
 
-			// Find </esi:remove> tag
-			index = bytes.Index(body, esiRemoveEnd)
-			if index == -1 {
-				return exception.Runtime(nil, "Syntax error: does not seem to close </esi:remove>")
+	name, attrs := tag, ""
+	if index := strings.IndexAny(tag, " \t\r\n"); index != -1 {
+		name, attrs = tag[:index], tag[index+1:]
+	}
+	switch name {
+	case "include":
+		attributes := parseESIAttributes(attrs)
+		src := attributes["src"]
+		if src == "" {
+			return nil, nil, fmt.Errorf("Syntax error: src attribute is required for <esi:include>")
+		}
+		if !selfClosing {
+			rest = bytes.TrimPrefix(rest, []byte("</esi:include>"))
+		}
+		return &esiNode{
+			Type:    esiInclude,
+			Src:     src,
+			Alt:     attributes["alt"],
+			OnError: attributes["onerror"],
+		}, rest, nil
+	case "comment":
+		return nil, rest, nil
+	case "remove":
+		// Content of <esi:remove> is for the client which does not process ESI, so it is always removed
+		if selfClosing {
+			return nil, rest, nil
+		}
+		index := bytes.Index(rest, []byte("</esi:remove>"))
+		if index == -1 {
+			return nil, nil, fmt.Errorf("Syntax error: does not seem to close </esi:remove>")
+		}
+		return nil, rest[index+len("</esi:remove>"):], nil
+	default:
+		// Unsupported ESI tag is output as it is
+		return &esiNode{Type: esiText, Text: body[:end+1]}, rest, nil
+	}
+}
+
+// Parse tag attributes like `src="/foo" alt='/bar'` to the map
+func parseESIAttributes(attrs string) map[string]string {
+	attributes := make(map[string]string)
+	for {
+		attrs = strings.TrimSpace(attrs)
+		key, rest, found := strings.Cut(attrs, "=")
+		if !found {
+			return attributes
+		}
+		rest = strings.TrimSpace(rest)
+		if rest == "" {
+			return attributes
+		}
+		var val string
+		if quote := rest[0]; quote == '"' || quote == '\'' {
+			end := strings.IndexByte(rest[1:], quote)
+			if end == -1 {
+				return attributes
 			}
-			parsed = append(parsed, body[0:index]...)
-			body = body[index+len(esiRemoveEnd):]
+			val, attrs = rest[1:end+1], rest[end+2:]
 		} else {
-			parsed = append(parsed, partial...)
-			// If ESI inclusion succeeded, find <esi:remove>...</esi:remove> tag and remove it
-			index := bytes.Index(body, esiRemoveStart)
-			if index == -1 {
-				continue
-			}
-			body = body[index+len(esiRemoveStart):]
-			index = bytes.Index(body, esiRemoveEnd)
-			if index == -1 {
-				return exception.Runtime(nil, "Syntax error: does not seem to close </esi:remove>")
+			val, attrs = rest, ""
+			if index := strings.IndexAny(rest, " \t\r\n"); index != -1 {
+				val, attrs = rest[:index], rest[index+1:]
 			}
-			body = body[index+len(esiRemoveEnd):]
 		}
+		attributes[strings.ToLower(strings.TrimSpace(key))] = val
 	}
+}
 
-	// remaining bytes
-	if len(body) > 0 {
-		parsed = append(parsed, body...)
+// Process ESI tags in the client response.
+// Each <esi:include> is dispatched as a new request through the VCL lifecycle with incremented req.esi_level.
+func (i *Interpreter) executeESI() error {
+	resp := i.ctx.Response
+	if resp == nil {
+		return exception.System("Client Response is nil")
 	}
-	resp.Body = io.NopCloser(bytes.NewReader(parsed))
+
+	var respBody bytes.Buffer
+	if _, err := respBody.ReadFrom(resp.Body); err != nil {
+		return err
+	}
+
+	nodes, err := parseESI(respBody.Bytes(), i.ctx.EsiAllowInsideCData.Value)
+	if err != nil {
+		return exception.Runtime(nil, "%s", err.Error())
+	}
+
+	var parsed bytes.Buffer
+	for _, node := range nodes {
+		switch node.Type {
+		case esiText:
+			parsed.Write(node.Text)
+		case esiInclude:
+			parsed.Write(i.executeEsiInclude(node))
+		}
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(parsed.Bytes()))
 	return nil
 }
 
-func executeEsiInclude(ctx context.Context, req *http.Request, includeUrl []byte) ([]byte, error) {
-	ctx, timeout := context.WithTimeout(ctx, 10*time.Second)
-	defer timeout()
+// Include the response of src, alt is tried when src is failed.
+// When both of them are failed, the failed response is included unless onerror="continue" is specified.
+func (i *Interpreter) executeEsiInclude(node *esiNode) []byte {
+	if i.ctx.ESILevel.Value >= maxESIDepth {
+		i.Debugger.Message(fmt.Sprintf("ESI include %s is ignored because nesting depth exceeds %d", node.Src, maxESIDepth))
+		return nil
+	}
 
-	if err := resolveIncludeURL(req, string(includeUrl)); err != nil {
-		return nil, err
+	body, err := i.esiSubRequest(node.Src)
+	if err != nil && node.Alt != "" {
+		i.Debugger.Message(fmt.Sprintf("ESI include is failed, try alt %s: %s", node.Alt, err))
+		body, err = i.esiSubRequest(node.Alt)
 	}
+	if err != nil {
+		i.Debugger.Message(fmt.Sprintf("ESI include is failed: %s", err))
+		if node.OnError == esiOnErrorContinue {
+			return nil
+		}
+	}
+	return body
+}
+
+// Process ESI include as a sub-request through the interpreter and returns the response body.
+// Returns error with the response body when the response status is an error.
+func (i *Interpreter) esiSubRequest(src string) ([]byte, error) {
+	parent := i.ctx.Request
+	ref, err := url.Parse(src)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	u := parent.URL.ResolveReference(ref)
 
-	resp, err := http.DefaultClient.Do(req.WithContext(ctx))
+	req, err := http.NewRequestWithContext(parent.Context(), http.MethodGet, u.String(), nil)
 	if err != nil {
-		return nil, err
+		return nil, errors.WithStack(err)
 	}
-	defer resp.Body.Close()
-	var buf bytes.Buffer
-	if _, err := buf.ReadFrom(resp.Body); err != nil {
-		return nil, err
+	req.Header = parent.Header.Clone()
+	req.Host = parent.Host
+	if u.Host != "" {
+		req.Host = u.Host
+	}
+
+	topURL := i.ctx.TopURL.Value
+	if topURL == "" {
+		topURL = parent.URL.RequestURI()
 	}
-	return buf.Bytes(), nil
-}
 
-func resolveIncludeURL(req *http.Request, path string) error {
-	switch {
-	case strings.HasPrefix(path, "http://"), strings.HasPrefix(path, "https://"):
-		parsed, err := url.Parse(path)
-		if err != nil {
-			return err
+	f := i.fork()
+	i.Debugger.Message(fmt.Sprintf("ESI include %s (level %d)", u.String(), i.ctx.ESILevel.Value+1))
+	if err := f.ProcessInit(req); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	f.ctx.ESILevel = &value.Integer{Value: i.ctx.ESILevel.Value + 1}
+	f.ctx.TopURL = &value.String{Value: topURL}
+	if err := f.ProcessRecv(); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	resp := f.ctx.Response
+	if resp == nil {
+		return nil, fmt.Errorf("ESI include %s does not respond", src)
+	}
+	var body bytes.Buffer
+	if resp.Body != nil {
+		if _, err := body.ReadFrom(resp.Body); err != nil {
+			return nil, errors.WithStack(err)
 		}
-		req.URL = parsed
-		req.Header.Set("Host", parsed.Host)
-		return nil
-	case strings.HasPrefix(path, "/"):
-		req.URL.Path = path
-		return nil
-	default:
-		req.URL.Path = filepath.Join(req.URL.Path, path)
-		return nil
 	}
+	if resp.StatusCode >= http.StatusBadRequest {
+		return body.Bytes(), fmt.Errorf("ESI include %s responds status %d", src, resp.StatusCode)
+	}
+	return body.Bytes(), nil
 }
@@ -0,0 +1,193 @@
+package interpreter
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/ysugimoto/falco/interpreter/context"
+	"github.com/ysugimoto/falco/resolver"
+)
+
+func TestParseESI(t *testing.T) {
+	tests := []struct {
+		name             string
+		input            string
+		allowInsideCData bool
+		expect           []*esiNode
+		isError          bool
+	}{
+		{
+			name:  "include with attributes",
+			input: `a<esi:include src="/foo" alt='/bar' onerror="continue"/>b`,
+			expect: []*esiNode{
+				{Type: esiText, Text: []byte("a")},
+				{Type: esiInclude, Src: "/foo", Alt: "/bar", OnError: "continue"},
+				{Type: esiText, Text: []byte("b")},
+			},
+		},
+		{
+			name:  "include with closing tag",
+			input: "<esi:include\n  src=/foo></esi:include>b",
+			expect: []*esiNode{
+				{Type: esiInclude, Src: "/foo"},
+				{Type: esiText, Text: []byte("b")},
+			},
+		},
+		{
+			name:  "remove and comment",
+			input: `a<esi:remove><a href="/foo">foo</a></esi:remove>b<esi:comment text="ignored" />c`,
+			expect: []*esiNode{
+				{Type: esiText, Text: []byte("a")},
+				{Type: esiText, Text: []byte("b")},
+				{Type: esiText, Text: []byte("c")},
+			},
+		},
+		{
+			name:  "esi comment",
+			input: `a<!--esi <esi:include src="/foo"/> -->b`,
+			expect: []*esiNode{
+				{Type: esiText, Text: []byte("a")},
+				{Type: esiText, Text: []byte(" ")},
+				{Type: esiInclude, Src: "/foo"},
+				{Type: esiText, Text: []byte(" ")},
+				{Type: esiText, Text: []byte("b")},
+			},
+		},
+		{
+			name:  "ESI tags inside CDATA are not processed",
+			input: `<![CDATA[<esi:include src="/foo"/>]]>`,
+			expect: []*esiNode{
+				{Type: esiText, Text: []byte(`<![CDATA[<esi:include src="/foo"/>]]>`)},
+			},
+		},
+		{
+			name:             "ESI tags inside CDATA are processed if allowed",
+			input:            `<![CDATA[<esi:include src="/foo"/>]]>`,
+			allowInsideCData: true,
+			expect: []*esiNode{
+				{Type: esiText, Text: []byte(`<![CDATA[`)},
+				{Type: esiInclude, Src: "/foo"},
+				{Type: esiText, Text: []byte(`]]>`)},
+			},
+		},
+		{
+			name:  "unsupported tag is kept",
+			input: `<esi:vars>$(HTTP_HOST)</esi:vars>`,
+			expect: []*esiNode{
+				{Type: esiText, Text: []byte(`<esi:vars>`)},
+				{Type: esiText, Text: []byte(`$(HTTP_HOST)</esi:vars>`)},
+			},
+		},
+		{name: "unclosed remove", input: `<esi:remove>foo`, isError: true},
+		{name: "unclosed esi comment", input: `<!--esi foo`, isError: true},
+		{name: "include without src", input: `<esi:include />`, isError: true},
+	}
+
+	for _, tt := range tests {
+		nodes, err := parseESI([]byte(tt.input), tt.allowInsideCData)
+		if tt.isError {
+			if err == nil {
+				t.Errorf("%s: expects error but nil", tt.name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: unexpected error: %s", tt.name, err)
+			continue
+		}
+		if diff := cmp.Diff(tt.expect, nodes); diff != "" {
+			t.Errorf("%s: unmatch parsed nodes, diff=%s", tt.name, diff)
+		}
+	}
+}
+
+func TestESI(t *testing.T) {
+	bodies := map[string]string{
+		"/top": strings.Join([]string{
+			`A<esi:include src="/fragment"/>`,
+			`B<esi:remove>REMOVED</esi:remove><esi:comment text="comment"/>`,
+			`C<!--esi <esi:include src="/fragment"/> -->`,
+			`D<![CDATA[<esi:include src="/fragment"/>]]>`,
+			`E<esi:include src="/notfound" alt="fragment"/>`,
+			`F<esi:include src="/notfound" onerror="continue"/>`,
+			`G<esi:include src="/notfound"/>`,
+			`H<esi:include src="/nested"/>`,
+		}, ""),
+		"/nested": `N<esi:include src="/fragment"/>`,
+		"/deep":   `x<esi:include src="/deep"/>`,
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, ok := bodies[r.URL.Path]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte("NOTFOUND")) // nolint:errcheck
+			return
+		}
+		w.Header().Set("Cache-Control", "max-age=60")
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(body)) // nolint:errcheck
+	}))
+	defer server.Close()
+
+	parsed, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatalf("Test server URL parsing error: %s", err)
+	}
+	vcl := defaultBackend(parsed) + `
+sub vcl_recv {
+  set req.http.X-Level = req.esi_level;
+  if (req.url ~ "^/fragment") {
+    error 900;
+  }
+  return(lookup);
+}
+
+sub vcl_fetch {
+  if (beresp.status == 200) {
+    esi;
+  }
+}
+
+sub vcl_error {
+  if (obj.status == 900) {
+    set obj.status = 200;
+    synthetic "[" req.http.X-Level ":" req.topurl "]";
+    return(deliver);
+  }
+}
+`
+	ip := New(context.WithResolver(resolver.NewStaticResolver("main", vcl)))
+	ip.Proxy = true
+
+	request := func(path string) string {
+		rec := httptest.NewRecorder()
+		ip.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "http://localhost"+path, nil))
+		return rec.Body.String()
+	}
+
+	expect := strings.Join([]string{
+		`A[1:/top]`,
+		`B`,
+		`C [1:/top] `,
+		`D<![CDATA[<esi:include src="/fragment"/>]]>`,
+		`E[1:/top]`,
+		`F`,
+		`GNOTFOUND`,
+		`HN[2:/top]`,
+	}, "")
+	// ESI is processed for the cached object as well
+	for n := 0; n < 2; n++ {
+		if diff := cmp.Diff(expect, request("/top")); diff != "" {
+			t.Errorf("Unexpected ESI result, diff=%s", diff)
+		}
+	}
+
+	// Nesting depth is limited
+	if diff := cmp.Diff(strings.Repeat("x", maxESIDepth+1), request("/deep")); diff != "" {
+		t.Errorf("Unexpected nested ESI result, diff=%s", diff)
+	}
+}
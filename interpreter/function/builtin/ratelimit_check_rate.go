@@ -3,6 +3,8 @@
 package builtin
 
 import (
+	"time"
+
 	"github.com/ysugimoto/falco/interpreter/context"
 	"github.com/ysugimoto/falco/interpreter/function/errors"
 	"github.com/ysugimoto/falco/interpreter/value"
@@ -34,6 +36,78 @@ func Ratelimit_check_rate(ctx *context.Context, args ...value.Value) (value.Valu
 		return value.Null, err
 	}
 
-	// TODO: Needs to be implemented
-	return &value.Boolean{Value: false}, nil
+	entry := value.Unwrap[*value.String](args[0]).Value
+	rc := value.Unwrap[*value.Ident](args[1]).Value
+	delta := value.Unwrap[*value.Integer](args[2]).Value
+	window := value.Unwrap[*value.Integer](args[3]).Value
+	limit := value.Unwrap[*value.Integer](args[4]).Value
+	pb := value.Unwrap[*value.Ident](args[5]).Value
+	ttl := value.Unwrap[*value.RTime](args[6]).Value
+
+	if err := validatePenaltybox(ctx, Ratelimit_check_rate_Name, pb); err != nil {
+		return &value.Boolean{Value: false}, err
+	}
+	now := ratelimitNow(ctx)
+	// Entry which is already in the penaltybox is limited without checking the rate
+	if ctx.Ratelimit.InPenaltybox(pb, entry, now) {
+		return &value.Boolean{Value: true}, nil
+	}
+	exceeded, err := checkRate(ctx, Ratelimit_check_rate_Name, entry, rc, delta, window, limit, now)
+	if err != nil {
+		return &value.Boolean{Value: false}, err
+	}
+	if exceeded {
+		ctx.Ratelimit.AddPenaltybox(pb, entry, penaltyboxTTL(ttl), now)
+	}
+	return &value.Boolean{Value: exceeded}, nil
+}
+
+// Ratelimit functions use fixed time for testing if it is injected
+func ratelimitNow(ctx *context.Context) time.Time {
+	if ctx.FixedTime != nil {
+		return *ctx.FixedTime
+	}
+	return time.Now()
+}
+
+func validateRatecounter(ctx *context.Context, name, rc string) error {
+	if _, ok := ctx.Ratecounters[rc]; !ok {
+		return errors.New(name, "ratecounter %s does not exist", rc)
+	}
+	return nil
+}
+
+func validatePenaltybox(ctx *context.Context, name, pb string) error {
+	if _, ok := ctx.Penaltyboxes[pb]; !ok {
+		return errors.New(name, "penaltybox %s does not exist", pb)
+	}
+	return nil
+}
+
+// Increment the ratecounter and report whether the rate in the window exceeds the limit
+func checkRate(
+	ctx *context.Context,
+	name, entry, rc string,
+	delta, window, limit int64,
+	now time.Time,
+) (bool, error) {
+
+	if err := validateRatecounter(ctx, name, rc); err != nil {
+		return false, err
+	}
+	switch window {
+	case 1, 10, 60:
+	default:
+		return false, errors.New(name, "window must be one of 1, 10 or 60 but %d provided", window)
+	}
+
+	ctx.Ratelimit.Increment(rc, entry, delta, now)
+	ctx.RatecounterEntries[rc] = entry
+	rate := ctx.Ratelimit.Rate(rc, entry, time.Duration(window)*time.Second, now)
+	return rate > float64(limit), nil
+}
+
+// Penaltybox TTL is truncated to minutes, and must be between 1 minute and 1 hour
+func penaltyboxTTL(ttl time.Duration) time.Duration {
+	return min(max(ttl.Truncate(time.Minute), time.Minute), time.Hour)
 }
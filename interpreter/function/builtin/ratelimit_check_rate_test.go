@@ -4,8 +4,11 @@ package builtin
 
 import (
 	"testing"
-	// "github.com/ysugimoto/falco/interpreter/context"
-	// "github.com/ysugimoto/falco/interpreter/value"
+	"time"
+
+	"github.com/ysugimoto/falco/ast"
+	"github.com/ysugimoto/falco/interpreter/context"
+	"github.com/ysugimoto/falco/interpreter/value"
 )
 
 // Fastly built-in function testing implementation of ratelimit.check_rate
@@ -13,5 +16,66 @@ import (
 // - STRING, ID, INTEGER, INTEGER, INTEGER, ID, RTIME
 // Reference: https://developer.fastly.com/reference/vcl/functions/rate-limiting/ratelimit-check-rate/
 func Test_Ratelimit_check_rate(t *testing.T) {
-	t.Skip("Test Builtin function ratelimit.check_rate should be impelemented")
+	now := time.Unix(1700000000, 0)
+	ctx := newRatelimitContext(&now)
+
+	check := func(entry string, window int64) bool {
+		ret, err := Ratelimit_check_rate(
+			ctx,
+			&value.String{Value: entry},
+			&value.Ident{Value: "rc"},
+			&value.Integer{Value: 1},
+			&value.Integer{Value: window},
+			&value.Integer{Value: 2},
+			&value.Ident{Value: "pb"},
+			&value.RTime{Value: 2 * time.Minute},
+		)
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		return value.Unwrap[*value.Boolean](ret).Value
+	}
+
+	// 3 hits in 1 second exceeds 2 hits per second limit
+	for i, expect := range []bool{false, false, true} {
+		if actual := check("client", 1); actual != expect {
+			t.Errorf("Hit %d: expects %t but got %t", i+1, expect, actual)
+		}
+	}
+	if check("other", 1) {
+		t.Errorf("Other entry must not be limited")
+	}
+
+	// Entry is limited while it is in the penaltybox
+	now = now.Add(90 * time.Second)
+	if !check("client", 1) {
+		t.Errorf("Entry must be limited while it is in the penaltybox")
+	}
+	now = now.Add(time.Minute)
+	if check("client", 1) {
+		t.Errorf("Entry must not be limited after penaltybox ttl is expired")
+	}
+
+	_, err := Ratelimit_check_rate(
+		ctx,
+		&value.String{Value: "client"},
+		&value.Ident{Value: "rc"},
+		&value.Integer{Value: 1},
+		&value.Integer{Value: 5},
+		&value.Integer{Value: 2},
+		&value.Ident{Value: "pb"},
+		&value.RTime{Value: time.Minute},
+	)
+	if err == nil {
+		t.Errorf("Expected error for invalid window")
+	}
+}
+
+func newRatelimitContext(now *time.Time) *context.Context {
+	ctx := context.New()
+	ctx.FixedTime = now
+	ctx.Ratecounters["rc"] = &ast.RatecounterDeclaration{}
+	ctx.Ratecounters["rc2"] = &ast.RatecounterDeclaration{}
+	ctx.Penaltyboxes["pb"] = &ast.PenaltyboxDeclaration{}
+	return ctx
 }
@@ -34,6 +34,39 @@ func Ratelimit_check_rates(ctx *context.Context, args ...value.Value) (value.Val
 		return value.Null, err
 	}
 
-	// TODO: Needs to be implemented
-	return &value.Boolean{Value: false}, nil
+	entry := value.Unwrap[*value.String](args[0]).Value
+	pb := value.Unwrap[*value.Ident](args[9]).Value
+	ttl := value.Unwrap[*value.RTime](args[10]).Value
+
+	if err := validatePenaltybox(ctx, Ratelimit_check_rates_Name, pb); err != nil {
+		return &value.Boolean{Value: false}, err
+	}
+	now := ratelimitNow(ctx)
+	// Entry which is already in the penaltybox is limited without checking the rate
+	if ctx.Ratelimit.InPenaltybox(pb, entry, now) {
+		return &value.Boolean{Value: true}, nil
+	}
+
+	// Both ratecounters are incremented, and limited if either of rate exceeds its limit
+	var exceeded bool
+	for _, offset := range []int{1, 5} {
+		ok, err := checkRate(
+			ctx,
+			Ratelimit_check_rates_Name,
+			entry,
+			value.Unwrap[*value.Ident](args[offset]).Value,
+			value.Unwrap[*value.Integer](args[offset+1]).Value,
+			value.Unwrap[*value.Integer](args[offset+2]).Value,
+			value.Unwrap[*value.Integer](args[offset+3]).Value,
+			now,
+		)
+		if err != nil {
+			return &value.Boolean{Value: false}, err
+		}
+		exceeded = exceeded || ok
+	}
+	if exceeded {
+		ctx.Ratelimit.AddPenaltybox(pb, entry, penaltyboxTTL(ttl), now)
+	}
+	return &value.Boolean{Value: exceeded}, nil
 }
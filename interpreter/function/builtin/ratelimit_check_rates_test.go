@@ -4,8 +4,9 @@ package builtin
 
 import (
 	"testing"
-	// "github.com/ysugimoto/falco/interpreter/context"
-	// "github.com/ysugimoto/falco/interpreter/value"
+	"time"
+
+	"github.com/ysugimoto/falco/interpreter/value"
 )
 
 // Fastly built-in function testing implementation of ratelimit.check_rates
@@ -13,5 +14,37 @@ import (
 // - STRING, ID, INTEGER, INTEGER, INTEGER, ID, INTEGER, INTEGER, INTEGER, ID, RTIME
 // Reference: https://developer.fastly.com/reference/vcl/functions/rate-limiting/ratelimit-check-rates/
 func Test_Ratelimit_check_rates(t *testing.T) {
-	t.Skip("Test Builtin function ratelimit.check_rates should be impelemented")
+	now := time.Unix(1700000000, 0)
+	ctx := newRatelimitContext(&now)
+
+	check := func() bool {
+		ret, err := Ratelimit_check_rates(
+			ctx,
+			&value.String{Value: "client"},
+			&value.Ident{Value: "rc"},
+			&value.Integer{Value: 1},
+			&value.Integer{Value: 1},
+			&value.Integer{Value: 100},
+			&value.Ident{Value: "rc2"},
+			&value.Integer{Value: 5},
+			&value.Integer{Value: 10},
+			&value.Integer{Value: 1},
+			&value.Ident{Value: "pb"},
+			&value.RTime{Value: time.Minute},
+		)
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		return value.Unwrap[*value.Boolean](ret).Value
+	}
+
+	// Second ratecounter reaches 10 hits in 10 seconds at second check, then exceeds the limit at third check
+	for i, expect := range []bool{false, false, true} {
+		if actual := check(); actual != expect {
+			t.Errorf("Hit %d: expects %t but got %t", i+1, expect, actual)
+		}
+	}
+	if count := ctx.Ratelimit.Count("rc", "client", time.Minute, now); count != 3 {
+		t.Errorf("First ratecounter expects 3 hits but got %d", count)
+	}
 }
@@ -34,6 +34,13 @@ func Ratelimit_penaltybox_add(ctx *context.Context, args ...value.Value) (value.
 		return value.Null, err
 	}
 
-	// TODO: Needs to be implemented
+	pb := value.Unwrap[*value.Ident](args[0]).Value
+	entry := value.Unwrap[*value.String](args[1]).Value
+	ttl := value.Unwrap[*value.RTime](args[2]).Value
+
+	if err := validatePenaltybox(ctx, Ratelimit_penaltybox_add_Name, pb); err != nil {
+		return value.Null, err
+	}
+	ctx.Ratelimit.AddPenaltybox(pb, entry, penaltyboxTTL(ttl), ratelimitNow(ctx))
 	return value.Null, nil
 }
@@ -3,9 +3,11 @@
 package builtin
 
 import (
+	"fmt"
 	"testing"
-	// "github.com/ysugimoto/falco/interpreter/context"
-	// "github.com/ysugimoto/falco/interpreter/value"
+	"time"
+
+	"github.com/ysugimoto/falco/interpreter/value"
 )
 
 // Fastly built-in function testing implementation of ratelimit.penaltybox_add
@@ -13,5 +15,40 @@ import (
 // - ID, STRING, RTIME
 // Reference: https://developer.fastly.com/reference/vcl/functions/rate-limiting/ratelimit-penaltybox-add/
 func Test_Ratelimit_penaltybox_add(t *testing.T) {
-	t.Skip("Test Builtin function ratelimit.penaltybox_add should be impelemented")
+	now := time.Unix(1700000000, 0)
+	ctx := newRatelimitContext(&now)
+
+	tests := []struct {
+		ttl     time.Duration
+		expires time.Duration
+	}{
+		{ttl: 10 * time.Second, expires: time.Minute},
+		{ttl: 150 * time.Second, expires: 2 * time.Minute},
+		{ttl: 2 * time.Hour, expires: time.Hour},
+	}
+
+	for i, tt := range tests {
+		entry := &value.String{Value: fmt.Sprintf("client%d", i)}
+		_, err := Ratelimit_penaltybox_add(ctx, &value.Ident{Value: "pb"}, entry, &value.RTime{Value: tt.ttl})
+		if err != nil {
+			t.Errorf("[%d] Unexpected error: %s", i, err)
+			continue
+		}
+		if !ctx.Ratelimit.InPenaltybox("pb", entry.Value, now.Add(tt.expires-time.Second)) {
+			t.Errorf("[%d] Entry must be in the penaltybox before %s", i, tt.expires)
+		}
+		if ctx.Ratelimit.InPenaltybox("pb", entry.Value, now.Add(tt.expires)) {
+			t.Errorf("[%d] Entry must be expired after %s", i, tt.expires)
+		}
+	}
+
+	_, err := Ratelimit_penaltybox_add(
+		ctx,
+		&value.Ident{Value: "undefined"},
+		&value.String{Value: "client"},
+		&value.RTime{Value: time.Minute},
+	)
+	if err == nil {
+		t.Errorf("Expected error for undefined penaltybox")
+	}
 }
@@ -34,6 +34,13 @@ func Ratelimit_penaltybox_has(ctx *context.Context, args ...value.Value) (value.
 		return value.Null, err
 	}
 
-	// TODO: Needs to be implemented
-	return &value.Boolean{Value: false}, nil
+	pb := value.Unwrap[*value.Ident](args[0]).Value
+	entry := value.Unwrap[*value.String](args[1]).Value
+
+	if err := validatePenaltybox(ctx, Ratelimit_penaltybox_has_Name, pb); err != nil {
+		return &value.Boolean{Value: false}, err
+	}
+	return &value.Boolean{
+		Value: ctx.Ratelimit.InPenaltybox(pb, entry, ratelimitNow(ctx)),
+	}, nil
 }
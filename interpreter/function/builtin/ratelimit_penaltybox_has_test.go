@@ -4,8 +4,9 @@ package builtin
 
 import (
 	"testing"
-	// "github.com/ysugimoto/falco/interpreter/context"
-	// "github.com/ysugimoto/falco/interpreter/value"
+	"time"
+
+	"github.com/ysugimoto/falco/interpreter/value"
 )
 
 // Fastly built-in function testing implementation of ratelimit.penaltybox_has
@@ -13,5 +14,26 @@ import (
 // - ID, STRING
 // Reference: https://developer.fastly.com/reference/vcl/functions/rate-limiting/ratelimit-penaltybox-has/
 func Test_Ratelimit_penaltybox_has(t *testing.T) {
-	t.Skip("Test Builtin function ratelimit.penaltybox_has should be impelemented")
+	now := time.Unix(1700000000, 0)
+	ctx := newRatelimitContext(&now)
+	ctx.Ratelimit.AddPenaltybox("pb", "client", time.Minute, now)
+
+	tests := []struct {
+		entry  string
+		expect bool
+	}{
+		{entry: "client", expect: true},
+		{entry: "other", expect: false},
+	}
+
+	for i, tt := range tests {
+		ret, err := Ratelimit_penaltybox_has(ctx, &value.Ident{Value: "pb"}, &value.String{Value: tt.entry})
+		if err != nil {
+			t.Errorf("[%d] Unexpected error: %s", i, err)
+			continue
+		}
+		if v := value.Unwrap[*value.Boolean](ret).Value; v != tt.expect {
+			t.Errorf("[%d] Return value unmatch, expect=%t, got=%t", i, tt.expect, v)
+		}
+	}
 }
@@ -34,6 +34,15 @@ func Ratelimit_ratecounter_increment(ctx *context.Context, args ...value.Value)
 		return value.Null, err
 	}
 
-	// TODO: Needs to be implemented
+	rc := value.Unwrap[*value.Ident](args[0]).Value
+	entry := value.Unwrap[*value.String](args[1]).Value
+	delta := value.Unwrap[*value.Integer](args[2]).Value
+
+	if err := validateRatecounter(ctx, Ratelimit_ratecounter_increment_Name, rc); err != nil {
+		return &value.Integer{Value: 0}, err
+	}
+	ctx.Ratelimit.Increment(rc, entry, delta, ratelimitNow(ctx))
+	ctx.RatecounterEntries[rc] = entry
+	// Return value is meaningless, Fastly document shows example which assigns it to the ignored variable
 	return &value.Integer{Value: 0}, nil
 }
@@ -4,8 +4,9 @@ package builtin
 
 import (
 	"testing"
-	// "github.com/ysugimoto/falco/interpreter/context"
-	// "github.com/ysugimoto/falco/interpreter/value"
+	"time"
+
+	"github.com/ysugimoto/falco/interpreter/value"
 )
 
 // Fastly built-in function testing implementation of ratelimit.ratecounter_increment
@@ -13,5 +14,41 @@ import (
 // - ID, STRING, INTEGER
 // Reference: https://developer.fastly.com/reference/vcl/functions/rate-limiting/ratelimit-ratecounter-increment/
 func Test_Ratelimit_ratecounter_increment(t *testing.T) {
-	t.Skip("Test Builtin function ratelimit.ratecounter_increment should be impelemented")
+	start := time.Unix(1700000000, 0)
+	now := start
+	ctx := newRatelimitContext(&now)
+
+	// Increment at 0s, 5s and 10s
+	for i, delta := range []int64{1, 2, 3} {
+		now = start.Add(time.Duration(i*5) * time.Second)
+		_, err := Ratelimit_ratecounter_increment(
+			ctx,
+			&value.Ident{Value: "rc"},
+			&value.String{Value: "client"},
+			&value.Integer{Value: delta},
+		)
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+	}
+
+	if count := ctx.Ratelimit.Count("rc", "client", 10*time.Second, now); count != 5 {
+		t.Errorf("Expects 5 hits in the last 10 seconds but got %d", count)
+	}
+	if count := ctx.Ratelimit.Count("rc", "client", time.Minute, now); count != 6 {
+		t.Errorf("Expects 6 hits in the last 60 seconds but got %d", count)
+	}
+	if entry := ctx.RatecounterEntries["rc"]; entry != "client" {
+		t.Errorf("Expects last incremented entry is client but got %s", entry)
+	}
+
+	_, err := Ratelimit_ratecounter_increment(
+		ctx,
+		&value.Ident{Value: "undefined"},
+		&value.String{Value: "client"},
+		&value.Integer{Value: 1},
+	)
+	if err == nil {
+		t.Errorf("Expected error for undefined ratecounter")
+	}
 }
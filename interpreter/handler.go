@@ -1,7 +1,9 @@
 package interpreter
 
 import (
+	"io"
 	"net/http"
+	"strconv"
 	"strings"
 
 	"github.com/pkg/errors"
@@ -10,8 +12,35 @@ import (
 	"github.com/ysugimoto/falco/interpreter/variable"
 )
 
-// Implements http.Handler
+const (
+	// Client could enable proxy mode per request by sending this header with "1" or "true" value
+	ProxyModeHeader = "Falco-Proxy"
+	// Trace id of the request is responded with this header in proxy mode
+	TraceIdHeader = "Falco-Trace-Id"
+	// Trace of the request is served on this path in proxy mode
+	TracePathPrefix = "/__falco/trace/"
+)
+
+// Implements http.Handler, each request is processed concurrently on the forked interpreter.
+// Paths under /__falco/ are reserved for the simulator admin endpoints and never go through the VCL
 func (i *Interpreter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch {
+	case strings.HasPrefix(r.URL.Path, TracePathPrefix):
+		i.serveTrace(w, strings.TrimPrefix(r.URL.Path, TracePathPrefix))
+		return
+	case r.URL.Path == PurgeAllPath, strings.HasPrefix(r.URL.Path, PurgePathPrefix):
+		i.servePurge(w, r)
+		return
+	case r.URL.Path == CachePath:
+		i.serveCache(w, r)
+		return
+	}
+	i.fork().Serve(w, r)
+}
+
+// Serve processes the request on this interpreter, the interpreter state is kept after processing.
+// Serve must not be called concurrently, use ServeHTTP to process requests concurrently.
+func (i *Interpreter) Serve(w http.ResponseWriter, r *http.Request) {
 	i.Debugger.Message("Request Incoming =========>")
 	defer i.Debugger.Message("<========= Request finished")
 	// Prevent deadlock if simulator is a backend for itself.
@@ -19,8 +48,13 @@ func (i *Interpreter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "loop detected", http.StatusServiceUnavailable)
 		return
 	}
-	i.lock.Lock()
-	defer i.lock.Unlock()
+
+	// Proxy mode header is only for the simulator, VCL should not see it
+	proxy := i.Proxy
+	if v, err := strconv.ParseBool(r.Header.Get(ProxyModeHeader)); err == nil {
+		proxy = v
+	}
+	r.Header.Del(ProxyModeHeader)
 
 	if err := i.ProcessInit(r); err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
@@ -45,6 +79,11 @@ func (i *Interpreter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 
 	i.process.Restarts = i.ctx.Restarts
 	i.process.Backend = i.ctx.Backend
+	if proxy {
+		i.serveProxyResponse(w)
+		return
+	}
+
 	if i.process.Error != nil {
 		w.WriteHeader(http.StatusInternalServerError)
 	} else {
@@ -57,3 +96,48 @@ func (i *Interpreter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	}
 	w.Write(out) // nolint:errcheck
 }
+
+// In proxy mode, client receives the final response as vcl_deliver left it,
+// and the process trace is stored to be retrieved from the trace endpoint
+func (i *Interpreter) serveProxyResponse(w http.ResponseWriter) {
+	out, err := i.process.Finalize(i.ctx.Response)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set(TraceIdHeader, i.traces.Add(out))
+
+	resp := i.ctx.Response
+	if i.process.Error != nil || resp == nil {
+		http.Error(w, "Failed to process VCL, see trace for details", http.StatusInternalServerError)
+		return
+	}
+
+	// Finalize rewinds the response body so we can read it again
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	for key, values := range resp.Header {
+		for _, v := range values {
+			w.Header().Add(key, v)
+		}
+	}
+	// Body may be modified by synthetic statement so correct the length
+	w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+	w.Header().Del("Transfer-Encoding")
+	w.WriteHeader(resp.StatusCode)
+	w.Write(body) // nolint:errcheck
+}
+
+func (i *Interpreter) serveTrace(w http.ResponseWriter, id string) {
+	trace, ok := i.traces.Get(id)
+	if !ok {
+		http.Error(w, "trace not found", http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(trace) // nolint:errcheck
+}
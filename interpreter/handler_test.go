@@ -0,0 +1,716 @@
+package interpreter
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/ysugimoto/falco/interpreter/cache"
+	"github.com/ysugimoto/falco/interpreter/context"
+	"github.com/ysugimoto/falco/resolver"
+)
+
+func TestProxyMode(t *testing.T) {
+	vcl := `
+sub vcl_recv {
+  if (req.http.Falco-Proxy) {
+    error 400;
+  }
+  error 404;
+}
+
+sub vcl_error {
+  set obj.http.Content-Type = "text/plain";
+  synthetic "Not Found";
+  return(deliver);
+}
+
+sub vcl_deliver {
+  set resp.http.X-Deliver = "1";
+}
+`
+	tests := []struct {
+		name  string
+		proxy bool
+		value string
+	}{
+		{name: "enabled by option", proxy: true},
+		{name: "enabled by header", value: "true"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ip := New(context.WithResolver(resolver.NewStaticResolver("main", vcl)))
+			ip.Proxy = tt.proxy
+
+			req := httptest.NewRequest(http.MethodGet, "http://localhost", nil)
+			if tt.value != "" {
+				req.Header.Set(ProxyModeHeader, tt.value)
+			}
+			rec := httptest.NewRecorder()
+			ip.ServeHTTP(rec, req)
+
+			if rec.Code != http.StatusNotFound {
+				t.Errorf("Expected status 404, got %d", rec.Code)
+			}
+			if body := rec.Body.String(); body != "Not Found" {
+				t.Errorf("Expected synthetic body, got %s", body)
+			}
+			if v := rec.Header().Get("X-Deliver"); v != "1" {
+				t.Errorf("Expected header set in vcl_deliver, got %s", v)
+			}
+			id := rec.Header().Get(TraceIdHeader)
+			if id == "" {
+				t.Fatalf("Expected trace id header")
+			}
+
+			rec = httptest.NewRecorder()
+			ip.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "http://localhost"+TracePathPrefix+id, nil))
+			if rec.Code != http.StatusOK {
+				t.Fatalf("Expected trace is found, got status %d", rec.Code)
+			}
+			var trace struct {
+				Flows          []json.RawMessage `json:"flows"`
+				ClientResponse struct {
+					StatusCode int `json:"status_code"`
+				} `json:"client_response"`
+			}
+			if err := json.Unmarshal(rec.Body.Bytes(), &trace); err != nil {
+				t.Fatalf("Failed to decode trace: %s", err)
+			}
+			if len(trace.Flows) == 0 || trace.ClientResponse.StatusCode != http.StatusNotFound {
+				t.Errorf("Unexpected trace: %s", rec.Body.String())
+			}
+		})
+	}
+
+	ip := New(context.WithResolver(resolver.NewStaticResolver("main", vcl)))
+	rec := httptest.NewRecorder()
+	ip.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "http://localhost"+TracePathPrefix+"unknown", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("Expected unknown trace is not found, got status %d", rec.Code)
+	}
+}
+
+func TestConcurrentRequests(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(200 * time.Millisecond)
+		w.Header().Set("Cache-Control", "max-age=60")
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte("OK")) // nolint:errcheck
+	}))
+	defer server.Close()
+
+	parsed, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatalf("Test server URL parsing error: %s", err)
+	}
+	vcl := defaultBackend(parsed) + `
+sub vcl_recv {
+  #FASTLY RECV
+  if (req.url ~ "^/pass") {
+    return(pass);
+  }
+  return(lookup);
+}
+
+sub vcl_deliver {
+  #FASTLY DELIVER
+  set resp.http.X-Url = req.url;
+}
+`
+	ip := New(context.WithResolver(resolver.NewStaticResolver("main", vcl)))
+	ip.Proxy = true
+
+	request := func(path string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		ip.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "http://localhost"+path, nil))
+		return rec
+	}
+
+	// Slow backend must not block other requests
+	concurrency := 10
+	start := time.Now()
+	var wg sync.WaitGroup
+	for n := 0; n < concurrency; n++ {
+		wg.Add(1)
+		go func(n int) {
+			defer wg.Done()
+			path := fmt.Sprintf("/pass/%d", n)
+			rec := request(path)
+			if rec.Code != http.StatusOK || rec.Body.String() != "OK" {
+				t.Errorf("Unexpected response for %s: %d %s", path, rec.Code, rec.Body.String())
+			}
+			if v := rec.Header().Get("X-Url"); v != path {
+				t.Errorf("Response is mixed with other request, expected %s, got %s", path, v)
+			}
+		}(n)
+	}
+	wg.Wait()
+	if elapsed := time.Since(start); elapsed >= time.Duration(concurrency)*200*time.Millisecond {
+		t.Errorf("Requests are not processed concurrently, elapsed %s", elapsed)
+	}
+
+	// Cached object is shared between concurrent requests
+	request("/cached")
+	for n := 0; n < concurrency; n++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if rec := request("/cached"); rec.Body.String() != "OK" {
+				t.Errorf("Unexpected cached response body: %s", rec.Body.String())
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestRatelimitStateAcrossRequests(t *testing.T) {
+	vcl := `
+ratecounter rc {}
+penaltybox pb {}
+
+sub vcl_recv {
+  declare local var.ignored INTEGER;
+  if (ratelimit.penaltybox_has(pb, req.http.Client)) {
+    error 429;
+  }
+  set var.ignored = ratelimit.ratecounter_increment(rc, req.http.Client, 1);
+  error 200;
+}
+
+sub vcl_error {
+  set obj.http.X-Bucket = ratecounter.rc.bucket.60s;
+  if (ratecounter.rc.bucket.60s >= 2) {
+    ratelimit.penaltybox_add(pb, req.http.Client, 1m);
+  }
+  return(deliver);
+}
+`
+	ip := New(context.WithResolver(resolver.NewStaticResolver("main", vcl)))
+	ip.Proxy = true
+
+	tests := []struct {
+		client string
+		status int
+		bucket string
+	}{
+		{client: "a", status: http.StatusOK, bucket: "1"},
+		{client: "b", status: http.StatusOK, bucket: "1"},
+		{client: "a", status: http.StatusOK, bucket: "2"},
+		{client: "a", status: http.StatusTooManyRequests, bucket: "0"},
+		{client: "b", status: http.StatusOK, bucket: "2"},
+	}
+	for i, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, "http://localhost", nil)
+		req.Header.Set("Client", tt.client)
+		rec := httptest.NewRecorder()
+		ip.ServeHTTP(rec, req)
+
+		if rec.Code != tt.status {
+			t.Errorf("[%d] Expected status %d, got %d", i, tt.status, rec.Code)
+		}
+		if v := rec.Header().Get("X-Bucket"); v != tt.bucket {
+			t.Errorf("[%d] Expected bucket %s, got %s", i, tt.bucket, v)
+		}
+	}
+}
+
+func TestStaleWhileRevalidate(t *testing.T) {
+	var fetches atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := fetches.Add(1)
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(fmt.Sprintf("v%d", n))) // nolint:errcheck
+	}))
+	defer server.Close()
+
+	parsed, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatalf("Test server URL parsing error: %s", err)
+	}
+	vcl := defaultBackend(parsed) + `
+sub vcl_recv {
+  return(lookup);
+}
+
+sub vcl_fetch {
+  set beresp.ttl = 100ms;
+  set beresp.stale_while_revalidate = 60s;
+}
+
+sub vcl_deliver {
+  if (resp.stale.is_revalidating) {
+    set resp.http.X-Revalidating = "1";
+  }
+}
+`
+	ip := New(context.WithResolver(resolver.NewStaticResolver("main", vcl)))
+	ip.Proxy = true
+
+	request := func() *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		ip.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "http://localhost", nil))
+		return rec
+	}
+
+	if rec := request(); rec.Body.String() != "v1" {
+		t.Fatalf("Expected first response is fetched, got %s", rec.Body.String())
+	}
+	time.Sleep(200 * time.Millisecond)
+
+	// Stale object is served immediately and refreshed in background
+	rec := request()
+	if rec.Body.String() != "v1" || rec.Header().Get("X-Revalidating") != "1" {
+		t.Errorf("Expected stale object is served while revalidating, got %s", rec.Body.String())
+	}
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		rec = request()
+		if rec.Body.String() == "v2" {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Stale object is not revalidated, got %s", rec.Body.String())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if rec.Header().Get("X-Revalidating") != "" {
+		t.Errorf("Expected revalidated object is fresh")
+	}
+	if n := fetches.Load(); n != 2 {
+		t.Errorf("Expected backend is fetched twice, got %d", n)
+	}
+}
+
+func TestStaleIfError(t *testing.T) {
+	var fetches atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if fetches.Add(1) > 1 {
+			w.WriteHeader(http.StatusInternalServerError)
+			w.Write([]byte("error")) // nolint:errcheck
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte("OK")) // nolint:errcheck
+	}))
+	defer server.Close()
+
+	parsed, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatalf("Test server URL parsing error: %s", err)
+	}
+	vcl := defaultBackend(parsed) + `
+sub vcl_recv {
+  return(lookup);
+}
+
+sub vcl_fetch {
+  if (beresp.status >= 500 && stale.exists) {
+    return(deliver_stale);
+  }
+  set beresp.ttl = 100ms;
+  set beresp.stale_if_error = 60s;
+}
+
+sub vcl_error {
+  if (obj.status == 503 && stale.exists) {
+    return(deliver_stale);
+  }
+}
+
+sub vcl_deliver {
+  if (resp.stale.is_error) {
+    set resp.http.X-Stale-Error = "1";
+  }
+}
+`
+	ip := New(context.WithResolver(resolver.NewStaticResolver("main", vcl)))
+	ip.Proxy = true
+
+	request := func() *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		ip.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "http://localhost", nil))
+		return rec
+	}
+
+	if rec := request(); rec.Code != http.StatusOK || rec.Body.String() != "OK" {
+		t.Fatalf("Expected first response is fetched, got %d %s", rec.Code, rec.Body.String())
+	}
+	time.Sleep(200 * time.Millisecond)
+
+	// Origin responds error status, vcl_fetch delivers stale object
+	rec := request()
+	if rec.Code != http.StatusOK || rec.Body.String() != "OK" || rec.Header().Get("X-Stale-Error") != "1" {
+		t.Errorf("Expected stale object is delivered from vcl_fetch, got %d %s", rec.Code, rec.Body.String())
+	}
+
+	// Origin is down, vcl_error delivers stale object
+	server.Close()
+	rec = request()
+	if rec.Code != http.StatusOK || rec.Body.String() != "OK" || rec.Header().Get("X-Stale-Error") != "1" {
+		t.Errorf("Expected stale object is delivered from vcl_error, got %d %s", rec.Code, rec.Body.String())
+	}
+}
+
+func TestPurge(t *testing.T) {
+	var fetches atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetches.Add(1)
+		w.Header().Set("Surrogate-Key", "all page"+r.URL.Path)
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte("OK")) // nolint:errcheck
+	}))
+	defer server.Close()
+
+	parsed, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatalf("Test server URL parsing error: %s", err)
+	}
+	vcl := defaultBackend(parsed) + `
+sub vcl_recv {
+  if (req.is_purge && req.http.Fastly-Key != "secret") {
+    error 401;
+  }
+  return(lookup);
+}
+
+sub vcl_fetch {
+  set beresp.stale_if_error = 60s;
+  if (stale.exists) {
+    set beresp.http.X-Stale-Exists = "1";
+  }
+}
+`
+	ip := New(context.WithResolver(resolver.NewStaticResolver("main", vcl)))
+	ip.Proxy = true
+
+	request := func(method, path string, headers ...string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "http://localhost"+path, nil)
+		for n := 0; n < len(headers); n += 2 {
+			req.Header.Set(headers[n], headers[n+1])
+		}
+		rec := httptest.NewRecorder()
+		ip.ServeHTTP(rec, req)
+		return rec
+	}
+	assertCache := func(path, expect string) {
+		t.Helper()
+		if v := request(http.MethodGet, path).Header().Get("X-Cache"); v != expect {
+			t.Errorf("Expected %s is %s, got %s", path, expect, v)
+		}
+	}
+
+	assertCache("/a", "MISS")
+	assertCache("/a", "HIT")
+
+	// Purge request goes through vcl_recv
+	if rec := request("PURGE", "/a"); rec.Code != http.StatusUnauthorized {
+		t.Errorf("Expected unauthorized purge request is rejected in vcl_recv, got %d", rec.Code)
+	}
+	assertCache("/a", "HIT")
+	rec := request("PURGE", "/a", "Fastly-Key", "secret")
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"status":"ok"`) {
+		t.Errorf("Unexpected purge response: %d %s", rec.Code, rec.Body.String())
+	}
+	assertCache("/a", "MISS")
+
+	// Soft purge keeps the object as stale
+	request("PURGE", "/a", "Fastly-Key", "secret", SoftPurgeHeader, "1")
+	if rec := request(http.MethodGet, "/a"); rec.Header().Get("X-Cache") != "MISS" || rec.Header().Get("X-Stale-Exists") != "1" {
+		t.Errorf("Expected soft purged object is stale, got %s", rec.Header().Get("X-Cache"))
+	}
+
+	// Purge by surrogate key
+	assertCache("/b", "MISS")
+	if rec := request(http.MethodPost, PurgePathPrefix+"page/b"); rec.Code != http.StatusOK {
+		t.Errorf("Unexpected key purge response: %d %s", rec.Code, rec.Body.String())
+	}
+	assertCache("/a", "HIT")
+	assertCache("/b", "MISS")
+
+	// Purge all
+	if rec := request(http.MethodGet, PurgeAllPath); rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("Expected purge all accepts only POST, got %d", rec.Code)
+	}
+	request(http.MethodPost, PurgeAllPath)
+	assertCache("/a", "MISS")
+	assertCache("/b", "MISS")
+
+	if n := fetches.Load(); n != 7 {
+		t.Errorf("Expected backend is fetched 7 times, got %d", n)
+	}
+}
+
+func TestRequestCollapsing(t *testing.T) {
+	var mu sync.Mutex
+	fetches := map[string]int{}
+	passes := map[string]int{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		fetches[r.URL.Path]++
+		if r.Header.Get("X-From-Pass") != "" {
+			passes[r.URL.Path]++
+		}
+		mu.Unlock()
+
+		time.Sleep(100 * time.Millisecond)
+		if r.URL.Path == "/hfp" {
+			w.Header().Set("X-Pass", "1")
+		}
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte("OK")) // nolint:errcheck
+	}))
+	defer server.Close()
+
+	parsed, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatalf("Test server URL parsing error: %s", err)
+	}
+	vcl := defaultBackend(parsed) + `
+sub vcl_recv {
+  if (req.http.Ignore-Busy) {
+    set req.hash_ignore_busy = true;
+  }
+  if (req.http.Always-Miss) {
+    set req.hash_always_miss = true;
+  }
+  return(lookup);
+}
+
+sub vcl_pass {
+  set bereq.http.X-From-Pass = "1";
+}
+
+sub vcl_fetch {
+  if (beresp.http.X-Pass) {
+    return(pass);
+  }
+}
+`
+	ip := New(context.WithResolver(resolver.NewStaticResolver("main", vcl)))
+	ip.Proxy = true
+
+	concurrent := func(path string, headers ...string) {
+		var wg sync.WaitGroup
+		for n := 0; n < 5; n++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				req := httptest.NewRequest(http.MethodGet, "http://localhost"+path, nil)
+				for n := 0; n < len(headers); n += 2 {
+					req.Header.Set(headers[n], headers[n+1])
+				}
+				rec := httptest.NewRecorder()
+				ip.ServeHTTP(rec, req)
+				if rec.Code != http.StatusOK || rec.Body.String() != "OK" {
+					t.Errorf("Unexpected response for %s: %d %s", path, rec.Code, rec.Body.String())
+				}
+			}()
+		}
+		wg.Wait()
+	}
+
+	tests := []struct {
+		name    string
+		path    string
+		headers []string
+		fetches int
+		passes  int
+	}{
+		{name: "concurrent misses are collapsed", path: "/collapse", fetches: 1},
+		{name: "req.hash_ignore_busy disables collapsing", path: "/ignore-busy", headers: []string{"Ignore-Busy", "1"}, fetches: 5},
+		{name: "req.hash_always_miss ignores cached object", path: "/collapse", headers: []string{"Always-Miss", "1"}, fetches: 6},
+		{name: "hit-for-pass requests go to vcl_pass", path: "/hfp", fetches: 5, passes: 4},
+	}
+	for _, tt := range tests {
+		concurrent(tt.path, tt.headers...)
+		mu.Lock()
+		if fetches[tt.path] != tt.fetches {
+			t.Errorf("%s: expected backend is fetched %d times, got %d", tt.name, tt.fetches, fetches[tt.path])
+		}
+		if passes[tt.path] != tt.passes {
+			t.Errorf("%s: expected %d requests are passed, got %d", tt.name, tt.passes, passes[tt.path])
+		}
+		mu.Unlock()
+	}
+}
+
+func TestFetchRestartIsNotCached(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte("OK")) // nolint:errcheck
+	}))
+	defer server.Close()
+
+	parsed, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatalf("Test server URL parsing error: %s", err)
+	}
+	vcl := defaultBackend(parsed) + `
+sub vcl_recv {
+  if (req.restarts > 0) {
+    error 601;
+  }
+  return(lookup);
+}
+
+sub vcl_fetch {
+  if (req.url == "/error") {
+    error 602;
+  }
+  return(restart);
+}
+
+sub vcl_error {
+  set obj.status = 200;
+  synthetic "restarted";
+  return(deliver);
+}
+`
+	ip := New(context.WithResolver(resolver.NewStaticResolver("main", vcl)))
+	ip.Proxy = true
+
+	for _, path := range []string{"/restart", "/error"} {
+		rec := httptest.NewRecorder()
+		ip.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "http://localhost"+path, nil))
+		if rec.Code != http.StatusOK {
+			t.Errorf("Unexpected response for %s: %d %s", path, rec.Code, rec.Body.String())
+		}
+	}
+	if entries := ip.cache.Entries(); len(entries) != 0 {
+		t.Errorf("Expected nothing is cached, got %+v", entries)
+	}
+}
+
+func TestSelfReferencingCollapse(t *testing.T) {
+	var ip *Interpreter
+	var fetches atomic.Int32
+	// Origin requests the same URL to the simulator while the first request is fetching as the leader
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetches.Add(1)
+		if r.Header.Get("X-Inner") == "" {
+			req := httptest.NewRequest(http.MethodGet, "http://localhost"+r.URL.Path, nil)
+			req.Header.Set("X-Inner", "1")
+			rec := httptest.NewRecorder()
+			ip.ServeHTTP(rec, req)
+			w.WriteHeader(rec.Code)
+			w.Write(rec.Body.Bytes()) // nolint:errcheck
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte("OK")) // nolint:errcheck
+	}))
+	defer server.Close()
+
+	parsed, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatalf("Test server URL parsing error: %s", err)
+	}
+	vcl := defaultBackend(parsed) + `
+sub vcl_recv {
+  return(lookup);
+}
+`
+	ip = New(context.WithResolver(resolver.NewStaticResolver("main", vcl)))
+	ip.Proxy = true
+	ip.SetCache(cache.New(cache.WithCollapseTimeout(100 * time.Millisecond)))
+
+	finished := make(chan *httptest.ResponseRecorder)
+	go func() {
+		rec := httptest.NewRecorder()
+		ip.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "http://localhost/self", nil))
+		finished <- rec
+	}()
+
+	select {
+	case rec := <-finished:
+		if rec.Code != http.StatusOK || rec.Body.String() != "OK" {
+			t.Errorf("Unexpected response: %d %s", rec.Code, rec.Body.String())
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Collapsed request waits for itself forever")
+	}
+	if n := fetches.Load(); n != 2 {
+		t.Errorf("Expected backend is fetched 2 times, got %d", n)
+	}
+}
+
+func TestCacheAdmin(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Surrogate-Key", "page"+r.URL.Path)
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte("OK")) // nolint:errcheck
+	}))
+	defer server.Close()
+
+	parsed, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatalf("Test server URL parsing error: %s", err)
+	}
+	vcl := defaultBackend(parsed) + `
+sub vcl_recv {
+  return(lookup);
+}
+`
+	ip := New(context.WithResolver(resolver.NewStaticResolver("main", vcl)))
+	ip.Proxy = true
+
+	request := func(method, path string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		ip.ServeHTTP(rec, httptest.NewRequest(method, "http://localhost"+path, nil))
+		return rec
+	}
+	entries := func() []cache.Entry {
+		t.Helper()
+		rec := request(http.MethodGet, CachePath)
+		var v []cache.Entry
+		if err := json.Unmarshal(rec.Body.Bytes(), &v); err != nil {
+			t.Fatalf("Unexpected cache list response: %s", rec.Body.String())
+		}
+		return v
+	}
+
+	request(http.MethodGet, "/a")
+	request(http.MethodGet, "/a")
+	request(http.MethodGet, "/b")
+
+	list := entries()
+	if len(list) != 2 {
+		t.Fatalf("Expected 2 objects are listed, got %d", len(list))
+	}
+	// Most recently used object comes first
+	if list[0].Hits != 0 || list[1].Hits != 1 || list[1].TTL <= 0 {
+		t.Errorf("Unexpected cache entries: %+v", list)
+	}
+	if diff := cmp.Diff([]string{"page/b"}, list[0].SurrogateKeys); diff != "" {
+		t.Errorf("Unexpected surrogate keys, diff=%s", diff)
+	}
+
+	if rec := request(http.MethodDelete, CachePath+"?hash="+url.QueryEscape(list[0].Hash)); rec.Code != http.StatusOK {
+		t.Errorf("Expected object is deleted, got %d", rec.Code)
+	}
+	if rec := request(http.MethodDelete, CachePath+"?hash="+url.QueryEscape(list[0].Hash)); rec.Code != http.StatusNotFound {
+		t.Errorf("Expected deleted object is not found, got %d", rec.Code)
+	}
+	if n := len(entries()); n != 1 {
+		t.Errorf("Expected 1 object is left, got %d", n)
+	}
+	if rec := request(http.MethodDelete, CachePath); rec.Code != http.StatusOK {
+		t.Errorf("Expected all objects are deleted, got %d", rec.Code)
+	}
+	if n := len(entries()); n != 0 {
+		t.Errorf("Expected all objects are deleted, got %d", n)
+	}
+	if rec := request(http.MethodPost, CachePath); rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("Expected method is not allowed, got %d", rec.Code)
+	}
+}
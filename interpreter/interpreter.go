@@ -5,7 +5,6 @@ import (
 	"io"
 	"net/http"
 	"strings"
-	"sync"
 	"sync/atomic"
 	"time"
 
@@ -13,41 +12,84 @@ import (
 	"github.com/ysugimoto/falco/ast"
 	"github.com/ysugimoto/falco/interpreter/cache"
 	"github.com/ysugimoto/falco/interpreter/context"
+	"github.com/ysugimoto/falco/interpreter/coverage"
 	"github.com/ysugimoto/falco/interpreter/exception"
 	"github.com/ysugimoto/falco/interpreter/limitations"
 	"github.com/ysugimoto/falco/interpreter/process"
+	"github.com/ysugimoto/falco/interpreter/ratelimit"
 	"github.com/ysugimoto/falco/interpreter/value"
 	"github.com/ysugimoto/falco/interpreter/variable"
-	"github.com/ysugimoto/falco/lexer"
-	"github.com/ysugimoto/falco/parser"
 )
 
+// Number of recent request traces which are kept in proxy mode
+const maxTraces = 100
+
 type Interpreter struct {
 	vars      variable.Variable
 	localVars variable.LocalVariables
-	lock      sync.Mutex
 
 	options []context.Option
+	program *program
 
 	ctx           *context.Context
 	process       *process.Process
 	cache         *cache.Cache
+	ratelimit     *ratelimit.Ratelimit
 	Debugger      Debugger
 	IdentResolver func(v string) value.Value
 
+	// If true, respond the final response to the client instead of process JSON
+	Proxy  bool
+	traces *process.Traces
+
+	// Pending goto statement which is searching its destination
+	gotoStatement *ast.GotoStatement
+
+	// Release function of the collapsed request which is fetching the object as the leader
+	collapsed func()
+
+	// Mocked backend responses on testing
+	backendMocks map[string]*MockedBackendResponse
+
+	// Collect VCL code coverage on testing, nil means disabled
+	Coverage *coverage.Coverage
+
 	TestingState State
 }
 
 func New(options ...context.Option) *Interpreter {
 	return &Interpreter{
 		options:      options,
+		program:      &program{},
 		cache:        cache.New(),
+		ratelimit:    ratelimit.New(),
+		traces:       process.NewTraces(maxTraces),
 		localVars:    variable.LocalVariables{},
 		Debugger:     DefaultDebugger{},
 		TestingState: NONE,
 	}
 }
 
+// Create interpreter for processing a request.
+// Parsed program, cache and settings are shared with the original interpreter
+// so that many requests could be processed concurrently.
+func (i *Interpreter) fork() *Interpreter {
+	return &Interpreter{
+		options:       i.options,
+		program:       i.program,
+		cache:         i.cache,
+		ratelimit:     i.ratelimit,
+		traces:        i.traces,
+		localVars:     variable.LocalVariables{},
+		Debugger:      i.Debugger,
+		IdentResolver: i.IdentResolver,
+		Proxy:         i.Proxy,
+		backendMocks:  i.backendMocks,
+		Coverage:      i.Coverage,
+		TestingState:  NONE,
+	}
+}
+
 func (i *Interpreter) SetScope(scope context.Scope) {
 	i.ctx.Scope = scope
 	switch scope {
@@ -79,6 +121,9 @@ func (i *Interpreter) restart() error {
 	i.ctx.BackendResponse = nil
 	i.ctx.Object = nil
 	i.ctx.Response = nil
+	i.ctx.PassRequest = false
+	// Restarted request may look up the same hash so release it to prevent waiting itself
+	i.releaseCollapse()
 
 	if err := i.ProcessRecv(); err != nil {
 		return err
@@ -88,42 +133,19 @@ func (i *Interpreter) restart() error {
 
 func (i *Interpreter) ProcessInit(r *http.Request) error {
 	ctx := context.New(i.options...)
+	ctx.Ratelimit = i.ratelimit
+	i.ctx = ctx
 
-	main, err := ctx.Resolver.MainVCL()
-	if err != nil {
-		i.Debugger.Message(err.Error())
-		return err
-	}
-	if err := limitations.CheckFastlyVCLLimitation(main.Data); err != nil {
-		i.Debugger.Message(err.Error())
-		return err
-	}
-	vcl, err := parser.New(
-		lexer.NewFromString(main.Data, lexer.WithFile(main.Name)),
-	).ParseVCL()
+	statements, err := i.loadProgram()
 	if err != nil {
-		// parse error
-		i.Debugger.Message(err.Error())
 		return err
 	}
+	i.Coverage.Register(statements)
 
-	// If remote snippets exists, prepare parse and prepend to main VCL
-	if ctx.FastlySnippets != nil {
-		for _, snip := range ctx.FastlySnippets.EmbedSnippets() {
-			s, err := parser.New(
-				lexer.NewFromString(snip.Data, lexer.WithFile(snip.Name)),
-			).ParseVCL()
-			if err != nil {
-				// parse error
-				i.Debugger.Message(err.Error())
-				return err
-			}
-			vcl.Statements = append(s.Statements, vcl.Statements...)
-		}
-	}
 	ctx.RequestStartTime = time.Now()
-	i.ctx = ctx
 	i.ctx.Request = r
+	// Request method could be changed in VCL, so determine purge request by the original method
+	i.ctx.IsPurge = &value.Boolean{Value: r.Method == variable.PURGE}
 	r.Header.Set("Host", r.Host)
 
 	// OriginalHost value may be overridden. If not empty, set the request value
@@ -135,10 +157,6 @@ func (i *Interpreter) ProcessInit(r *http.Request) error {
 	i.ctx.Scope = context.InitScope
 	i.vars = variable.NewAllScopeVariables(i.ctx)
 
-	statements, err := i.resolveIncludeStatement(vcl.Statements, true)
-	if err != nil {
-		return err
-	}
 	if err := i.ProcessDeclarations(statements); err != nil {
 		return err
 	}
@@ -279,17 +297,11 @@ func (i *Interpreter) ProcessRecv() error {
 		if err = i.ProcessHash(); err != nil {
 			return errors.WithStack(err)
 		}
-		if v := i.cache.Get(i.ctx.RequestHash.Value); v != nil {
-			i.process.Cached = true
-			i.ctx.State = "HIT"
-			i.ctx.CacheHitItem = v
-			i.ctx.Object = i.cloneResponse(v.Response)
-			i.Debugger.Message(fmt.Sprintf("Move state: %s -> HIT", i.ctx.Scope))
-			err = i.ProcessHit()
+		if i.ctx.IsPurge.Value {
+			i.Debugger.Message(fmt.Sprintf("Move state: %s -> PURGE", i.ctx.Scope))
+			err = i.ProcessPurge()
 		} else {
-			i.ctx.State = "MISS"
-			i.Debugger.Message(fmt.Sprintf("Move state: %s -> MISS", i.ctx.Scope))
-			err = i.ProcessMiss()
+			err = i.ProcessLookup()
 		}
 	default:
 		return exception.Runtime(
@@ -366,6 +378,9 @@ func (i *Interpreter) ProcessMiss() error {
 
 	switch state {
 	case DELIVER_STALE:
+		if i.ctx.StaleItem != nil {
+			i.deliverStale(false)
+		}
 		i.Debugger.Message(fmt.Sprintf("Move state: %s -> DELIVER", i.ctx.Scope))
 		err = i.ProcessDeliver()
 	case PASS:
@@ -409,10 +424,14 @@ func (i *Interpreter) ProcessHit() error {
 		}
 	}
 
-	// Update cache lifetime because cache object statue may be changed by setting obj.ttl
-	if i.ctx.ObjectTTL.Value > 0 {
-		i.ctx.CacheHitItem.Update(i.ctx.ObjectTTL.Value)
-	}
+	// Update cache lifetime because cache object statue may be changed by setting obj.ttl and obj.grace
+	ttl, grace := i.ctx.ObjectTTL.Value, i.ctx.ObjectGrace.Value
+	i.cache.Update(i.ctx.RequestHash.Value, func(item *cache.CacheItem) {
+		if ttl > 0 {
+			item.Update(ttl)
+		}
+		item.StaleIfError = grace
+	})
 
 	switch state {
 	case DELIVER:
@@ -443,6 +462,7 @@ func (i *Interpreter) ProcessHit() error {
 
 func (i *Interpreter) ProcessPass() error {
 	i.SetScope(context.PassScope)
+	i.ctx.PassRequest = true
 
 	if i.ctx.Backend == nil {
 		return exception.Runtime(nil, "No backend determined in PASS")
@@ -505,38 +525,68 @@ func (i *Interpreter) ProcessFetch() error {
 	var err error
 	i.ctx.BackendResponse, err = i.sendBackendRequest(i.ctx.Backend)
 	if err != nil {
-		return errors.WithStack(err)
+		// When the backend does not respond, Fastly generates 503 error so vcl_error could deliver stale object
+		var fe *backendFetchError
+		if !errors.As(err, &fe) {
+			return errors.WithStack(err)
+		}
+		i.Debugger.Message(fe.Error())
+		i.ctx.ObjectStatus = &value.Integer{Value: http.StatusServiceUnavailable}
+		i.ctx.ObjectResponse = &value.String{Value: "Backend unavailable"}
+		i.Debugger.Message(fmt.Sprintf("Move state: %s -> ERROR", i.ctx.Scope))
+		return i.ProcessError()
 	}
 
 	// Mark request process has ended
 	i.ctx.RequestEndTime = time.Now()
 
 	// Set cacheable strategy
-	isCacheable := cache.IsCacheableStatusCode(i.ctx.BackendResponse.StatusCode)
-	i.ctx.BackendResponseCacheable = &value.Boolean{Value: isCacheable}
-	if isCacheable {
-		i.ctx.BackendResponseTTL = &value.RTime{
-			Value: i.determineCacheTTL(i.ctx.BackendResponse),
-		}
-	}
-	// TODO: consider stale-white-revalidate and stale-if-error TTL
+	freshness := cache.CalculateFreshness(i.ctx.BackendResponse, time.Now())
+	i.ctx.BackendResponseCacheable = &value.Boolean{Value: freshness.Cacheable}
+	i.ctx.BackendResponseTTL = &value.RTime{Value: freshness.TTL}
+	i.ctx.BackendResponseStaleWhileRevalidate = &value.RTime{Value: freshness.StaleWhileRevalidate}
+	i.ctx.BackendResponseGrace = &value.RTime{Value: freshness.StaleIfError}
 
 	// Update cache
-	defer func() {
+	var deliverStale, hitForPass bool
+	storeCache := func() {
+		// Fetched response is discarded when stale object is delivered, and passed response is never cached
+		if deliverStale || i.ctx.PassRequest {
+			return
+		}
+		// Store hit-for-pass marker so that subsequent requests for the hash go to vcl_pass
+		if hitForPass {
+			if ttl := i.ctx.BackendResponseTTL.Value; ttl > 0 {
+				now := time.Now()
+				i.cache.Set(i.ctx.RequestHash.String(), &cache.CacheItem{
+					HitForPass: true,
+					Expires:    now.Add(ttl),
+					EntryTime:  now,
+				})
+			}
+			return
+		}
 		resp := i.cloneResponse(i.ctx.BackendResponse)
 		// Note: compare BackendResponseCacheable value
 		// because this value will be changed by user in vcl_fetch directive
 		if i.ctx.BackendResponseCacheable.Value {
-			if i.ctx.BackendResponseTTL.Value.Seconds() > 0 {
+			ttl := i.ctx.BackendResponseTTL.Value
+			swr := i.ctx.BackendResponseStaleWhileRevalidate.Value
+			sie := i.ctx.BackendResponseGrace.Value
+			// Object which has no TTL is still stored to be served as stale
+			if ttl+max(swr, sie) > 0 {
 				now := time.Now()
 				i.cache.Set(i.ctx.RequestHash.String(), &cache.CacheItem{
-					Response:  resp,
-					Expires:   now.Add(i.ctx.BackendResponseTTL.Value),
-					EntryTime: now,
+					Response:             resp,
+					Expires:              now.Add(ttl),
+					EntryTime:            now,
+					StaleWhileRevalidate: swr,
+					StaleIfError:         sie,
+					ESI:                  i.ctx.TriggerESI,
 				})
 			}
 		}
-	}()
+	}
 
 	// Simulate Fastly statement lifecycle
 	// see: https://developer.fastly.com/learning/vcl/using/#the-vcl-request-lifecycle
@@ -552,6 +602,21 @@ func (i *Interpreter) ProcessFetch() error {
 		}
 	}
 
+	switch {
+	case state == DELIVER_STALE && i.ctx.StaleItem != nil:
+		deliverStale = true
+		i.deliverStale(true)
+	case state == PASS:
+		hitForPass = true
+	}
+	// Object is stored before delivering so that collapsed requests and ESI sub-requests could use it.
+	// Fetched response is never cached when vcl_fetch returns error or restart
+	switch state {
+	case DELIVER, DELIVER_STALE, PASS:
+		storeCache()
+	}
+	i.releaseCollapse()
+
 	switch state {
 	case DELIVER, DELIVER_STALE, PASS:
 		i.Debugger.Message(fmt.Sprintf("Move state: %s -> DELIVER", i.ctx.Scope))
@@ -610,7 +675,10 @@ func (i *Interpreter) ProcessError() error {
 	}
 
 	switch state {
-	case DELIVER:
+	case DELIVER, DELIVER_STALE:
+		if state == DELIVER_STALE && i.ctx.StaleItem != nil {
+			i.deliverStale(true)
+		}
 		i.Debugger.Message(fmt.Sprintf("Move state: %s -> DELIVER", i.ctx.Scope))
 		err = i.ProcessDeliver()
 	case RESTART:
@@ -675,20 +743,13 @@ func (i *Interpreter) ProcessDeliver() error {
 		} else {
 			i.ctx.Response.Header.Set("X-Cache-Hits", "0")
 		}
-		// When Fastly-Debug header is present, add debug header but values are fakes
+		// When Fastly-Debug header is present, add debug header but the path is fake
 		if i.ctx.Request.Header.Get("Fastly-Debug") != "" {
 			i.ctx.Response.Header.Set(
 				"Fastly-Debug-Path",
 				fmt.Sprintf("(D %s 0) (F %s 0)", cache.LocalDatacenterString, cache.LocalDatacenterString),
 			)
-			cacheHit := "M"
-			if i.ctx.State == "HIT" {
-				cacheHit = "H"
-			}
-			i.ctx.Response.Header.Set(
-				"Fastly-Debug-TTL",
-				fmt.Sprintf("(%s %s %.3f %.3f %d)", cacheHit, cache.LocalDatacenterString, 0.000, 0.000, 0),
-			)
+			i.ctx.Response.Header.Set("Fastly-Debug-TTL", i.debugTTL())
 		}
 
 		i.Debugger.Message(fmt.Sprintf("Move state: %s -> LOG", i.ctx.Scope))
@@ -730,32 +791,27 @@ func (i *Interpreter) ProcessLog() error {
 	return nil
 }
 
-var expiresValueLayout = "Mon, 02 Jan 2006 15:04:05 MST"
-
-func (i *Interpreter) determineCacheTTL(resp *http.Response) time.Duration {
-	if v := resp.Header.Get("Surrogate-Control"); v != "" {
-		if strings.HasPrefix(v, "max-age=") {
-			if dur, err := time.ParseDuration(strings.TrimPrefix(v, "max-age=") + "s"); err == nil {
-				return dur
-			}
-		}
-	}
-	if v := resp.Header.Get("Cache-Control"); v != "" {
-		if strings.HasPrefix(v, "s-maxage=") {
-			if dur, err := time.ParseDuration(strings.TrimPrefix(v, "s-maxage=") + "s"); err == nil {
-				return dur
-			}
-		}
-		if strings.HasPrefix(v, "max-age=") {
-			if dur, err := time.ParseDuration(strings.TrimPrefix(v, "max-age=") + "s"); err == nil {
-				return dur
-			}
-		}
+// Format Fastly-Debug-TTL header value as "(<H|M> <node> <remaining TTL> <grace> <age>)".
+// TTL and grace are "-" when the object is not cached.
+func (i *Interpreter) debugTTL() string {
+	node := cache.LocalDatacenterString
+	if i.ctx.State == "HIT" && i.ctx.CacheHitItem != nil {
+		item := i.ctx.CacheHitItem
+		return fmt.Sprintf(
+			"(H %s %.3f %.3f %.0f)",
+			node,
+			time.Until(item.Expires).Seconds(),
+			item.StaleIfError.Seconds(),
+			time.Since(item.EntryTime).Seconds(),
+		)
 	}
-	if v := resp.Header.Get("Expires"); v != "" {
-		if d, err := time.Parse(expiresValueLayout, v); err == nil {
-			return time.Until(d)
-		}
+	if i.ctx.BackendResponse != nil && i.ctx.BackendResponseCacheable.Value && !i.ctx.PassRequest {
+		return fmt.Sprintf(
+			"(M %s %.3f %.3f 0)",
+			node,
+			i.ctx.BackendResponseTTL.Value.Seconds(),
+			i.ctx.BackendResponseGrace.Value.Seconds(),
+		)
 	}
-	return time.Duration(2 * time.Minute)
+	return fmt.Sprintf("(M %s - - 0)", node)
 }
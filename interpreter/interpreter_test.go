@@ -45,7 +45,7 @@ func assertInterpreter(t *testing.T, vcl string, scope context.Scope, assertions
 	ip := New(context.WithResolver(
 		resolver.NewStaticResolver("main", vcl),
 	))
-	ip.ServeHTTP(
+	ip.Serve(
 		httptest.NewRecorder(),
 		httptest.NewRequest(http.MethodGet, "http://localhost", nil),
 	)
@@ -0,0 +1,52 @@
+package interpreter
+
+import (
+	"fmt"
+
+	"github.com/ysugimoto/falco/interpreter/value"
+)
+
+// Look up the cache and move to HIT, PASS (hit-for-pass) or MISS.
+// Concurrent misses for the same hash are collapsed so that only one request fetches from the origin,
+// unless req.hash_ignore_busy is set.
+func (i *Interpreter) ProcessLookup() error {
+	for {
+		if v := i.lookupCache(); v != nil {
+			if v.HitForPass {
+				i.ctx.State = "MISS"
+				i.Debugger.Message(fmt.Sprintf("Hit-for-pass object found, move state: %s -> PASS", i.ctx.Scope))
+				return i.ProcessPass()
+			}
+			i.process.Cached = true
+			i.ctx.State = "HIT"
+			i.ctx.CacheHitItem = v
+			i.ctx.Object = i.cloneResponse(v.Response)
+			i.ctx.ObjectGrace = &value.RTime{Value: v.StaleIfError}
+			i.ctx.TriggerESI = v.ESI
+			i.Debugger.Message(fmt.Sprintf("Move state: %s -> HIT", i.ctx.Scope))
+			return i.ProcessHit()
+		}
+		if i.ctx.HashIgnoreBusy.Value {
+			break
+		}
+		done, leader := i.cache.Collapse(i.ctx.Request.Context(), i.ctx.RequestHash.Value)
+		if leader {
+			i.collapsed = done
+			defer i.releaseCollapse()
+			break
+		}
+		// Another request has fetched the object, look up the cache again
+		i.Debugger.Message("Collapsed request is released")
+	}
+
+	i.ctx.State = "MISS"
+	i.Debugger.Message(fmt.Sprintf("Move state: %s -> MISS", i.ctx.Scope))
+	return i.ProcessMiss()
+}
+
+func (i *Interpreter) releaseCollapse() {
+	if i.collapsed != nil {
+		i.collapsed()
+		i.collapsed = nil
+	}
+}
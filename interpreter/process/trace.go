@@ -0,0 +1,46 @@
+package process
+
+import (
+	"sync"
+
+	"github.com/rs/xid"
+)
+
+// Traces holds finalized process results of recent requests by trace id.
+// Old traces are evicted when the number of traces exceeds the size.
+type Traces struct {
+	mu     sync.Mutex
+	size   int
+	ids    []string
+	traces map[string][]byte
+}
+
+func NewTraces(size int) *Traces {
+	return &Traces{
+		size:   size,
+		traces: make(map[string][]byte),
+	}
+}
+
+// Add stores trace and returns its id
+func (t *Traces) Add(trace []byte) string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	id := xid.New().String()
+	t.ids = append(t.ids, id)
+	t.traces[id] = trace
+	for len(t.ids) > t.size {
+		delete(t.traces, t.ids[0])
+		t.ids = t.ids[1:]
+	}
+	return id
+}
+
+func (t *Traces) Get(id string) ([]byte, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	trace, ok := t.traces[id]
+	return trace, ok
+}
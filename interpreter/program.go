@@ -0,0 +1,153 @@
+package interpreter
+
+import (
+	"sync"
+
+	"github.com/pkg/errors"
+	"github.com/ysugimoto/falco/ast"
+	"github.com/ysugimoto/falco/interpreter/context"
+	"github.com/ysugimoto/falco/interpreter/limitations"
+	"github.com/ysugimoto/falco/lexer"
+	"github.com/ysugimoto/falco/parser"
+	"github.com/ysugimoto/falco/resolver"
+)
+
+// Program is the parsed VCL which is shared between concurrent requests.
+// Fastly reserved subroutines are concatenated and boilerplate macros are extracted when the program is built,
+// so statements are never modified while processing requests.
+type program struct {
+	mu         sync.Mutex
+	statements []ast.Statement
+	main       string
+	modules    map[string]string // include module name -> content
+}
+
+// Resolver which records modules included from the root statements
+type recordingResolver struct {
+	resolver.Resolver
+	modules map[string]string
+}
+
+func (r *recordingResolver) Resolve(stmt *ast.IncludeStatement) (*resolver.VCL, error) {
+	module, err := r.Resolver.Resolve(stmt)
+	if err != nil {
+		return nil, err
+	}
+	r.modules[stmt.Module.Value] = module.Data
+	return module, nil
+}
+
+// Returns root statements of the program.
+// VCL is parsed again only when the main VCL or root included modules are changed.
+func (i *Interpreter) loadProgram() ([]ast.Statement, error) {
+	p := i.program
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.statements != nil && !p.isChanged(i.ctx.Resolver) {
+		return p.statements, nil
+	}
+
+	rslv := &recordingResolver{
+		Resolver: i.ctx.Resolver,
+		modules:  make(map[string]string),
+	}
+	i.ctx.Resolver = rslv
+	defer func() {
+		i.ctx.Resolver = rslv.Resolver
+	}()
+
+	main, err := rslv.MainVCL()
+	if err != nil {
+		i.Debugger.Message(err.Error())
+		return nil, err
+	}
+	if err := limitations.CheckFastlyVCLLimitation(main.Data); err != nil {
+		i.Debugger.Message(err.Error())
+		return nil, err
+	}
+	vcl, err := parser.New(
+		lexer.NewFromString(main.Data, lexer.WithFile(main.Name)),
+	).ParseVCL()
+	if err != nil {
+		// parse error
+		i.Debugger.Message(err.Error())
+		return nil, err
+	}
+
+	// If remote snippets exists, prepare parse and prepend to main VCL
+	if i.ctx.FastlySnippets != nil {
+		for _, snip := range i.ctx.FastlySnippets.EmbedSnippets() {
+			s, err := parser.New(
+				lexer.NewFromString(snip.Data, lexer.WithFile(snip.Name)),
+			).ParseVCL()
+			if err != nil {
+				// parse error
+				i.Debugger.Message(err.Error())
+				return nil, err
+			}
+			vcl.Statements = append(s.Statements, vcl.Statements...)
+		}
+	}
+
+	statements, err := i.resolveIncludeStatement(vcl.Statements, true)
+	if err != nil {
+		return nil, err
+	}
+	if statements, err = i.prepareSubroutines(statements); err != nil {
+		return nil, err
+	}
+
+	p.statements = statements
+	p.main = main.Data
+	p.modules = rslv.modules
+	return statements, nil
+}
+
+func (p *program) isChanged(rslv resolver.Resolver) bool {
+	main, err := rslv.MainVCL()
+	if err != nil || main.Data != p.main {
+		return true
+	}
+	for name, content := range p.modules {
+		module, err := rslv.Resolve(&ast.IncludeStatement{
+			Module: &ast.String{Value: name},
+		})
+		if err != nil || module.Data != content {
+			return true
+		}
+	}
+	return false
+}
+
+// Concatenate duplicated Fastly reserved subroutines and extract boilerplate macros
+// ref: https://developer.fastly.com/reference/vcl/subroutines/#concatenation
+func (i *Interpreter) prepareSubroutines(statements []ast.Statement) ([]ast.Statement, error) {
+	var prepared []ast.Statement
+	reserved := make(map[string]*ast.SubroutineDeclaration)
+
+	for _, stmt := range statements {
+		sub, ok := stmt.(*ast.SubroutineDeclaration)
+		if !ok || sub.ReturnType != nil {
+			prepared = append(prepared, stmt)
+			continue
+		}
+		if _, ok := context.FastlyReservedSubroutine[sub.Name.Value]; !ok {
+			prepared = append(prepared, stmt)
+			continue
+		}
+		if exists, ok := reserved[sub.Name.Value]; ok {
+			exists.Block.Statements = append(exists.Block.Statements, sub.Block.Statements...)
+			continue
+		}
+		reserved[sub.Name.Value] = sub
+		prepared = append(prepared, stmt)
+	}
+
+	for _, sub := range reserved {
+		if err := i.extractBoilerplateMacro(sub); err != nil {
+			return nil, errors.WithStack(err)
+		}
+	}
+	return prepared, nil
+}
@@ -0,0 +1,94 @@
+package interpreter
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/rs/xid"
+	"github.com/ysugimoto/falco/interpreter/value"
+)
+
+const (
+	// Object is marked as stale instead of being removed when this header is sent with "1"
+	SoftPurgeHeader = "Fastly-Soft-Purge"
+	// Admin endpoints which mimic Fastly purge API
+	PurgeAllPath    = "/__falco/purge_all"
+	PurgePathPrefix = "/__falco/purge/"
+)
+
+// Purge request which is returned lookup from vcl_recv purges the object of the request hash
+// and responds the result as Fastly does. The response is generated without going through vcl_deliver.
+// ref: https://developer.fastly.com/reference/http/http-methods/#purge
+func (i *Interpreter) ProcessPurge() error {
+	soft := isSoftPurge(i.ctx.Request)
+	purged := i.cache.Purge(i.ctx.RequestHash.Value, soft)
+	i.Debugger.Message(fmt.Sprintf("Purge %s (soft: %t, purged: %t)", i.ctx.RequestHash.Value, soft, purged))
+
+	body := purgeResult(i.ctx.RequestID.Value)
+	i.ctx.IsLocallyGenerated = &value.Boolean{Value: true}
+	i.ctx.Response = &http.Response{
+		StatusCode: http.StatusOK,
+		Status:     http.StatusText(http.StatusOK),
+		Proto:      "HTTP/1.1",
+		ProtoMajor: 1,
+		ProtoMinor: 1,
+		Header: http.Header{
+			"Content-Type":   {"application/json"},
+			"Content-Length": {strconv.Itoa(len(body))},
+		},
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       i.ctx.Request,
+	}
+	return nil
+}
+
+// Serve admin purge endpoints:
+//
+//	POST /__falco/purge_all   : purge all objects
+//	POST /__falco/purge/{key} : purge objects by surrogate key
+func (i *Interpreter) servePurge(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var purged int
+	if r.URL.Path == PurgeAllPath {
+		purged = i.cache.PurgeAll()
+	} else {
+		key := strings.TrimPrefix(r.URL.Path, PurgePathPrefix)
+		if key == "" {
+			http.Error(w, "surrogate key is required", http.StatusBadRequest)
+			return
+		}
+		purged = i.cache.PurgeKey(key, isSoftPurge(r))
+	}
+	i.Debugger.Message(fmt.Sprintf("Purge %s (purged: %d)", r.URL.Path, purged))
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(purgeResult(xid.New().String())) // nolint:errcheck
+}
+
+func isSoftPurge(r *http.Request) bool {
+	return r.Header.Get(SoftPurgeHeader) == "1"
+}
+
+func purgeResult(id string) []byte {
+	// Marshaling fixed struct never fails
+	out, _ := json.Marshal(struct { // nolint:errcheck
+		Status string `json:"status"`
+		ID     string `json:"id"`
+	}{
+		Status: "ok",
+		ID:     id,
+	})
+	return out
+}
@@ -0,0 +1,114 @@
+// Rate counter and penalty box states which persist across simulator requests
+package ratelimit
+
+import (
+	"sync"
+	"time"
+)
+
+// Ratecounter counts hits of each entry by one second, and keeps them in the last 60 seconds
+const windowSeconds = 60
+
+type counter struct {
+	seconds [windowSeconds]int64 // unix time of the slot
+	counts  [windowSeconds]int64
+}
+
+func (c *counter) increment(delta int64, now time.Time) {
+	sec := now.Unix()
+	slot := sec % windowSeconds
+	if c.seconds[slot] != sec {
+		c.seconds[slot] = sec
+		c.counts[slot] = 0
+	}
+	c.counts[slot] += delta
+}
+
+func (c *counter) count(window time.Duration, now time.Time) int64 {
+	sec := now.Unix()
+	from := sec - int64(window.Seconds())
+	var count int64
+	for i := range c.seconds {
+		if c.seconds[i] > from && c.seconds[i] <= sec {
+			count += c.counts[i]
+		}
+	}
+	return count
+}
+
+type Ratelimit struct {
+	mu           sync.Mutex
+	ratecounters map[string]map[string]*counter  // ratecounter name -> entry -> counter
+	penaltyboxes map[string]map[string]time.Time // penaltybox name -> entry -> expiration
+}
+
+func New() *Ratelimit {
+	return &Ratelimit{
+		ratecounters: make(map[string]map[string]*counter),
+		penaltyboxes: make(map[string]map[string]time.Time),
+	}
+}
+
+// Increment count of the entry in the ratecounter
+func (r *Ratelimit) Increment(rc, entry string, delta int64, now time.Time) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	entries, ok := r.ratecounters[rc]
+	if !ok {
+		entries = make(map[string]*counter)
+		r.ratecounters[rc] = entries
+	}
+	c, ok := entries[entry]
+	if !ok {
+		c = &counter{}
+		entries[entry] = c
+	}
+	c.increment(delta, now)
+}
+
+// Count returns the number of hits of the entry in the last window
+func (r *Ratelimit) Count(rc, entry string, window time.Duration, now time.Time) int64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	c, ok := r.ratecounters[rc][entry]
+	if !ok {
+		return 0
+	}
+	return c.count(window, now)
+}
+
+// Rate returns hits per second of the entry in the last window
+func (r *Ratelimit) Rate(rc, entry string, window time.Duration, now time.Time) float64 {
+	return float64(r.Count(rc, entry, window, now)) / window.Seconds()
+}
+
+// AddPenaltybox adds the entry to the penaltybox until ttl is expired
+func (r *Ratelimit) AddPenaltybox(pb, entry string, ttl time.Duration, now time.Time) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	entries, ok := r.penaltyboxes[pb]
+	if !ok {
+		entries = make(map[string]time.Time)
+		r.penaltyboxes[pb] = entries
+	}
+	entries[entry] = now.Add(ttl)
+}
+
+// InPenaltybox reports whether the entry is in the penaltybox and not expired
+func (r *Ratelimit) InPenaltybox(pb, entry string, now time.Time) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	expires, ok := r.penaltyboxes[pb][entry]
+	if !ok {
+		return false
+	}
+	if !now.Before(expires) {
+		delete(r.penaltyboxes[pb], entry)
+		return false
+	}
+	return true
+}
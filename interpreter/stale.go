@@ -0,0 +1,82 @@
+package interpreter
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/ysugimoto/falco/interpreter/cache"
+	"github.com/ysugimoto/falco/interpreter/value"
+)
+
+// Find cache object for the request hash.
+// Fresh object (or hit-for-pass marker) is a cache hit, and stale object could be served while revalidating is also treated as a hit.
+// Otherwise stale object is kept to be delivered by return(deliver_stale) when the origin is failed.
+func (i *Interpreter) lookupCache() *cache.CacheItem {
+	hash := i.ctx.RequestHash.Value
+	if i.ctx.HashAlwaysMiss.Value {
+		return nil
+	}
+	if v := i.cache.Get(hash); v != nil {
+		return v
+	}
+
+	stale := i.cache.Stale(hash)
+	if stale == nil {
+		return nil
+	}
+	now := time.Now()
+	if stale.CanServeWhileRevalidate(now, i.ctx.MaxStaleWhileRevalidate.Value) {
+		i.ctx.StaleExists = &value.Boolean{Value: true}
+		i.ctx.Stale = &value.Boolean{Value: true}
+		i.ctx.StaleIsRevalidating = &value.Boolean{Value: true}
+		i.revalidate()
+		return stale
+	}
+	if stale.CanServeIfError(now, i.ctx.MaxStaleIfError.Value) {
+		i.ctx.StaleExists = &value.Boolean{Value: true}
+		i.ctx.StaleItem = stale
+	}
+	return nil
+}
+
+// Deliver the stale object instead of the backend response or the synthetic object
+func (i *Interpreter) deliverStale(isError bool) {
+	i.Debugger.Message("Deliver stale object")
+	i.ctx.Object = i.cloneResponse(i.ctx.StaleItem.Response)
+	i.ctx.CacheHitItem = i.ctx.StaleItem
+	i.ctx.TriggerESI = i.ctx.StaleItem.ESI
+	i.ctx.IsLocallyGenerated = &value.Boolean{Value: false}
+	i.ctx.Stale = &value.Boolean{Value: true}
+	i.ctx.StaleIsError = &value.Boolean{Value: isError}
+}
+
+// Refresh the stale object in background.
+// Background fetch runs from vcl_miss with the current request, the response is only used for updating the cache.
+func (i *Interpreter) revalidate() {
+	hash := i.ctx.RequestHash.Value
+	if !i.cache.BeginRevalidate(hash) {
+		return
+	}
+
+	// Original request context will be canceled after the client response is sent
+	req := i.ctx.Request.Clone(context.Background())
+	backend := i.ctx.Backend
+
+	go func() {
+		defer i.cache.EndRevalidate(hash)
+
+		f := i.fork()
+		if err := f.ProcessInit(req); err != nil {
+			f.Debugger.Message(fmt.Sprintf("Failed to revalidate in background: %s", err))
+			return
+		}
+		f.ctx.Backend = backend
+		f.ctx.RequestHash = &value.String{Value: hash}
+		f.ctx.IsBackgroundFetch = &value.Boolean{Value: true}
+		f.ctx.State = "MISS"
+		if err := f.ProcessMiss(); err != nil {
+			f.Debugger.Message(fmt.Sprintf("Failed to revalidate in background: %s", err))
+		}
+	}()
+}
@@ -18,6 +18,7 @@ const (
 	END            State = "end"
 	INTERNAL_ERROR State = "_internal_error_"
 	BARE_RETURN    State = "_bare_return_"
+	GOTO           State = "_goto_"
 )
 
 func (s State) String() string {
@@ -46,6 +47,8 @@ func (s State) String() string {
 		return "_internal_error_"
 	case BARE_RETURN:
 		return "_bare_return_"
+	case GOTO:
+		return "_goto_"
 	default:
 		return ""
 	}
@@ -29,7 +29,9 @@ func (i *Interpreter) ProcessBlockStatement(
 	var err error
 	var debugState DebugState = ds
 
-	for _, stmt := range statements {
+	for index := 0; index < len(statements); index++ {
+		stmt := statements[index]
+		i.Coverage.Statement(stmt)
 		// Call debugger
 		if debugState != DebugStepOut {
 			debugState = i.Debugger.Run(stmt)
@@ -66,11 +68,17 @@ func (i *Interpreter) ProcessBlockStatement(
 			}
 			err = i.ProcessSyntheticBase64Statement(t)
 
-		// TODO: implement
-		// case *ast.GotoStatement:
-		// 	err = i.ProcessGotoStatement(t)
-		// case *ast.GotoDestinationStatement:
-		// 	err = i.ProcessGotoDesticationStatement(t)
+		case *ast.GotoStatement:
+			var state State
+			if state, err = i.ProcessGotoStatement(t); err == nil {
+				index, state, err = i.jumpToGotoDestination(statements, index)
+			}
+			// Destination is not found in this block, propagate to the parent block
+			if state != NONE {
+				return value.Null, state, DebugPass, nil
+			}
+		case *ast.GotoDestinationStatement:
+			// Nothing to do, destination is just a marker to jump
 
 		// Probably change status statements
 		case *ast.FunctionCallStatement:
@@ -106,6 +114,9 @@ func (i *Interpreter) ProcessBlockStatement(
 			if val != value.Null {
 				return val, NONE, DebugPass, err
 			}
+			if state == GOTO {
+				index, state, err = i.jumpToGotoDestination(statements, index)
+			}
 			if state != NONE {
 				return value.Null, state, DebugPass, nil
 			}
@@ -117,6 +128,9 @@ func (i *Interpreter) ProcessBlockStatement(
 			if val != value.Null {
 				return val, NONE, DebugPass, err
 			}
+			if state == GOTO {
+				index, state, err = i.jumpToGotoDestination(statements, index)
+			}
 			if state != NONE {
 				return value.Null, state, DebugPass, nil
 			}
@@ -177,6 +191,12 @@ func (i *Interpreter) ProcessBlockStatement(
 			if val != value.Null {
 				return val, NONE, DebugPass, nil
 			}
+			if state == GOTO {
+				index, state, err = i.jumpToGotoDestination(statements, index)
+				if err != nil {
+					return value.Null, NONE, DebugPass, errors.WithStack(err)
+				}
+			}
 			if state != NONE {
 				return value.Null, state, DebugPass, nil
 			}
@@ -195,6 +215,43 @@ func (i *Interpreter) ProcessBlockStatement(
 	return value.Null, NONE, DebugPass, nil
 }
 
+func (i *Interpreter) ProcessGotoStatement(stmt *ast.GotoStatement) (State, error) {
+	if _, ok := i.ctx.Gotos[stmt.Destination.Value]; !ok {
+		return NONE, exception.Runtime(
+			&stmt.GetMeta().Token,
+			"goto destination %s is not found in the subroutine",
+			stmt.Destination.Value,
+		)
+	}
+	i.gotoStatement = stmt
+	return GOTO, nil
+}
+
+// jumpToGotoDestination finds pending goto destination in the statements.
+// If found, returns index of the destination statement and clears pending goto
+// so that caller continues to process from next of the destination.
+// If not found, returns GOTO state as it is in order to find the destination in the parent block.
+func (i *Interpreter) jumpToGotoDestination(statements []ast.Statement, current int) (int, State, error) {
+	stmt := i.gotoStatement
+	for index, s := range statements {
+		dest, ok := s.(*ast.GotoDestinationStatement)
+		if !ok || strings.TrimSuffix(dest.Name.Value, ":") != stmt.Destination.Value {
+			continue
+		}
+		// Fastly only allows forward jump
+		if index <= current {
+			return current, NONE, exception.Runtime(
+				&stmt.GetMeta().Token,
+				"goto destination %s is placed before goto statement, only forward jump is allowed",
+				stmt.Destination.Value,
+			)
+		}
+		i.gotoStatement = nil
+		return index, NONE, nil
+	}
+	return current, GOTO, nil
+}
+
 func (i *Interpreter) ProcessDeclareStatement(stmt *ast.DeclareStatement) error {
 	return i.localVars.Declare(stmt.Name.Value, stmt.ValueType.Value)
 }
@@ -472,6 +529,7 @@ func (i *Interpreter) ProcessIfStatement(
 	switch t := cond.(type) {
 	case *value.Boolean:
 		if t.Value {
+			i.Coverage.Branch(stmt, 0)
 			val, state, _, err := i.ProcessBlockStatement(stmt.Consequence.Statements, ds, isReturnAsValue)
 			if err != nil {
 				return value.Null, NONE, errors.WithStack(err)
@@ -483,6 +541,7 @@ func (i *Interpreter) ProcessIfStatement(
 		}
 	case *value.String:
 		if !t.IsNotSet {
+			i.Coverage.Branch(stmt, 0)
 			val, state, _, err := i.ProcessBlockStatement(stmt.Consequence.Statements, ds, isReturnAsValue)
 			if err != nil {
 				return value.Null, NONE, errors.WithStack(err)
@@ -502,7 +561,7 @@ func (i *Interpreter) ProcessIfStatement(
 	}
 
 	// else if
-	for _, ei := range stmt.Another {
+	for n, ei := range stmt.Another {
 		// Call debugger
 		if ds != DebugStepOut {
 			ds = i.Debugger.Run(ei)
@@ -515,6 +574,7 @@ func (i *Interpreter) ProcessIfStatement(
 		switch t := cond.(type) {
 		case *value.Boolean:
 			if t.Value {
+				i.Coverage.Branch(stmt, n+1)
 				val, state, _, err := i.ProcessBlockStatement(ei.Consequence.Statements, ds, isReturnAsValue)
 				if err != nil {
 					return value.Null, NONE, errors.WithStack(err)
@@ -526,6 +586,7 @@ func (i *Interpreter) ProcessIfStatement(
 			}
 		case *value.String:
 			if !t.IsNotSet {
+				i.Coverage.Branch(stmt, n+1)
 				val, state, _, err := i.ProcessBlockStatement(ei.Consequence.Statements, ds, isReturnAsValue)
 				if err != nil {
 					return value.Null, NONE, errors.WithStack(err)
@@ -545,7 +606,8 @@ func (i *Interpreter) ProcessIfStatement(
 		}
 	}
 
-	// else
+	// else, branch is counted even if else statement is omitted
+	i.Coverage.Branch(stmt, len(stmt.Another)+1)
 	if stmt.Alternative != nil {
 		val, state, _, err := i.ProcessBlockStatement(stmt.Alternative.Statements, ds, isReturnAsValue)
 		if err != nil {
@@ -596,6 +658,10 @@ func (i *Interpreter) ProcessSwitchStatement(
 		if err != nil {
 			return value.Null, NONE, errors.WithStack(err)
 		}
+		// Case returns a value in functional subroutine is also matched
+		if matched || val != value.Null {
+			i.Coverage.Branch(stmt, n)
+		}
 		if val != value.Null {
 			return val, NONE, nil
 		}
@@ -605,6 +671,7 @@ func (i *Interpreter) ProcessSwitchStatement(
 	}
 	// No cases matched, check if switch has a default case.
 	if stmt.Default != -1 {
+		i.Coverage.Branch(stmt, stmt.Default)
 		val, state, _, err := i.ProcessCaseStatement(stmt, stmt.Default, control, false, ds, isReturnAsValue)
 		if err != nil {
 			return value.Null, NONE, errors.WithStack(err)
@@ -614,7 +681,8 @@ func (i *Interpreter) ProcessSwitchStatement(
 		}
 		return value.Null, state, nil
 	}
-
+	// Omitted default case is counted as the last branch
+	i.Coverage.Branch(stmt, len(stmt.Cases))
 	return value.Null, NONE, nil
 }
 
@@ -2,12 +2,16 @@ package interpreter
 
 import (
 	"net/http"
+	"net/http/httptest"
 	"testing"
 	"time"
 
+	"github.com/google/go-cmp/cmp"
 	"github.com/ysugimoto/falco/ast"
 	"github.com/ysugimoto/falco/interpreter/context"
+	"github.com/ysugimoto/falco/interpreter/coverage"
 	"github.com/ysugimoto/falco/interpreter/value"
+	"github.com/ysugimoto/falco/resolver"
 )
 
 func TestDeclareStatement(t *testing.T) {
@@ -816,3 +820,196 @@ func TestSwitchStatement(t *testing.T) {
 		})
 	}
 }
+
+func TestGotoStatement(t *testing.T) {
+	tests := []struct {
+		name       string
+		vcl        string
+		assertions map[string]value.Value
+		isError    bool
+	}{
+		{
+			name: "Jump forward to the destination",
+			vcl: `
+			sub vcl_recv {
+				set req.http.before = "1";
+				goto skip;
+				set req.http.skipped = "1";
+				skip:
+				set req.http.after = "1";
+			}`,
+			assertions: map[string]value.Value{
+				"req.http.before":  &value.String{Value: "1"},
+				"req.http.skipped": &value.String{IsNotSet: true},
+				"req.http.after":   &value.String{Value: "1"},
+			},
+		},
+		{
+			name: "Jump from nested if block",
+			vcl: `
+			sub vcl_recv {
+				if (req.url) {
+					if (true) {
+						goto skip;
+					}
+					set req.http.skipped = "1";
+				}
+				set req.http.skipped = "2";
+				skip:
+				set req.http.after = "1";
+			}`,
+			assertions: map[string]value.Value{
+				"req.http.skipped": &value.String{IsNotSet: true},
+				"req.http.after":   &value.String{Value: "1"},
+			},
+		},
+		{
+			name: "Jump from switch case",
+			vcl: `
+			sub vcl_recv {
+				set req.http.control = "1";
+				switch (req.http.control) {
+				case "1":
+					goto skip;
+					break;
+				}
+				set req.http.skipped = "1";
+				skip:
+				set req.http.after = "1";
+			}`,
+			assertions: map[string]value.Value{
+				"req.http.skipped": &value.String{IsNotSet: true},
+				"req.http.after":   &value.String{Value: "1"},
+			},
+		},
+		{
+			name: "Jump in functional subroutine",
+			vcl: `
+			sub compute STRING {
+				if (true) {
+					goto skip;
+				}
+				return "skipped";
+				skip:
+				return "jumped";
+			}
+
+			sub vcl_recv {
+				set req.http.result = compute();
+			}`,
+			assertions: map[string]value.Value{
+				"req.http.result": &value.String{Value: "jumped"},
+			},
+		},
+		{
+			name: "Destination is not found",
+			vcl: `
+			sub vcl_recv {
+				goto missing;
+			}`,
+			isError: true,
+		},
+		{
+			name: "Backward jump is not allowed",
+			vcl: `
+			sub vcl_recv {
+				back:
+				set req.http.before = "1";
+				goto back;
+			}`,
+			isError: true,
+		},
+		{
+			name: "Destination in another subroutine is not found",
+			vcl: `
+			sub other {
+				skip:
+				set req.http.other = "1";
+			}
+
+			sub vcl_recv {
+				goto skip;
+				call other;
+			}`,
+			isError: true,
+		},
+		{
+			name: "Cannot jump into nested block",
+			vcl: `
+			sub vcl_recv {
+				goto skip;
+				if (true) {
+					skip:
+					set req.http.after = "1";
+				}
+			}`,
+			isError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assertInterpreter(t, tt.vcl, context.RecvScope, tt.assertions, tt.isError)
+		})
+	}
+}
+
+func TestCoverage(t *testing.T) {
+	vcl := `
+sub vcl_recv {
+  if (req.http.A) {
+    set req.http.X = "a";
+  } else if (req.http.B) {
+    set req.http.X = "b";
+  }
+  switch (req.http.C) {
+  case "1":
+    set req.http.Y = "1";
+    break;
+  default:
+    set req.http.Y = "default";
+    break;
+  }
+  error 600;
+}
+
+sub unused {
+  set req.http.Z = "1";
+}
+`
+	ip := New(context.WithResolver(resolver.NewStaticResolver("main", vcl)))
+	ip.Coverage = coverage.New()
+
+	for _, headers := range []map[string]string{{"A": "1", "C": "1"}, {}} {
+		req := httptest.NewRequest(http.MethodGet, "http://localhost", nil)
+		for k, v := range headers {
+			req.Header.Set(k, v)
+		}
+		ip.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	report := ip.Coverage.Report("")
+	expect := []*coverage.SubroutineReport{
+		{
+			Name:       "vcl_recv",
+			Line:       2,
+			Hits:       2,
+			Statements: coverage.Counter{Covered: 8, Total: 9},
+			Branches:   coverage.Counter{Covered: 4, Total: 5},
+		},
+		{
+			Name:       "unused",
+			Line:       19,
+			Statements: coverage.Counter{Covered: 0, Total: 1},
+		},
+	}
+	if len(report.Files) != 1 {
+		t.Fatalf("Expected one file is reported, got %d", len(report.Files))
+	}
+	if diff := cmp.Diff(expect, report.Files[0].Subroutines); diff != "" {
+		t.Errorf("Unexpected subroutine coverage, diff=%s", diff)
+	}
+	if diff := cmp.Diff(coverage.Counter{Covered: 1, Total: 2}, report.Subroutines); diff != "" {
+		t.Errorf("Unexpected total subroutine coverage, diff=%s", diff)
+	}
+}
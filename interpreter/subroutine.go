@@ -22,54 +22,70 @@ func (i *Interpreter) ProcessTestSubroutine(scope context.Scope, sub *ast.Subrou
 
 func (i *Interpreter) ProcessSubroutine(sub *ast.SubroutineDeclaration, ds DebugState) (State, error) {
 	i.process.Flows = append(i.process.Flows, process.NewFlow(i.ctx, sub))
+	i.ctx.VisitedSubroutines = append(i.ctx.VisitedSubroutines, sub.Name.Value)
+	i.Coverage.Subroutine(sub)
 
 	// Store the current values and restore after subroutine has ended
 	regex := i.ctx.RegexMatchedValues
 	local := i.localVars
+	gotos := i.ctx.Gotos
 	i.ctx.RegexMatchedValues = make(map[string]*value.String)
 	i.localVars = variable.LocalVariables{}
+	i.ctx.Gotos = make(map[string]*ast.GotoDestinationStatement)
 
 	defer func() {
 		i.ctx.RegexMatchedValues = regex
 		i.localVars = local
+		i.ctx.Gotos = gotos
 		i.ctx.SubroutineCalls[sub.Name.Value]++
 	}()
 
-	// Try to extract fastly reserved subroutine macro
-	if err := i.extractBoilerplateMacro(sub); err != nil {
-		return NONE, errors.WithStack(err)
-	}
-
 	statements, err := i.resolveIncludeStatement(sub.Block.Statements, false)
 	if err != nil {
 		return NONE, errors.WithStack(err)
 	}
+	i.Coverage.RegisterSubroutine(sub, statements)
+
+	// goto destination must be declared in the same subroutine
+	collectGotoDestinations(statements, i.ctx.Gotos)
 
 	// Ignore debug status and must return state, not a value
 	_, state, _, err := i.ProcessBlockStatement(statements, ds, false)
+	if state == GOTO {
+		return NONE, i.unreachableGotoDestination()
+	}
 	return state, err
 }
 
 // nolint: gocognit
 func (i *Interpreter) ProcessFunctionSubroutine(sub *ast.SubroutineDeclaration, ds DebugState) (value.Value, State, error) {
 	i.process.Flows = append(i.process.Flows, process.NewFlow(i.ctx, sub))
+	i.ctx.VisitedSubroutines = append(i.ctx.VisitedSubroutines, sub.Name.Value)
+	i.Coverage.Subroutine(sub)
 
 	// Store the current values and restore after subroutine has ended
 	regex := i.ctx.RegexMatchedValues
 	local := i.localVars
+	gotos := i.ctx.Gotos
 	i.ctx.RegexMatchedValues = make(map[string]*value.String)
 	i.localVars = variable.LocalVariables{}
+	i.ctx.Gotos = make(map[string]*ast.GotoDestinationStatement)
 
 	defer func() {
 		i.ctx.RegexMatchedValues = regex
 		i.localVars = local
+		i.ctx.Gotos = gotos
 		i.ctx.SubroutineCalls[sub.Name.Value]++
 	}()
 
+	collectGotoDestinations(sub.Block.Statements, i.ctx.Gotos)
+
 	var err error
 	var debugState DebugState = ds
 
-	for _, stmt := range sub.Block.Statements {
+	for index := 0; index < len(sub.Block.Statements); index++ {
+		stmt := sub.Block.Statements[index]
+		i.Coverage.Statement(stmt)
 		// Call debugger
 		if debugState != DebugStepOut {
 			debugState = i.Debugger.Run(stmt)
@@ -93,10 +109,16 @@ func (i *Interpreter) ProcessFunctionSubroutine(sub *ast.SubroutineDeclaration,
 			err = i.ProcessSyntheticStatement(t)
 		case *ast.SyntheticBase64Statement:
 			err = i.ProcessSyntheticBase64Statement(t)
-		// case *ast.GotoStatement:
-		// 	err = i.ProcessGotoStatement(t)
-		// case *ast.GotoDestinationStatement:
-		// 	err = i.ProcessGotoDesticationStatement(t)
+		case *ast.GotoStatement:
+			var state State
+			if state, err = i.ProcessGotoStatement(t); err == nil {
+				index, state, err = i.jumpToGotoDestination(sub.Block.Statements, index)
+			}
+			if state == GOTO {
+				return value.Null, NONE, i.unreachableGotoDestination()
+			}
+		case *ast.GotoDestinationStatement:
+			// Nothing to do, destination is just a marker to jump
 		// Probably change status statements
 		case *ast.BlockStatement:
 			var val value.Value
@@ -105,6 +127,12 @@ func (i *Interpreter) ProcessFunctionSubroutine(sub *ast.SubroutineDeclaration,
 			if val != value.Null {
 				return val, NONE, nil
 			}
+			if state == GOTO && err == nil {
+				index, state, err = i.jumpToGotoDestination(sub.Block.Statements, index)
+			}
+			if state == GOTO {
+				return value.Null, NONE, i.unreachableGotoDestination()
+			}
 			if state != NONE {
 				return value.Null, state, nil
 			}
@@ -138,6 +166,12 @@ func (i *Interpreter) ProcessFunctionSubroutine(sub *ast.SubroutineDeclaration,
 			if val != value.Null {
 				return val, NONE, nil
 			}
+			if state == GOTO {
+				index, state, err = i.jumpToGotoDestination(sub.Block.Statements, index)
+			}
+			if state == GOTO {
+				return value.Null, NONE, i.unreachableGotoDestination()
+			}
 			if state != NONE {
 				return value.Null, state, nil
 			}
@@ -148,6 +182,12 @@ func (i *Interpreter) ProcessFunctionSubroutine(sub *ast.SubroutineDeclaration,
 			if val != value.Null {
 				return val, NONE, nil
 			}
+			if state == GOTO {
+				index, state, err = i.jumpToGotoDestination(sub.Block.Statements, index)
+			}
+			if state == GOTO {
+				return value.Null, NONE, i.unreachableGotoDestination()
+			}
 			if state != NONE {
 				return value.Null, state, nil
 			}
@@ -199,6 +239,41 @@ func (i *Interpreter) ProcessFunctionSubroutine(sub *ast.SubroutineDeclaration,
 	)
 }
 
+// Goto statement could not find its destination in the same or outer blocks.
+// It means destination is placed inside the other nested block which cannot jump into.
+func (i *Interpreter) unreachableGotoDestination() error {
+	stmt := i.gotoStatement
+	i.gotoStatement = nil
+	return exception.Runtime(
+		&stmt.GetMeta().Token,
+		"goto destination %s is not reachable, destination must be placed in the same or outer block",
+		stmt.Destination.Value,
+	)
+}
+
+func collectGotoDestinations(statements []ast.Statement, gotos map[string]*ast.GotoDestinationStatement) {
+	for _, stmt := range statements {
+		switch t := stmt.(type) {
+		case *ast.GotoDestinationStatement:
+			gotos[strings.TrimSuffix(t.Name.Value, ":")] = t
+		case *ast.BlockStatement:
+			collectGotoDestinations(t.Statements, gotos)
+		case *ast.IfStatement:
+			collectGotoDestinations(t.Consequence.Statements, gotos)
+			for _, another := range t.Another {
+				collectGotoDestinations(another.Consequence.Statements, gotos)
+			}
+			if t.Alternative != nil {
+				collectGotoDestinations(t.Alternative.Statements, gotos)
+			}
+		case *ast.SwitchStatement:
+			for _, c := range t.Cases {
+				collectGotoDestinations(c.Statements, gotos)
+			}
+		}
+	}
+}
+
 func (i *Interpreter) ProcessExpressionReturnStatement(stmt *ast.ReturnStatement) (value.Value, State, error) {
 	val, err := i.ProcessExpression(*stmt.ReturnExpression, false)
 	if err != nil {
@@ -2,12 +2,14 @@ package interpreter
 
 import (
 	"context"
+	"fmt"
 	"io"
 	"net/http"
 	"strings"
 
 	"github.com/pkg/errors"
 	"github.com/ysugimoto/falco/ast"
+	"github.com/ysugimoto/falco/interpreter/exception"
 	"github.com/ysugimoto/falco/interpreter/value"
 )
 
@@ -19,20 +21,7 @@ func (i *Interpreter) TestProcessInit(r *http.Request) error {
 		return errors.WithStack(err)
 	}
 
-	// If backend is not defined in main VCL, set virual backend
-	if i.ctx.Backend == nil {
-		i.ctx.Backend = &value.Backend{
-			Value: &ast.BackendDeclaration{
-				Name: &ast.Ident{Value: "falco_local_backend"},
-				Properties: []*ast.BackendProperty{
-					{
-						Key:   &ast.Ident{Value: "host"},
-						Value: &ast.String{Value: "http://localhost:3124"},
-					},
-				},
-			},
-		}
-	}
+	i.setTestingBackend()
 
 	// On testing process, all request/response variables should be set initially
 	i.ctx.BackendRequest, err = i.createBackendRequest(i.ctx, i.ctx.Backend)
@@ -57,3 +46,82 @@ func (i *Interpreter) TestProcessInit(r *http.Request) error {
 	i.ctx.Object = i.cloneResponse(i.ctx.BackendResponse)
 	return nil
 }
+
+// If backend is not defined in main VCL, set virual backend
+func (i *Interpreter) setTestingBackend() {
+	if i.ctx.Backend != nil {
+		return
+	}
+	i.ctx.Backend = &value.Backend{
+		Value: &ast.BackendDeclaration{
+			Name: &ast.Ident{Value: "falco_local_backend"},
+			Properties: []*ast.BackendProperty{
+				{
+					Key:   &ast.Ident{Value: "host"},
+					Value: &ast.String{Value: "http://localhost:3124"},
+				},
+			},
+		},
+	}
+}
+
+// Mocked backend response which is used instead of sending the backend request on testing
+type MockedBackendResponse struct {
+	StatusCode int
+	Header     http.Header
+	Body       string
+}
+
+// Mock the response of the backend, the request to the backend is never sent to the network
+func (i *Interpreter) MockBackend(name string, resp *MockedBackendResponse) {
+	if i.backendMocks == nil {
+		i.backendMocks = make(map[string]*MockedBackendResponse)
+	}
+	i.backendMocks[name] = resp
+}
+
+// Run whole request lifecycle from vcl_recv with the current request on testing.
+// The request is processed in the new request context,
+// but testing states like fixed time, modified tables and overridden host are kept.
+// Backends which are not mocked are treated as unavailable, backend requests are never sent to the network.
+func (i *Interpreter) TestProcessRequest() error {
+	prev := i.ctx
+	if err := i.ProcessInit(prev.Request.Clone(prev.Request.Context())); err != nil {
+		return errors.WithStack(err)
+	}
+	i.ctx.FixedTime = prev.FixedTime
+	i.ctx.Tables = prev.Tables
+	i.ctx.OriginalHost = prev.OriginalHost
+	i.ctx.RatecounterEntries = prev.RatecounterEntries
+	i.setTestingBackend()
+	if i.backendMocks == nil {
+		i.backendMocks = make(map[string]*MockedBackendResponse)
+	}
+	i.TestingState = NONE
+
+	return errors.WithStack(i.ProcessRecv())
+}
+
+func (i *Interpreter) mockedBackendResponse(backend *value.Backend) (*http.Response, error) {
+	name := backend.Value.Name.Value
+	mock, ok := i.backendMocks[name]
+	if !ok {
+		return nil, &backendFetchError{
+			err: exception.Runtime(nil, "Backend %s is not mocked", name),
+		}
+	}
+	i.Debugger.Message(fmt.Sprintf("Backend (%s) responds mocked status code %d", name, mock.StatusCode))
+
+	return &http.Response{
+		StatusCode:    mock.StatusCode,
+		Status:        http.StatusText(mock.StatusCode),
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        mock.Header.Clone(),
+		Body:          io.NopCloser(strings.NewReader(mock.Body)),
+		ContentLength: int64(len(mock.Body)),
+		Trailer:       http.Header{},
+		Request:       i.ctx.BackendRequest,
+	}, nil
+}
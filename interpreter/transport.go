@@ -25,6 +25,16 @@ import (
 
 const HTTPS_SCHEME = "https"
 
+// Error which indicates the backend did not respond.
+// Fastly moves to vcl_error with 503 status on this error
+type backendFetchError struct {
+	err error
+}
+
+func (e *backendFetchError) Error() string {
+	return e.err.Error()
+}
+
 func getOverrideBackend(ctx *icontext.Context, backendName string) (*config.OverrideBackend, error) {
 	for key, val := range ctx.OverrideBackends {
 		p, err := glob.Compile(key)
@@ -166,6 +176,11 @@ func (i *Interpreter) sendBackendRequest(backend *value.Backend) (*http.Response
 		return nil, errors.WithStack(err)
 	}
 
+	// On testing, mocked response is used instead
+	if i.backendMocks != nil {
+		return i.mockedBackendResponse(backend)
+	}
+
 	client := http.DefaultClient
 	if req.URL.Scheme == HTTPS_SCHEME {
 		client = &http.Client{
@@ -178,7 +193,9 @@ func (i *Interpreter) sendBackendRequest(backend *value.Backend) (*http.Response
 	}
 	resp, err := client.Do(req)
 	if err != nil {
-		return nil, exception.Runtime(nil, "Failed to retrieve backend response: %s", err)
+		return nil, &backendFetchError{
+			err: exception.Runtime(nil, "Failed to retrieve backend response: %s", err),
+		}
 	}
 
 	// Debug message
@@ -74,12 +74,8 @@ func (v *AllScopeVariables) Get(s context.Scope, name string) (value.Value, erro
 		CLIENT_CLASS_SPAM,
 		CLIENT_PLATFORM_MEDIAPLAYER,
 		REQ_BACKEND_IS_SHIELD,
-		REQ_IS_BACKGROUND_FETCH,
 		REQ_IS_CLUSTERING,
 		REQ_IS_ESI_SUBREQ,
-		RESP_STALE,
-		RESP_STALE_IS_ERROR,
-		RESP_STALE_IS_REVALIDATING,
 		WORKSPACE_OVERFLOWED:
 		return &value.Boolean{Value: false}, nil
 
@@ -499,7 +495,11 @@ func (v *AllScopeVariables) Get(s context.Scope, name string) (value.Value, erro
 			id = FALCO_VIRTUAL_SERVICE_ID
 		}
 		return &value.String{Value: id}, nil
-	case REQ_TOPURL: // FIXME: what is the difference of req.url ?
+	case REQ_TOPURL:
+		// ESI sub-request has the URL of the top-level request
+		if v.ctx.TopURL.Value != "" {
+			return v.ctx.TopURL, nil
+		}
 		u := req.URL.EscapedPath()
 		if v := req.URL.RawQuery; v != "" {
 			u += "?" + v
@@ -564,7 +564,15 @@ func (v *AllScopeVariables) Get(s context.Scope, name string) (value.Value, erro
 	case SERVER_REGION:
 		return &value.String{Value: "US"}, nil
 	case STALE_EXISTS:
-		return v.ctx.StaleContents, nil
+		return v.ctx.StaleExists, nil
+	case REQ_IS_BACKGROUND_FETCH:
+		return v.ctx.IsBackgroundFetch, nil
+	case RESP_STALE:
+		return v.ctx.Stale, nil
+	case RESP_STALE_IS_ERROR:
+		return v.ctx.StaleIsError, nil
+	case RESP_STALE_IS_REVALIDATING:
+		return v.ctx.StaleIsRevalidating, nil
 	case TIME_ELAPSED_MSEC:
 		return &value.String{
 			Value: fmt.Sprint(time.Since(v.ctx.RequestStartTime).Milliseconds()),
@@ -645,23 +653,7 @@ func (v *AllScopeVariables) getFromRegex(name string) value.Value {
 
 	// Ratecounter variable matching
 	if match := rateCounterRegex.FindStringSubmatch(name); match != nil {
-		var val float64
-		// all ratecounter variable value returns 1.0 fixed value
-		switch match[1] {
-		case "rate_10s",
-			"rate_1s",
-			"rate.60s",
-			"bucket.10s",
-			"bucket.20s",
-			"bucket.30s",
-			"bucket.40s",
-			"bucket.50s",
-			"bucket.60s":
-			val = 1.0
-		}
-		return &value.Float{
-			Value: val,
-		}
+		return v.getRatecounterValue(match[1], match[2])
 	}
 
 	if match := backendConnectionsOpenRegex.FindStringSubmatch(name); match != nil {
@@ -679,6 +671,31 @@ func (v *AllScopeVariables) getFromRegex(name string) value.Value {
 	return nil
 }
 
+// Ratecounter variables refer to the entry which is incremented last in the request
+func (v *AllScopeVariables) getRatecounterValue(rc, name string) value.Value {
+	entry, ok := v.ctx.RatecounterEntries[rc]
+	now := time.Now()
+	if v.ctx.FixedTime != nil {
+		now = *v.ctx.FixedTime
+	}
+
+	switch name {
+	case "rate.1s", "rate.10s", "rate.60s":
+		if !ok {
+			return &value.Float{}
+		}
+		window, _ := time.ParseDuration(strings.TrimPrefix(name, "rate.")) // nolint:errcheck
+		return &value.Float{Value: v.ctx.Ratelimit.Rate(rc, entry, window, now)}
+	case "bucket.10s", "bucket.20s", "bucket.30s", "bucket.40s", "bucket.50s", "bucket.60s":
+		if !ok {
+			return &value.Integer{}
+		}
+		window, _ := time.ParseDuration(strings.TrimPrefix(name, "bucket.")) // nolint:errcheck
+		return &value.Integer{Value: v.ctx.Ratelimit.Count(rc, entry, window, now)}
+	}
+	return nil
+}
+
 func (v *AllScopeVariables) Set(s context.Scope, name, operator string, val value.Value) error {
 	switch strings.ToLower(name) {
 	case CLIENT_IDENTITY:
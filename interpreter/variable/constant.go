@@ -7,7 +7,7 @@ import (
 // Frequent occurrences string constant
 const (
 	PORT                     = "port"
-	PURGE                    = "purge"
+	PURGE                    = "PURGE"
 	FALCO_VIRTUAL_SERVICE_ID = "falco-virtual-service-id"
 	FALCO_SERVER_HOSTNAME    = "cache-localsimulator"
 	FALCO_DATACENTER         = "FALCO"
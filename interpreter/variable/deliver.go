@@ -120,7 +120,7 @@ func (v *DeliverScopeVariables) Get(s context.Scope, name string) (value.Value,
 		return &value.Boolean{Value: parsed.Is6()}, nil
 
 	case REQ_IS_PURGE:
-		return &value.Boolean{Value: v.ctx.Request.Method == PURGE}, nil
+		return v.ctx.IsPurge, nil
 
 	case REQ_BACKEND_IP:
 		return &value.IP{Value: net.IPv4(127, 0, 0, 1)}, nil
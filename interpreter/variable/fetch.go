@@ -187,7 +187,8 @@ func (v *FetchScopeVariables) Get(s context.Scope, name string) (value.Value, er
 	case BERESP_RESPONSE:
 		return v.ctx.BackendResponseResponse, nil
 	case BERESP_STALE_IF_ERROR:
-		return v.ctx.BackendResponseStaleIfError, nil
+		// alias for beresp.grace
+		return v.ctx.BackendResponseGrace, nil
 	case BERESP_STALE_WHILE_REVALIDATE:
 		return v.ctx.BackendResponseStaleWhileRevalidate, nil
 	case BERESP_STATUS:
@@ -340,7 +341,8 @@ func (v *FetchScopeVariables) Set(s context.Scope, name, operator string, val va
 		}
 		return nil
 	case BERESP_STALE_IF_ERROR:
-		if err := doAssign(v.ctx.BackendResponseStaleIfError, operator, val); err != nil {
+		// alias for beresp.grace
+		if err := doAssign(v.ctx.BackendResponseGrace, operator, val); err != nil {
 			return errors.WithStack(err)
 		}
 		return nil
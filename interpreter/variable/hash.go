@@ -37,7 +37,7 @@ func (v *HashScopeVariables) Get(s context.Scope, name string) (value.Value, err
 		return &value.Boolean{Value: parsed.Is6()}, nil
 
 	case REQ_IS_PURGE:
-		return &value.Boolean{Value: v.ctx.Request.Method == PURGE}, nil
+		return v.ctx.IsPurge, nil
 	case FASTLY_INFO_REQUEST_ID:
 		return v.ctx.RequestID, nil
 	}
@@ -63,8 +63,10 @@ func (v *HitScopeVariables) Get(s context.Scope, name string) (value.Value, erro
 		// alias for obj.grace
 		return v.ctx.ObjectGrace, nil
 	case OBJ_STALE_WHILE_REVALIDATE:
-		// Return fixed value because we don't support SWR yet
-		return &value.RTime{Value: 60 * time.Second}, nil
+		if v.ctx.CacheHitItem != nil {
+			return &value.RTime{Value: v.ctx.CacheHitItem.StaleWhileRevalidate}, nil
+		}
+		return &value.RTime{Value: 0}, nil
 	case OBJ_STATUS:
 		return &value.Integer{Value: int64(v.ctx.Object.StatusCode)}, nil
 	case OBJ_TTL:
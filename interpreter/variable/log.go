@@ -108,8 +108,10 @@ func (v *LogScopeVariables) Get(s context.Scope, name string) (value.Value, erro
 		// alias for obj.grace
 		return v.ctx.ObjectGrace, nil
 	case OBJ_STALE_WHILE_REVALIDATE:
-		// Return fixed value because we don't support SWR yet
-		return &value.RTime{Value: 60 * time.Second}, nil
+		if v.ctx.CacheHitItem != nil {
+			return &value.RTime{Value: v.ctx.CacheHitItem.StaleWhileRevalidate}, nil
+		}
+		return &value.RTime{Value: 0}, nil
 	case OBJ_TTL:
 		return v.ctx.ObjectTTL, nil
 
@@ -122,7 +124,7 @@ func (v *LogScopeVariables) Get(s context.Scope, name string) (value.Value, erro
 		}
 		return &value.Boolean{Value: parsed.Is6()}, nil
 	case REQ_IS_PURGE:
-		return &value.Boolean{Value: v.ctx.Request.Method == "PURGE"}, nil
+		return v.ctx.IsPurge, nil
 
 	case REQ_BACKEND_IP:
 		return &value.IP{Value: net.IPv4(127, 0, 0, 1)}, nil
@@ -65,7 +65,7 @@ func (v *RecvScopeVariables) Get(s context.Scope, name string) (value.Value, err
 		}
 		return &value.Boolean{Value: parsed.Is6()}, nil
 	case REQ_IS_PURGE:
-		return &value.Boolean{Value: v.ctx.Request.Method == "PURGE"}, nil
+		return v.ctx.IsPurge, nil
 	case SEGMENTED_CACHING_BLOCK_SIZE:
 		return v.ctx.SegmentedCacheingBlockSize, nil
 	case FASTLY_INFO_REQUEST_ID:
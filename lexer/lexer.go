@@ -37,6 +37,22 @@ func NewFromString(input string, opts ...OptionFunc) *Lexer {
 	return New(strings.NewReader(input), opts...)
 }
 
+// NewFromLines creates lexer which has already read all lines of the input.
+// This is used to display source lines of the cached AST without lexing again.
+func NewFromLines(input string, opts ...OptionFunc) *Lexer {
+	o := collect(opts)
+	// Lexer always has an empty line at the end after calling NewLine() on EOF
+	lines := append(strings.Split(strings.TrimSuffix(input, "\n"), "\n"), "")
+	return &Lexer{
+		r:      bufio.NewReader(strings.NewReader("")),
+		line:   len(lines) + 1,
+		buffer: new(bytes.Buffer),
+		stack:  lines,
+		file:   o.Filename,
+		isEOF:  true,
+	}
+}
+
 func (l *Lexer) readChar() {
 	r, _, err := l.r.ReadRune()
 	if err != nil {
@@ -678,3 +678,29 @@ func TestPeekToken(t *testing.T) {
 		t.Errorf(`Assertion failed, diff= %s`, diff)
 	}
 }
+
+func TestNewFromLines(t *testing.T) {
+	inputs := []string{
+		"sub vcl_recv {\n  #FASTLY RECV\n}\n",
+		"sub vcl_recv {\n\n  return(pass);\n}",
+	}
+
+	for _, input := range inputs {
+		l := NewFromString(input)
+		for tok := l.NextToken(); tok.Type != token.EOF; tok = l.NextToken() {
+		}
+		l.NewLine()
+
+		n := NewFromLines(input)
+		if l.LineCount() != n.LineCount() {
+			t.Errorf("Line count mismatch, expect=%d, actual=%d", l.LineCount(), n.LineCount())
+		}
+		for i := 1; i <= l.LineCount(); i++ {
+			expect, _ := l.GetLine(i)
+			actual, _ := n.GetLine(i)
+			if expect != actual {
+				t.Errorf("Line %d mismatch, expect=%q, actual=%q", i, expect, actual)
+			}
+		}
+	}
+}
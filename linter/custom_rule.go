@@ -0,0 +1,250 @@
+package linter
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/ysugimoto/falco/ast"
+	"github.com/ysugimoto/falco/config"
+	"github.com/ysugimoto/falco/context"
+)
+
+var fastlySubroutineScopes = []int{
+	context.RECV, context.HASH, context.HIT, context.MISS, context.PASS,
+	context.FETCH, context.ERROR, context.DELIVER, context.LOG,
+}
+
+// customRule is compiled rule of linter.custom_rules configuration
+type customRule struct {
+	*config.CustomRule
+	severity      Severity
+	scopes        int
+	excludeScopes int
+	matched       int // scopes which the required node is found
+}
+
+// ValidateCustomRules checks linter.custom_rules configuration before linting
+// so that invalid rule is reported as configuration error, not as lint error which does not have the position
+func ValidateCustomRules(rules []*config.CustomRule) error {
+	_, err := compileCustomRules(rules)
+	return err
+}
+
+func compileCustomRules(rules []*config.CustomRule) ([]*customRule, error) {
+	var compiled []*customRule
+	for i, r := range rules {
+		if r.Name == "" {
+			return nil, fmt.Errorf("Custom rule at index %d must have name", i)
+		}
+		if r.Node != "" && !isCustomRuleNode(r.Node) {
+			return nil, fmt.Errorf(`Custom rule "%s" has unknown node kind "%s"`, r.Name, r.Node)
+		}
+		if r.Function != "" && r.Node != "" && r.Node != "function" {
+			return nil, fmt.Errorf(`Custom rule "%s" could specify function only for "function" node`, r.Name)
+		}
+
+		c := &customRule{CustomRule: r}
+		switch strings.ToUpper(r.Severity) {
+		case "", "ERROR":
+			c.severity = ERROR
+		case "WARNING":
+			c.severity = WARNING
+		case "INFO":
+			c.severity = INFO
+		default:
+			return nil, fmt.Errorf(`Custom rule "%s" has invalid severity "%s"`, r.Name, r.Severity)
+		}
+		if len(r.Scopes) > 0 {
+			if c.scopes = enforceSubroutineCallScopeFromConfig(r.Scopes); c.scopes == -1 {
+				return nil, fmt.Errorf(`Custom rule "%s" has unknown scopes %v`, r.Name, r.Scopes)
+			}
+		}
+		if len(r.ExcludeScopes) > 0 {
+			if c.excludeScopes = enforceSubroutineCallScopeFromConfig(r.ExcludeScopes); c.excludeScopes == -1 {
+				return nil, fmt.Errorf(`Custom rule "%s" has unknown exclude_scopes %v`, r.Name, r.ExcludeScopes)
+			}
+		}
+		if r.Require && c.scopes == 0 {
+			return nil, fmt.Errorf(`Custom rule "%s" requires scopes to find the node`, r.Name)
+		}
+		compiled = append(compiled, c)
+	}
+	return compiled, nil
+}
+
+func isCustomRuleNode(kind string) bool {
+	switch kind {
+	case "set", "unset", "add", "remove", "declare", "call", "return", "error", "restart", "esi", "log",
+		"synthetic", "synthetic.base64", "function", "ident",
+		"acl", "backend", "director", "table", "subroutine", "penaltybox", "ratecounter":
+		return true
+	}
+	return false
+}
+
+// Get node kind and its identifier which are matched with custom rules
+func customRuleNode(node ast.Node) (string, string) {
+	switch t := node.(type) {
+	case *ast.SetStatement:
+		return "set", t.Ident.Value
+	case *ast.UnsetStatement:
+		return "unset", t.Ident.Value
+	case *ast.AddStatement:
+		return "add", t.Ident.Value
+	case *ast.RemoveStatement:
+		return "remove", t.Ident.Value
+	case *ast.DeclareStatement:
+		return "declare", t.Name.Value
+	case *ast.CallStatement:
+		return "call", t.Subroutine.Value
+	case *ast.ReturnStatement:
+		if t.ReturnExpression != nil {
+			return "return", strings.Trim((*t.ReturnExpression).String(), "()")
+		}
+		return "return", ""
+	case *ast.ErrorStatement:
+		return "error", ""
+	case *ast.RestartStatement:
+		return "restart", ""
+	case *ast.EsiStatement:
+		return "esi", ""
+	case *ast.LogStatement:
+		return "log", ""
+	case *ast.SyntheticStatement:
+		return "synthetic", ""
+	case *ast.SyntheticBase64Statement:
+		return "synthetic.base64", ""
+	case *ast.FunctionCallStatement:
+		return "function", t.Function.Value
+	case *ast.FunctionCallExpression:
+		return "function", t.Function.Value
+	case *ast.Ident:
+		return "ident", t.Value
+	case *ast.AclDeclaration:
+		return "acl", t.Name.Value
+	case *ast.BackendDeclaration:
+		return "backend", t.Name.Value
+	case *ast.DirectorDeclaration:
+		return "director", t.Name.Value
+	case *ast.TableDeclaration:
+		return "table", t.Name.Value
+	case *ast.SubroutineDeclaration:
+		return "subroutine", t.Name.Value
+	case *ast.PenaltyboxDeclaration:
+		return "penaltybox", t.Name.Value
+	case *ast.RatecounterDeclaration:
+		return "ratecounter", t.Name.Value
+	}
+	return "", ""
+}
+
+// Match glob pattern case-insensitively because HTTP header name is case-insensitive
+func matchCustomRulePattern(pattern, value string) bool {
+	matched, err := path.Match(strings.ToLower(pattern), strings.ToLower(value))
+	return err == nil && matched
+}
+
+func (r *customRule) match(kind, ident string, scope int) bool {
+	node := r.Node
+	if node == "" && r.Function != "" {
+		node = "function"
+	}
+	if node != "" && node != kind {
+		return false
+	}
+	if r.Ident != "" && !matchCustomRulePattern(r.Ident, ident) {
+		return false
+	}
+	if r.Function != "" && !matchCustomRulePattern(r.Function, ident) {
+		return false
+	}
+	// Scoped rules only match the nodes inside subroutine
+	if r.scopes > 0 && scope&r.scopes == 0 {
+		return false
+	}
+	if r.excludeScopes > 0 && scope&r.excludeScopes > 0 {
+		return false
+	}
+	return true
+}
+
+func (r *customRule) message(kind, ident string) string {
+	if r.Message != "" {
+		return r.Message
+	}
+	if ident != "" {
+		return fmt.Sprintf(`Custom rule "%s" forbids %s "%s"`, r.Name, kind, ident)
+	}
+	return fmt.Sprintf(`Custom rule "%s" forbids %s`, r.Name, kind)
+}
+
+func (r *customRule) requiredMessage(subroutine string) string {
+	if r.Message != "" {
+		return r.Message
+	}
+	kind := r.Node
+	if kind == "" {
+		kind = "function"
+	}
+	target := r.Ident
+	if r.Function != "" {
+		target = r.Function
+	}
+	if target != "" {
+		return fmt.Sprintf(`Custom rule "%s" requires %s "%s" in %s`, r.Name, kind, target, subroutine)
+	}
+	return fmt.Sprintf(`Custom rule "%s" requires %s in %s`, r.Name, kind, subroutine)
+}
+
+func (l *Linter) lintCustomRules(node ast.Node, ctx *context.Context) {
+	kind, ident := customRuleNode(node)
+	if kind == "" {
+		return
+	}
+
+	var scope int
+	if ctx.CurrentSubroutine != nil {
+		scope = ctx.Mode()
+	}
+	for _, r := range l.customRules {
+		if !r.match(kind, ident, scope) {
+			continue
+		}
+		// Required node is reported after linting if not found
+		if r.Require {
+			r.matched |= scope
+			continue
+		}
+		l.Error(&LintError{
+			Severity: r.severity,
+			Token:    node.GetMeta().Token,
+			Message:  r.message(kind, ident),
+			Rule:     Rule(r.Name),
+		})
+	}
+}
+
+// Report required nodes which are not found in Fastly subroutine like vcl_deliver of the scopes
+func (l *Linter) lintRequiredCustomRules(ctx *context.Context) {
+	for _, r := range l.customRules {
+		if !r.Require {
+			continue
+		}
+		for _, scope := range fastlySubroutineScopes {
+			if r.scopes&scope == 0 || r.matched&scope > 0 {
+				continue
+			}
+			sub, ok := ctx.Subroutines["vcl_"+strings.ToLower(context.ScopeString(scope))]
+			if !ok {
+				continue
+			}
+			l.Error(&LintError{
+				Severity: r.severity,
+				Token:    sub.Decl.GetMeta().Token,
+				Message:  r.requiredMessage(sub.Decl.Name.Value),
+				Rule:     Rule(r.Name),
+			})
+		}
+	}
+}
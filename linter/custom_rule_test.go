@@ -0,0 +1,146 @@
+package linter
+
+import (
+	"testing"
+
+	"github.com/ysugimoto/falco/config"
+	"github.com/ysugimoto/falco/context"
+	"github.com/ysugimoto/falco/lexer"
+	"github.com/ysugimoto/falco/parser"
+)
+
+func TestCustomRules(t *testing.T) {
+	rules := []*config.CustomRule{
+		{
+			Name:     "no-atoi-in-deliver",
+			Function: "std.atoi",
+			Scopes:   []string{"deliver"},
+			Severity: "warning",
+		},
+		{
+			Name:    "require-hsts",
+			Node:    "set",
+			Ident:   "resp.http.Strict-Transport-Security",
+			Scopes:  []string{"deliver"},
+			Require: true,
+			Message: "Strict-Transport-Security header must be set in vcl_deliver",
+		},
+		{
+			Name:          "backend-only-in-recv",
+			Node:          "set",
+			Ident:         "req.backend",
+			ExcludeScopes: []string{"recv"},
+		},
+	}
+
+	backend := `
+backend F_origin {
+  .host = "example.com";
+}
+`
+
+	tests := []struct {
+		name     string
+		input    string
+		expects  []Rule
+		severity Severity
+	}{
+		{
+			name: "no violation",
+			input: `
+sub vcl_recv {
+  #FASTLY RECV
+  set req.backend = F_origin;
+}
+sub vcl_deliver {
+  #FASTLY DELIVER
+  set resp.http.strict-transport-security = "max-age=31536000";
+  set resp.http.X-Length = std.itoa(std.strlen(resp.http.Content-Type));
+}`,
+		},
+		{
+			name: "forbidden function in deliver",
+			input: `
+sub vcl_deliver {
+  #FASTLY DELIVER
+  set resp.http.Strict-Transport-Security = "max-age=31536000";
+  if (std.atoi(resp.http.X-Count) > 0) {
+    esi;
+  }
+}`,
+			expects:  []Rule{"no-atoi-in-deliver"},
+			severity: WARNING,
+		},
+		{
+			name: "required header is not set",
+			input: `
+sub vcl_deliver {
+  #FASTLY DELIVER
+  set resp.http.X-Frame-Options = "DENY";
+}`,
+			expects:  []Rule{"require-hsts"},
+			severity: ERROR,
+		},
+		{
+			name: "set req.backend outside vcl_recv",
+			input: `
+sub vcl_miss {
+  #FASTLY MISS
+  set req.backend = F_origin;
+}`,
+			expects:  []Rule{"backend-only-in-recv"},
+			severity: ERROR,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			vcl, err := parser.New(lexer.NewFromString(backend + tt.input)).ParseVCL()
+			if err != nil {
+				t.Fatalf("unexpected parser error: %s", err)
+			}
+			l := New(&config.LinterConfig{CustomRules: rules})
+			l.Lint(vcl, context.New())
+
+			var actual []*LintError
+			for _, e := range l.Errors {
+				le, ok := e.(*LintError)
+				if !ok {
+					continue
+				}
+				for i := range rules {
+					if le.Rule == Rule(rules[i].Name) {
+						actual = append(actual, le)
+					}
+				}
+			}
+			if len(actual) != len(tt.expects) {
+				t.Fatalf("Expected %d custom rule errors, got %d: %v", len(tt.expects), len(actual), actual)
+			}
+			for i := range actual {
+				if actual[i].Rule != tt.expects[i] {
+					t.Errorf("Expected rule %s, got %s", tt.expects[i], actual[i].Rule)
+				}
+				if actual[i].Severity != tt.severity {
+					t.Errorf("Expected severity %s, got %s", tt.severity, actual[i].Severity)
+				}
+			}
+		})
+	}
+}
+
+func TestInvalidCustomRules(t *testing.T) {
+	tests := []*config.CustomRule{
+		{Node: "set"},
+		{Name: "unknown-node", Node: "foo"},
+		{Name: "invalid-severity", Node: "set", Severity: "fatal"},
+		{Name: "unknown-scope", Node: "set", Scopes: []string{"unknown"}},
+		{Name: "require-without-scope", Node: "set", Require: true},
+	}
+
+	for _, tt := range tests {
+		if _, err := compileCustomRules([]*config.CustomRule{tt}); err == nil {
+			t.Errorf("Expected error for invalid custom rule %+v", tt)
+		}
+	}
+}
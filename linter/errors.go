@@ -25,6 +25,7 @@ type LintError struct {
 	Message   string
 	Reference string
 	Rule      Rule
+	Fix       *Fix
 }
 
 func (l *LintError) Match(r Rule) *LintError {
@@ -38,6 +39,12 @@ func (e *LintError) Ref(url string) *LintError {
 	return e
 }
 
+// Fixable attaches automatic fix which is applied by "falco lint --fix"
+func (e *LintError) Fixable(message string, edits ...*Edit) *LintError {
+	e.Fix = &Fix{Message: message, Edits: edits}
+	return e
+}
+
 func (e *LintError) Error() string {
 	var rule, ref, file string
 
@@ -281,7 +288,7 @@ func InvalidReturnState(m *ast.Meta, scope, state string, expects ...string) *Li
 }
 
 func UnusedDeclaration(m *ast.Meta, name, declType string) *LintError {
-	return &LintError{
+	err := &LintError{
 		Severity: WARNING,
 		Token:    m.Token,
 		Message: fmt.Sprintf(
@@ -289,6 +296,7 @@ func UnusedDeclaration(m *ast.Meta, name, declType string) *LintError {
 			declType, name,
 		),
 	}
+	return err.Fixable(fmt.Sprintf(`Remove unused %s "%s"`, declType, name), RemoveStatementEdit(m.Token))
 }
 
 func UnusedExternalDeclaration(name, declType string) *LintError {
@@ -302,11 +310,12 @@ func UnusedExternalDeclaration(name, declType string) *LintError {
 }
 
 func UnusedVariable(m *ast.Meta, name string) *LintError {
-	return &LintError{
+	err := &LintError{
 		Severity: WARNING,
 		Token:    m.Token,
 		Message:  fmt.Sprintf(`Variable "%s" is unused`, name),
 	}
+	return err.Fixable(fmt.Sprintf(`Remove unused variable "%s"`, name), RemoveStatementEdit(m.Token))
 }
 
 func NonEmptyPenaltyboxBlock(m *ast.Meta, name string) *LintError {
@@ -0,0 +1,247 @@
+package linter
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/ysugimoto/falco/lexer"
+	"github.com/ysugimoto/falco/token"
+)
+
+// Fix is an automatic fix for the lint error.
+// All edits of a fix are applied together, or nothing is applied when some edits conflict with other fixes.
+type Fix struct {
+	Message string
+	Edits   []*Edit
+}
+
+type EditType int
+
+const (
+	EditReplace         EditType = iota // replace text from the token with new text
+	EditInsertLine                      // insert a line before the token with the same indentation
+	EditRemoveStatement                 // remove whole statement or declaration which starts from the token
+)
+
+// Edit is a text edit for the source which is pointed by token line and position.
+// AST nodes do not have end position so the range of statement is found by lexing source on applying.
+type Edit struct {
+	Type   EditType
+	Token  token.Token
+	Length int // length of rune to be replaced from the token, zero means insertion
+	Text   string
+}
+
+func ReplaceEdit(t token.Token, length int, text string) *Edit {
+	return &Edit{Type: EditReplace, Token: t, Length: length, Text: text}
+}
+
+func InsertEdit(t token.Token, text string) *Edit {
+	return &Edit{Type: EditReplace, Token: t, Text: text}
+}
+
+func InsertLineEdit(t token.Token, text string) *Edit {
+	return &Edit{Type: EditInsertLine, Token: t, Text: text}
+}
+
+func RemoveStatementEdit(t token.Token) *Edit {
+	return &Edit{Type: EditRemoveStatement, Token: t}
+}
+
+// Token length in the source, string token literal does not contain quotes
+func tokenLength(t token.Token) int {
+	return utf8.RuneCountInString(t.Literal) + t.Offset
+}
+
+// resolved edit which has byte offsets of the source
+type textEdit struct {
+	start int
+	end   int
+	text  string
+}
+
+func (e textEdit) conflicts(o textEdit) bool {
+	if e.start == o.start && e.end == o.end {
+		return true
+	}
+	return e.start < o.end && o.start < e.end
+}
+
+// ApplyFixes applies fixes to the source and returns fixed source and applied fixes.
+// Fixes which conflict with the former fixes are skipped, run lint again to fix them.
+func ApplyFixes(src string, fixes []*Fix) (string, []*Fix, error) {
+	lines := lineOffsets(src)
+
+	var applied []*Fix
+	var edits []textEdit
+	for _, fix := range fixes {
+		var resolved []textEdit
+		for _, edit := range fix.Edits {
+			te, err := resolveEdit(src, lines, edit)
+			if err != nil {
+				return "", nil, err
+			}
+			resolved = append(resolved, te)
+		}
+
+		var conflict bool
+		for _, r := range resolved {
+			for _, e := range edits {
+				if r.conflicts(e) {
+					conflict = true
+				}
+			}
+		}
+		if conflict {
+			continue
+		}
+		edits = append(edits, resolved...)
+		applied = append(applied, fix)
+	}
+
+	sort.SliceStable(edits, func(i, j int) bool {
+		if edits[i].start != edits[j].start {
+			return edits[i].start < edits[j].start
+		}
+		return edits[i].end < edits[j].end
+	})
+
+	var buf strings.Builder
+	var cursor int
+	for _, e := range edits {
+		buf.WriteString(src[cursor:e.start])
+		buf.WriteString(e.text)
+		cursor = e.end
+	}
+	buf.WriteString(src[cursor:])
+
+	return buf.String(), applied, nil
+}
+
+// Byte offsets of beginning of each line
+func lineOffsets(src string) []int {
+	offsets := []int{0}
+	for i := 0; i < len(src); i++ {
+		if src[i] == '\n' {
+			offsets = append(offsets, i+1)
+		}
+	}
+	return offsets
+}
+
+// Convert 1-based line and rune position to byte offset
+func byteOffset(src string, lines []int, line, position int) (int, error) {
+	if line < 1 || line > len(lines) || position < 1 {
+		return 0, fmt.Errorf("Invalid position at line %d, position %d", line, position)
+	}
+	return advance(src, lines[line-1], position-1)
+}
+
+// Advance byte offset by rune count
+func advance(src string, offset, n int) (int, error) {
+	for ; n > 0; n-- {
+		if offset >= len(src) {
+			return 0, fmt.Errorf("Position exceeds the source length")
+		}
+		_, size := utf8.DecodeRuneInString(src[offset:])
+		offset += size
+	}
+	return offset, nil
+}
+
+func resolveEdit(src string, lines []int, edit *Edit) (textEdit, error) {
+	start, err := byteOffset(src, lines, edit.Token.Line, edit.Token.Position)
+	if err != nil {
+		return textEdit{}, err
+	}
+	lineStart := lines[edit.Token.Line-1]
+
+	switch edit.Type {
+	case EditInsertLine:
+		// Insert at the beginning of line so that the edit does not conflict with removing the statement
+		if indent := src[lineStart:start]; strings.TrimSpace(indent) == "" {
+			return textEdit{start: lineStart, end: lineStart, text: indent + edit.Text + "\n"}, nil
+		}
+		return textEdit{start: start, end: start, text: edit.Text + "\n"}, nil
+	case EditRemoveStatement:
+		end, err := statementEnd(src, start)
+		if err != nil {
+			return textEdit{}, err
+		}
+		// Remove whole lines if nothing remains in the lines
+		lineEnd := strings.IndexByte(src[end:], '\n')
+		if lineEnd == -1 {
+			lineEnd = len(src) - end
+		}
+		if strings.TrimSpace(src[lineStart:start]) == "" && strings.TrimSpace(src[end:end+lineEnd]) == "" {
+			start = lineStart
+			end = min(end+lineEnd+1, len(src))
+			// Also remove the following blank line if the previous line is blank too
+			// in order not to leave consecutive blank lines
+			prevBlank := isBlankLine(src, lines, edit.Token.Line-1)
+			if (start == 0 || prevBlank) && strings.HasPrefix(src[end:], "\n") {
+				end++
+			} else if prevBlank && end == len(src) {
+				// Remove the previous blank line as well at the end of file
+				start = lines[edit.Token.Line-2]
+			}
+		}
+		return textEdit{start: start, end: end}, nil
+	default:
+		end, err := advance(src, start, edit.Length)
+		if err != nil {
+			return textEdit{}, err
+		}
+		return textEdit{start: start, end: end, text: edit.Text}, nil
+	}
+}
+
+// Check 1-based line is blank
+func isBlankLine(src string, lines []int, line int) bool {
+	if line < 1 || line > len(lines) {
+		return false
+	}
+	end := len(src)
+	if line < len(lines) {
+		end = lines[line]
+	}
+	return strings.TrimSpace(src[lines[line-1]:end]) == ""
+}
+
+// Find the end of statement which starts from offset.
+// Statement ends with semicolon or right brace which closes the first left brace.
+func statementEnd(src string, offset int) (int, error) {
+	sub := src[offset:]
+	l := lexer.NewFromString(sub)
+	lines := lineOffsets(sub)
+
+	var depth int
+	for {
+		tok := l.NextToken()
+		switch tok.Type {
+		case token.EOF:
+			return 0, fmt.Errorf("Could not find the end of statement")
+		case token.LEFT_BRACE:
+			depth++
+			continue
+		case token.RIGHT_BRACE:
+			depth--
+			if depth > 0 {
+				continue
+			}
+		case token.SEMICOLON:
+			if depth > 0 {
+				continue
+			}
+		default:
+			continue
+		}
+		end, err := byteOffset(sub, lines, tok.Line, tok.Position)
+		if err != nil {
+			return 0, err
+		}
+		return offset + end + 1, nil
+	}
+}
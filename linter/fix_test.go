@@ -0,0 +1,211 @@
+package linter
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/ysugimoto/falco/config"
+	"github.com/ysugimoto/falco/context"
+	"github.com/ysugimoto/falco/lexer"
+	"github.com/ysugimoto/falco/parser"
+	"github.com/ysugimoto/falco/token"
+)
+
+func applyLintFixes(t *testing.T, input string) string {
+	vcl, err := parser.New(lexer.NewFromString(input)).ParseVCL()
+	if err != nil {
+		t.Fatalf("unexpected parser error: %s", err)
+	}
+
+	l := New(&config.LinterConfig{})
+	l.Lint(vcl, context.New())
+
+	var fixes []*Fix
+	for _, err := range l.Errors {
+		if le, ok := err.(*LintError); ok && le.Fix != nil {
+			fixes = append(fixes, le.Fix)
+		}
+	}
+	fixed, _, err := ApplyFixes(input, fixes)
+	if err != nil {
+		t.Fatalf("unexpected fix error: %s", err)
+	}
+	return fixed
+}
+
+func TestLintFix(t *testing.T) {
+	tests := []struct {
+		name   string
+		input  string
+		expect string
+	}{
+		{
+			name: "remove unused declarations",
+			input: `acl internal {
+  "127.0.0.1";
+}
+
+table redirects STRING {
+  "/foo": "/bar",
+}
+
+sub vcl_recv {
+  #FASTLY RECV
+  declare local var.unused STRING;
+  return(lookup);
+}
+`,
+			expect: `sub vcl_recv {
+  #FASTLY RECV
+  return(lookup);
+}
+`,
+		},
+		{
+			name: "insert boilerplate macro and return default state",
+			input: `sub vcl_recv {
+  # comment
+  set req.http.Foo = "bar";
+  return;
+}
+
+sub vcl_deliver {}
+`,
+			expect: `sub vcl_recv {
+  #FASTLY RECV
+  # comment
+  set req.http.Foo = "bar";
+  return(lookup);
+}
+
+sub vcl_deliver {
+  #FASTLY DELIVER
+}
+`,
+		},
+		{
+			name: "change regex to non-capturing groups",
+			input: `sub vcl_recv {
+  #FASTLY RECV
+  if (req.url ~ "^/(foo)") {
+    set req.http.Foo = "1";
+  }
+  if (req.url ~ "^/(bar)/(?:baz)/(?P<id>[0-9]+)\(") {
+    set req.http.Bar = "1";
+  }
+}
+`,
+			expect: `sub vcl_recv {
+  #FASTLY RECV
+  if (req.url ~ "^/(foo)") {
+    set req.http.Foo = "1";
+  }
+  if (req.url ~ "^/(?:bar)/(?:baz)/(?:[0-9]+)\(") {
+    set req.http.Bar = "1";
+  }
+}
+`,
+		},
+		{
+			name: "regex is not changed if captured values are used",
+			input: `sub vcl_recv {
+  #FASTLY RECV
+  if (req.url ~ "^/(foo)") {
+    set req.http.Foo = "1";
+  }
+  if (req.url ~ "^/(bar)") {
+    set req.http.Bar = re.group.1;
+  }
+}
+`,
+			expect: `sub vcl_recv {
+  #FASTLY RECV
+  if (req.url ~ "^/(foo)") {
+    set req.http.Foo = "1";
+  }
+  if (req.url ~ "^/(bar)") {
+    set req.http.Bar = re.group.1;
+  }
+}
+`,
+		},
+		{
+			name: "re.group.N in comment or string is not treated as captured value use",
+			input: `sub vcl_recv {
+  #FASTLY RECV
+  if (req.url ~ "^/(foo)") {
+    set req.http.Foo = "1";
+  }
+  # re.group.1 is not used
+  if (req.url ~ "^/(bar)") {
+    set req.http.Bar = "re.group.1";
+  }
+}
+`,
+			expect: `sub vcl_recv {
+  #FASTLY RECV
+  if (req.url ~ "^/(foo)") {
+    set req.http.Foo = "1";
+  }
+  # re.group.1 is not used
+  if (req.url ~ "^/(?:bar)") {
+    set req.http.Bar = "re.group.1";
+  }
+}
+`,
+		},
+		{
+			name: "explicit type conversion on string concatenation",
+			input: `sub vcl_recv {
+  #FASTLY RECV
+  set req.http.Foo = "restarts:" + req.restarts + ", " + 1;
+}
+`,
+			expect: `sub vcl_recv {
+  #FASTLY RECV
+  set req.http.Foo = "restarts:" + std.itoa(req.restarts) + ", " + std.itoa(1);
+}
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if diff := cmp.Diff(tt.expect, applyLintFixes(t, tt.input)); diff != "" {
+				t.Errorf("Fixed VCL mismatch, diff=%s", diff)
+			}
+		})
+	}
+}
+
+func TestApplyFixes(t *testing.T) {
+	src := "sub vcl_recv {\n  return;\n}\n"
+	ret := token.Token{Type: token.RETURN, Literal: "return", Line: 2, Position: 3}
+
+	t.Run("Conflicted fix is skipped", func(t *testing.T) {
+		fixes := []*Fix{
+			{Message: "first", Edits: []*Edit{ReplaceEdit(ret, 6, "return(lookup)")}},
+			{Message: "second", Edits: []*Edit{RemoveStatementEdit(ret)}},
+		}
+		fixed, applied, err := ApplyFixes(src, fixes)
+		if err != nil {
+			t.Errorf("Unexpected error: %s", err)
+			return
+		}
+		if diff := cmp.Diff("sub vcl_recv {\n  return(lookup);\n}\n", fixed); diff != "" {
+			t.Errorf("Fixed source mismatch, diff=%s", diff)
+		}
+		if len(applied) != 1 || applied[0].Message != "first" {
+			t.Errorf("Expected only first fix is applied, got %v", applied)
+		}
+	})
+
+	t.Run("Invalid position returns error", func(t *testing.T) {
+		invalid := ret
+		invalid.Line = 10
+		_, _, err := ApplyFixes(src, []*Fix{{Edits: []*Edit{RemoveStatementEdit(invalid)}}})
+		if err == nil {
+			t.Errorf("Expected error but got nil")
+		}
+	})
+}
@@ -2,7 +2,7 @@ package linter
 
 import (
 	"fmt"
-	"regexp"
+	"reflect"
 	"strconv"
 	"strings"
 
@@ -11,8 +11,6 @@ import (
 	"github.com/ysugimoto/falco/types"
 )
 
-var captureRegex = regexp.MustCompile(`\([^\)]+\)`)
-
 var BackendPropertyTypes = map[string]types.Type{
 	"dynamic":                  types.BoolType,
 	"share_key":                types.StringType,
@@ -206,9 +204,12 @@ func pushRegexGroupVars(exp ast.Expression, ctx *context.Context) error {
 		return pushRegexGroupVars(t.Right, ctx)
 	case *ast.InfixExpression:
 		if t.Operator == "~" || t.Operator == "!~" {
-			m := captureRegex.FindAllStringSubmatch(t.Right.String(), -1)
-			if m != nil {
-				return ctx.PushRegexVariables(len(m) + 1)
+			pattern := t.Right.String()
+			if v, ok := t.Right.(*ast.String); ok {
+				pattern = v.Value
+			}
+			if n := len(captureGroups(pattern)); n > 0 {
+				return ctx.PushRegexVariables(n + 1)
 			}
 		} else {
 			return pushRegexGroupVars(t.Right, ctx)
@@ -217,6 +218,115 @@ func pushRegexGroupVars(exp ast.Expression, ctx *context.Context) error {
 	return nil
 }
 
+// Find the capturing regex string in the expression like pushRegexGroupVars does
+func findCaptureRegex(exp ast.Expression) *ast.String {
+	switch t := exp.(type) {
+	case *ast.PrefixExpression:
+		return findCaptureRegex(t.Right)
+	case *ast.InfixExpression:
+		if t.Operator != "~" && t.Operator != "!~" {
+			return findCaptureRegex(t.Right)
+		}
+		if v, ok := t.Right.(*ast.String); ok && len(captureGroups(v.Value)) > 0 {
+			return v
+		}
+	}
+	return nil
+}
+
+// captureGroups returns byte ranges of capturing group openers in the regex pattern like "(" or "(?P<name>".
+// Escaped parenthesis, parenthesis in the character class and non-capturing group like "(?:" are not included.
+func captureGroups(pattern string) [][2]int {
+	var groups [][2]int
+	var inClass bool
+
+	for i := 0; i < len(pattern); i++ {
+		switch pattern[i] {
+		case '\\':
+			i++
+		case '[':
+			inClass = true
+		case ']':
+			inClass = false
+		case '(':
+			if inClass {
+				continue
+			}
+			rest := pattern[i+1:]
+			switch {
+			case !strings.HasPrefix(rest, "?"):
+				groups = append(groups, [2]int{i, i + 1})
+			case strings.HasPrefix(rest, "?P<"), strings.HasPrefix(rest, "?<") &&
+				!strings.HasPrefix(rest, "?<=") && !strings.HasPrefix(rest, "?<!"):
+				// named group
+				if end := strings.IndexByte(rest, '>'); end != -1 {
+					groups = append(groups, [2]int{i, i + end + 2})
+				}
+			}
+		}
+	}
+	return groups
+}
+
+// Check the node refers regex captured values like re.group.1.
+// Comments and string literals are not identifiers, so they are not matched.
+func refersRegexGroup(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			return false
+		}
+		if ident, ok := v.Interface().(*ast.Ident); ok {
+			return strings.HasPrefix(ident.Value, "re.group.")
+		}
+		return refersRegexGroup(v.Elem())
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			// Meta only has token and comments
+			if f := v.Type().Field(i); f.IsExported() && f.Name != "Meta" && refersRegexGroup(v.Field(i)) {
+				return true
+			}
+		}
+	case reflect.Slice:
+		for i := 0; i < v.Len(); i++ {
+			if refersRegexGroup(v.Index(i)) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Convert all capturing groups to non-capturing group
+func nonCapturingRegex(pattern string) string {
+	var buf strings.Builder
+	var cursor int
+	for _, g := range captureGroups(pattern) {
+		buf.WriteString(pattern[cursor:g[0]])
+		buf.WriteString("(?:")
+		cursor = g[1]
+	}
+	buf.WriteString(pattern[cursor:])
+	return buf.String()
+}
+
+// Fastly default next state which is returned in the state-machine method
+func defaultReturnState(mode int) string {
+	switch mode {
+	case context.RECV:
+		return "lookup"
+	case context.HASH:
+		return "hash"
+	case context.MISS:
+		return "fetch"
+	case context.PASS:
+		return "pass"
+	case context.HIT, context.FETCH, context.ERROR, context.DELIVER, context.LOG:
+		return "deliver"
+	}
+	return ""
+}
+
 func isBooleanOperator(operator string) bool {
 	switch operator {
 	case "(":
@@ -1,9 +1,11 @@
 package linter
 
 import (
+	"reflect"
 	"strings"
 
 	"github.com/ysugimoto/falco/ast"
+	"github.com/ysugimoto/falco/token"
 )
 
 // ignore signatures
@@ -96,3 +98,66 @@ func (i *ignore) TeardownBlockStatement(meta *ast.Meta) {
 func (i *ignore) IsEnable() bool {
 	return i.ignoreNextLine || i.ignoreThisLine || i.ignoreRange
 }
+
+// IgnoredLines is the set of lines which are ignored by falco-ignore comments, keyed by file name.
+// Errors which are reported outside of the linter like rule plugins are filtered by the lines
+// in order to respect ignore comments as well as builtin rules.
+type IgnoredLines map[string]map[int]struct{}
+
+// Collect ignored lines by walking the AST with the same setup and teardown as linting
+func NewIgnoredLines(vcl *ast.VCL) IgnoredLines {
+	lines := IgnoredLines{}
+	i := &ignore{}
+	for _, stmt := range vcl.Statements {
+		i.markStatement(stmt, lines)
+	}
+	return lines
+}
+
+func (l IgnoredLines) Contains(t token.Token) bool {
+	_, ok := l[t.File][t.Line]
+	return ok
+}
+
+func (i *ignore) markStatement(stmt ast.Statement, lines IgnoredLines) {
+	i.SetupStatement(stmt.GetMeta())
+	defer i.TeardownStatement()
+	i.mark(reflect.ValueOf(stmt), lines)
+}
+
+func (i *ignore) mark(v reflect.Value, lines IgnoredLines) {
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			return
+		}
+		switch t := v.Interface().(type) {
+		case *ast.BlockStatement:
+			i.SetupBlockStatement(t.GetMeta())
+			defer i.TeardownBlockStatement(t.GetMeta())
+			for _, stmt := range t.Statements {
+				i.markStatement(stmt, lines)
+			}
+			return
+		case *ast.Meta:
+			if i.IsEnable() {
+				if _, ok := lines[t.Token.File]; !ok {
+					lines[t.Token.File] = make(map[int]struct{})
+				}
+				lines[t.Token.File][t.Token.Line] = struct{}{}
+			}
+			return
+		}
+		i.mark(v.Elem(), lines)
+	case reflect.Struct:
+		for n := 0; n < v.NumField(); n++ {
+			if v.Type().Field(n).IsExported() {
+				i.mark(v.Field(n), lines)
+			}
+		}
+	case reflect.Slice:
+		for n := 0; n < v.Len(); n++ {
+			i.mark(v.Index(n), lines)
+		}
+	}
+}
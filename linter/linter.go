@@ -3,6 +3,7 @@ package linter
 import (
 	"fmt"
 	"net"
+	"reflect"
 	"strings"
 
 	"github.com/pkg/errors"
@@ -23,14 +24,39 @@ type Linter struct {
 	includexLexers map[string]*lexer.Lexer
 	ignore         *ignore
 	conf           *config.LinterConfig
+	customRules    []*customRule
+	customRuleErr  error
+	parseCache     ParseCache
 }
 
-func New(c *config.LinterConfig) *Linter {
-	return &Linter{
+// ParseCache stores parsed statements of the included modules
+// in order to avoid parsing unchanged modules on every linting
+type ParseCache interface {
+	Load(file, content string, snippet bool) ([]ast.Statement, bool)
+	Save(file, content string, snippet bool, statements []ast.Statement)
+}
+
+type Option func(l *Linter)
+
+func WithParseCache(c ParseCache) Option {
+	return func(l *Linter) {
+		l.parseCache = c
+	}
+}
+
+func New(c *config.LinterConfig, opts ...Option) *Linter {
+	l := &Linter{
 		includexLexers: make(map[string]*lexer.Lexer),
 		ignore:         &ignore{},
 		conf:           c,
 	}
+	if c != nil {
+		l.customRules, l.customRuleErr = compileCustomRules(c.CustomRules)
+	}
+	for i := range opts {
+		opts[i](l)
+	}
+	return l
 }
 
 func (l *Linter) Lexers() map[string]*lexer.Lexer {
@@ -57,6 +83,11 @@ func (l *Linter) Lint(node ast.Node, ctx *context.Context) types.Type {
 	if ctx == nil {
 		ctx = context.New()
 	}
+	// Invalid custom rule configuration is reported as an error for the callers
+	// which do not validate the configuration by ValidateCustomRules in advance
+	if l.customRuleErr != nil {
+		l.Error(l.customRuleErr)
+	}
 
 	l.lint(node, ctx)
 
@@ -68,6 +99,7 @@ func (l *Linter) Lint(node ast.Node, ctx *context.Context) types.Type {
 	l.lintUnusedGotos(ctx)
 	l.lintUnusedPenaltyboxes(ctx)
 	l.lintUnusedRatecounters(ctx)
+	l.lintRequiredCustomRules(ctx)
 
 	return types.NeverType
 }
@@ -171,6 +203,10 @@ func (l *Linter) lintUnusedGotos(ctx *context.Context) {
 }
 
 func (l *Linter) lint(node ast.Node, ctx *context.Context) types.Type {
+	if len(l.customRules) > 0 {
+		l.lintCustomRules(node, ctx)
+	}
+
 	switch t := node.(type) {
 	// Root program
 	case *ast.VCL:
@@ -294,6 +330,10 @@ func (l *Linter) lintStatement(s ast.Statement, ctx *context.Context) {
 }
 
 func (l *Linter) loadSnippetVCL(file, content string) []ast.Statement {
+	if statements, ok := l.loadCachedVCL(file, content, true); ok {
+		return statements
+	}
+
 	lx := lexer.NewFromString(content, lexer.WithFile(file))
 	l.includexLexers[file] = lx
 	statements, err := parser.New(lx).ParseSnippetVCL()
@@ -305,10 +345,17 @@ func (l *Linter) loadSnippetVCL(file, content string) []ast.Statement {
 		}
 		return []ast.Statement{}
 	}
+	if l.parseCache != nil {
+		l.parseCache.Save(file, content, true, statements)
+	}
 	return statements
 }
 
 func (l *Linter) loadVCL(file, content string) []ast.Statement {
+	if statements, ok := l.loadCachedVCL(file, content, false); ok {
+		return statements
+	}
+
 	lx := lexer.NewFromString(content, lexer.WithFile(file))
 	l.includexLexers[file] = lx
 	vcl, err := parser.New(lx).ParseVCL()
@@ -320,9 +367,25 @@ func (l *Linter) loadVCL(file, content string) []ast.Statement {
 		}
 		return []ast.Statement{}
 	}
+	if l.parseCache != nil {
+		l.parseCache.Save(file, content, false, vcl.Statements)
+	}
 	return vcl.Statements
 }
 
+func (l *Linter) loadCachedVCL(file, content string, snippet bool) ([]ast.Statement, bool) {
+	if l.parseCache == nil {
+		return nil, false
+	}
+	statements, ok := l.parseCache.Load(file, content, snippet)
+	if !ok {
+		return nil, false
+	}
+	// Lexer is still needed to display source lines of lint errors
+	l.includexLexers[file] = lexer.NewFromLines(content, lexer.WithFile(file))
+	return statements, true
+}
+
 func (l *Linter) resolveIncludeStatements(statements []ast.Statement, ctx *context.Context, isRoot bool) []ast.Statement {
 	var resolved []ast.Statement
 
@@ -988,7 +1051,24 @@ func (l *Linter) lintFastlyBoilerPlateMacro(sub *ast.SubroutineDeclaration, ctx
 			`Subroutine "%s" is missing Fastly boilerplate comment "%s" inside definition`, sub.Name.Value, phrase,
 		),
 	}
-	l.Error(err.Match(SUBROUTINE_BOILERPLATE_MACRO))
+	l.Error(err.Match(SUBROUTINE_BOILERPLATE_MACRO).Fixable(
+		fmt.Sprintf(`Insert "#%s" macro to subroutine "%s"`, phrase, sub.Name.Value),
+		boilerPlateMacroEdit(sub, phrase),
+	))
+}
+
+// Insert macro comment before the first statement, or inside the braces if subroutine is empty
+func boilerPlateMacroEdit(sub *ast.SubroutineDeclaration, phrase string) *Edit {
+	if len(sub.Block.Statements) == 0 {
+		t := sub.Block.GetMeta().Token
+		t.Position++
+		return InsertEdit(t, "\n  #"+phrase+"\n")
+	}
+	first := sub.Block.Statements[0].GetMeta()
+	if len(first.Leading) > 0 {
+		return InsertLineEdit(first.Leading[0].Token, "#"+phrase)
+	}
+	return InsertLineEdit(first.Token, "#"+phrase)
 }
 
 func (l *Linter) lintBlockStatement(block *ast.BlockStatement, ctx *context.Context) types.Type {
@@ -1146,6 +1226,28 @@ func (l *Linter) lintRemoveStatement(stmt *ast.RemoveStatement, ctx *context.Con
 	return types.NeverType
 }
 
+// If the subroutine never refers regex captured values, overriding them is harmless
+// so we can fix by changing capturing groups to non-capturing groups
+func regexMatchedValueFix(err *LintError, cond ast.Expression, ctx *context.Context) *LintError {
+	if ctx.CurrentSubroutine == nil || refersRegexGroup(reflect.ValueOf(ctx.CurrentSubroutine.Block)) {
+		return err
+	}
+	regex := findCaptureRegex(cond)
+	if regex == nil {
+		return err
+	}
+
+	t := regex.GetMeta().Token
+	quote := [2]string{`"`, `"`}
+	if t.Offset == 4 {
+		quote = [2]string{`{"`, `"}`}
+	}
+	return err.Fixable(
+		"Change regex capturing groups to non-capturing groups",
+		ReplaceEdit(t, tokenLength(t), quote[0]+nonCapturingRegex(regex.Value)+quote[1]),
+	)
+}
+
 func (l *Linter) lintIfStatement(stmt *ast.IfStatement, ctx *context.Context) types.Type {
 	l.lintIfCondition(stmt.Condition, ctx)
 
@@ -1156,7 +1258,7 @@ func (l *Linter) lintIfStatement(stmt *ast.IfStatement, ctx *context.Context) ty
 			Token:    stmt.Condition.GetMeta().Token,
 			Message:  err.Error(),
 		}
-		l.Error(err.Match(REGEX_MATCHED_VALUE_MAY_OVERRIDE))
+		l.Error(regexMatchedValueFix(err.Match(REGEX_MATCHED_VALUE_MAY_OVERRIDE), stmt.Condition, ctx))
 	}
 	l.lint(stmt.Consequence, ctx)
 
@@ -1168,7 +1270,7 @@ func (l *Linter) lintIfStatement(stmt *ast.IfStatement, ctx *context.Context) ty
 				Token:    a.Condition.GetMeta().Token,
 				Message:  err.Error(),
 			}
-			l.Error(err.Match(REGEX_MATCHED_VALUE_MAY_OVERRIDE))
+			l.Error(regexMatchedValueFix(err.Match(REGEX_MATCHED_VALUE_MAY_OVERRIDE), a.Condition, ctx))
 		}
 		l.lint(a.Consequence, ctx)
 	}
@@ -1476,7 +1578,15 @@ func (l *Linter) lintReturnStatement(stmt *ast.ReturnStatement, ctx *context.Con
 				Token:    stmt.GetMeta().Token,
 				Message:  "Empty return is disallowed in state-machine method",
 			}
-			l.Error(err.Match(DISALLOW_EMPTY_RETURN))
+			err.Match(DISALLOW_EMPTY_RETURN)
+			// Fastly default next state of the subroutine
+			if state := defaultReturnState(ctx.Mode()); state != "" {
+				err.Fixable(
+					fmt.Sprintf("Return default state %s", state),
+					ReplaceEdit(stmt.GetMeta().Token, len("return"), "return("+state+")"),
+				)
+			}
+			l.Error(err)
 		}
 		return types.NeverType
 	}
@@ -1736,7 +1846,7 @@ func (l *Linter) lintInfixExpression(exp *ast.InfixExpression, ctx *context.Cont
 		case types.StringType:
 			break
 		default:
-			l.Error(ImplicitTypeConversion(exp.GetMeta(), left, types.StringType))
+			l.Error(implicitConversionFix(ImplicitTypeConversion(exp.GetMeta(), left, types.StringType), exp.Left, left))
 		}
 
 		switch right {
@@ -1749,7 +1859,7 @@ func (l *Linter) lintInfixExpression(exp *ast.InfixExpression, ctx *context.Cont
 		case types.StringType:
 			break
 		default:
-			l.Error(ImplicitTypeConversion(exp.GetMeta(), right, types.StringType))
+			l.Error(implicitConversionFix(ImplicitTypeConversion(exp.GetMeta(), right, types.StringType), exp.Right, right))
 		}
 		return types.StringType
 	case "&&", "||":
@@ -1760,6 +1870,33 @@ func (l *Linter) lintInfixExpression(exp *ast.InfixExpression, ctx *context.Cont
 	}
 }
 
+// Implicit type conversion could be explicit by std.itoa function
+// when the operand is a single token like variable or literal
+func implicitConversionFix(err *LintError, operand ast.Expression, t types.Type) *LintError {
+	// Only INTEGER has the conversion function which returns the same string as implicit conversion
+	if t != types.IntegerType {
+		return err
+	}
+	// Concatenation operand is parsed as prefix expression like "+ var.foo"
+	if prefix, ok := operand.(*ast.PrefixExpression); ok && prefix.Operator == "+" {
+		operand = prefix.Right
+	}
+	switch operand.(type) {
+	case *ast.Ident, *ast.Integer:
+	default:
+		return err
+	}
+
+	start := operand.GetMeta().Token
+	end := start
+	end.Position += tokenLength(start)
+	return err.Fixable(
+		"Convert INTEGER to STRING explicitly with std.itoa",
+		InsertEdit(start, "std.itoa("),
+		InsertEdit(end, ")"),
+	)
+}
+
 func (l *Linter) lintIfExpression(exp *ast.IfExpression, ctx *context.Context) types.Type {
 	l.lintIfCondition(exp.Condition, ctx)
 	if err := pushRegexGroupVars(exp.Condition, ctx); err != nil {
@@ -1768,7 +1905,7 @@ func (l *Linter) lintIfExpression(exp *ast.IfExpression, ctx *context.Context) t
 			Token:    exp.Condition.GetMeta().Token,
 			Message:  err.Error(),
 		}
-		l.Error(err.Match(REGEX_MATCHED_VALUE_MAY_OVERRIDE))
+		l.Error(regexMatchedValueFix(err.Match(REGEX_MATCHED_VALUE_MAY_OVERRIDE), exp.Condition, ctx))
 	}
 
 	if isConstantExpression(exp.Consequence) {
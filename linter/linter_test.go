@@ -12,6 +12,7 @@ import (
 	"github.com/ysugimoto/falco/parser"
 	"github.com/ysugimoto/falco/resolver"
 	"github.com/ysugimoto/falco/snippets"
+	"github.com/ysugimoto/falco/token"
 	"github.com/ysugimoto/falco/types"
 )
 
@@ -889,6 +890,66 @@ sub foo {
 		assertErrorWithSeverity(t, input, INFO)
 	})
 
+	t.Run("non-capturing group does not override re.group.N", func(t *testing.T) {
+		input := `
+sub foo {
+	declare local var.S STRING;
+	set var.S = "foo.bar.baz.example.com";
+	if (var.S ~ "foo\.(^[.]+)\.baz") {
+		if (var.S ~ "(?:^[.]+)\.bar") {
+			restart;
+		}
+		restart;
+	}
+	set var.S = re.group.1;
+}`
+		assertNoError(t, input)
+	})
+
+	t.Run("escaped parenthesis and character class are not capturing group", func(t *testing.T) {
+		input := `
+sub foo {
+	declare local var.S STRING;
+	set var.S = "foo.bar.baz.example.com";
+	if (var.S ~ "foo\.(^[.]+)\.baz") {
+		if (var.S ~ "\(bar\)[()]") {
+			restart;
+		}
+		restart;
+	}
+	set var.S = re.group.1;
+}`
+		assertNoError(t, input)
+	})
+
+	t.Run("named group overrides re.group.N", func(t *testing.T) {
+		input := `
+sub foo {
+	declare local var.S STRING;
+	set var.S = "foo.bar.baz.example.com";
+	if (var.S ~ "foo\.(^[.]+)\.baz") {
+		if (var.S ~ "(?P<name>^[.]+)\.bar") {
+			restart;
+		}
+		restart;
+	}
+	set var.S = re.group.1;
+}`
+		assertErrorWithSeverity(t, input, INFO)
+	})
+
+	t.Run("nested capturing groups are counted", func(t *testing.T) {
+		input := `
+sub foo {
+	declare local var.S STRING;
+	set var.S = "foo.bar.baz.example.com";
+	if (var.S ~ "((^[.]+)\.bar)") {
+		set var.S = re.group.2;
+	}
+}`
+		assertNoError(t, input)
+	})
+
 	t.Run("condition type is not expected", func(t *testing.T) {
 		input := `
 sub foo {
@@ -2703,6 +2764,34 @@ sub vcl_recv {
 	assertNoError(t, input)
 }
 
+func TestIgnoredLines(t *testing.T) {
+	input := `
+sub vcl_recv {
+   #FASTLY RECV
+   # falco-ignore-next-line
+   set req.http.A = "1";
+   set req.http.B = "1";
+   set req.http.C = "1"; // falco-ignore
+   // falco-ignore-start
+   if (req.http.D) {
+     set req.http.E = "1";
+   }
+   // falco-ignore-end
+   set req.http.F = "1";
+}`
+	vcl, err := parser.New(lexer.NewFromString(input)).ParseVCL()
+	if err != nil {
+		t.Fatalf("unexpected parser error: %s", err)
+	}
+	lines := NewIgnoredLines(vcl)
+	expects := map[int]bool{5: true, 6: false, 7: true, 9: true, 10: true, 13: false}
+	for line, expect := range expects {
+		if lines.Contains(token.Token{Line: line}) != expect {
+			t.Errorf("Expected ignored of line %d is %t", line, expect)
+		}
+	}
+}
+
 func TestEmptyReturnStatement(t *testing.T) {
 	t.Run("Error on state-machine-methods", func(t *testing.T) {
 		methodWithMacros := map[string]string{
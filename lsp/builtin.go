@@ -0,0 +1,153 @@
+package lsp
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/ysugimoto/falco/context"
+	"github.com/ysugimoto/falco/types"
+)
+
+const allScopes = context.RECV | context.HASH | context.HIT | context.MISS | context.PASS |
+	context.FETCH | context.ERROR | context.DELIVER | context.LOG
+
+const anyKey = "%any%"
+
+type variableItem struct {
+	name     string
+	accessor *context.Accessor
+}
+
+type functionItem struct {
+	name     string
+	function *context.BuiltinFunction
+}
+
+// Flatten predefined variables to the list of full variable name.
+// Variables which have any name like "req.http.%any%" are not listed because we could not complete them.
+func flattenVariables(vars context.Variables, prefix string) []*variableItem {
+	var items []*variableItem
+	for key, obj := range vars {
+		if key == anyKey {
+			continue
+		}
+		name := prefix + key
+		if obj.Value != nil {
+			items = append(items, &variableItem{name: name, accessor: obj.Value})
+		}
+		items = append(items, flattenVariables(obj.Items, name+".")...)
+	}
+	sort.Slice(items, func(i, j int) bool {
+		return items[i].name < items[j].name
+	})
+	return items
+}
+
+func flattenFunctions(functions context.Functions, prefix string) []*functionItem {
+	var items []*functionItem
+	for key, spec := range functions {
+		name := prefix + key
+		if spec.Value != nil {
+			items = append(items, &functionItem{name: name, function: spec.Value})
+		}
+		items = append(items, flattenFunctions(spec.Items, name+".")...)
+	}
+	sort.Slice(items, func(i, j int) bool {
+		return items[i].name < items[j].name
+	})
+	return items
+}
+
+// Find predefined variable accessor by name.
+// If exact key is not found, try to find the object which accepts any name like "req.http.%any%".
+func lookupVariable(vars context.Variables, name string) *context.Accessor {
+	keys := strings.Split(name, ".")
+	obj, ok := vars[keys[0]]
+	if !ok {
+		return nil
+	}
+	for _, key := range keys[1:] {
+		if v, ok := obj.Items[key]; ok {
+			obj = v
+		} else if v, ok := obj.Items[anyKey]; ok {
+			obj = v
+		} else {
+			return nil
+		}
+	}
+	return obj.Value
+}
+
+func lookupFunction(functions context.Functions, name string) *context.BuiltinFunction {
+	keys := strings.Split(name, ".")
+	spec, ok := functions[keys[0]]
+	if !ok {
+		return nil
+	}
+	for _, key := range keys[1:] {
+		v, ok := spec.Items[key]
+		if !ok {
+			return nil
+		}
+		spec = v
+	}
+	return spec.Value
+}
+
+func functionSignatures(name string, fn *context.BuiltinFunction) []string {
+	if len(fn.Arguments) == 0 {
+		return []string{fmt.Sprintf("%s %s()", fn.Return, name)}
+	}
+
+	signatures := make([]string, len(fn.Arguments))
+	for i, args := range fn.Arguments {
+		names := make([]string, len(args))
+		for j := range args {
+			names[j] = args[j].String()
+		}
+		signatures[i] = fmt.Sprintf("%s %s(%s)", fn.Return, name, strings.Join(names, ", "))
+	}
+	return signatures
+}
+
+func functionDocument(name string, fn *context.BuiltinFunction) string {
+	var doc strings.Builder
+	doc.WriteString("```vcl\n")
+	doc.WriteString(strings.Join(functionSignatures(name, fn), "\n"))
+	doc.WriteString("\n```\n")
+	writeScopesAndReference(&doc, fn.Scopes, fn.Reference)
+	return doc.String()
+}
+
+func variableDocument(name string, v *context.Accessor) string {
+	var doc strings.Builder
+	valueType := v.Get
+	if valueType == types.NeverType {
+		valueType = v.Set
+	}
+	doc.WriteString(fmt.Sprintf("```vcl\n%s %s\n```\n", valueType, name))
+
+	var access []string
+	if v.Get != types.NeverType {
+		access = append(access, "get")
+	}
+	if v.Set != types.NeverType {
+		access = append(access, "set")
+	}
+	if v.Unset {
+		access = append(access, "unset")
+	}
+	if len(access) > 0 {
+		doc.WriteString(fmt.Sprintf("\nAccess: %s\n", strings.Join(access, ", ")))
+	}
+	writeScopesAndReference(&doc, v.Scopes, v.Reference)
+	return doc.String()
+}
+
+func writeScopesAndReference(doc *strings.Builder, scopes int, reference string) {
+	doc.WriteString(fmt.Sprintf("\nScopes: %s\n", strings.TrimSpace(context.ScopesString(scopes))))
+	if reference != "" {
+		doc.WriteString(fmt.Sprintf("\n[Reference](%s)\n", reference))
+	}
+}
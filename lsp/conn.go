@@ -0,0 +1,120 @@
+package lsp
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+const jsonrpcVersion = "2.0"
+
+// conn reads and writes JSON-RPC messages which are framed with Content-Length header
+type conn struct {
+	reader *bufio.Reader
+	writer io.Writer
+	mu     sync.Mutex
+}
+
+func newConn(r io.Reader, w io.Writer) *conn {
+	return &conn{
+		reader: bufio.NewReader(r),
+		writer: w,
+	}
+}
+
+func (c *conn) Read() (*Message, error) {
+	var length int
+	for {
+		line, err := c.reader.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		line = strings.TrimSpace(line)
+		// Empty line means end of header part
+		if line == "" {
+			break
+		}
+		name, value, found := strings.Cut(line, ":")
+		if !found {
+			return nil, errors.New(fmt.Sprintf("Invalid header line: %s", line))
+		}
+		// Other headers like Content-Type are ignored
+		if strings.EqualFold(strings.TrimSpace(name), "Content-Length") {
+			length, err = strconv.Atoi(strings.TrimSpace(value))
+			if err != nil {
+				return nil, errors.WithStack(err)
+			}
+		}
+	}
+	if length <= 0 {
+		return nil, errors.New("Content-Length header is missing")
+	}
+
+	body := make([]byte, length)
+	if _, err := io.ReadFull(c.reader, body); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	var msg Message
+	if err := json.Unmarshal(body, &msg); err != nil {
+		return nil, &ResponseError{
+			Code:    errorCodeParseError,
+			Message: err.Error(),
+		}
+	}
+	return &msg, nil
+}
+
+func (c *conn) Write(msg *Message) error {
+	msg.JSONRPC = jsonrpcVersion
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, err := fmt.Fprintf(c.writer, "Content-Length: %d\r\n\r\n", len(body)); err != nil {
+		return errors.WithStack(err)
+	}
+	if _, err := c.writer.Write(body); err != nil {
+		return errors.WithStack(err)
+	}
+	return nil
+}
+
+func (c *conn) Reply(id *json.RawMessage, result interface{}) error {
+	encoded, err := json.Marshal(result)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	return c.Write(&Message{
+		ID:     id,
+		Result: encoded,
+	})
+}
+
+func (c *conn) ReplyError(id *json.RawMessage, err *ResponseError) error {
+	return c.Write(&Message{
+		ID:    id,
+		Error: err,
+	})
+}
+
+func (c *conn) Notify(method string, params interface{}) error {
+	encoded, err := json.Marshal(params)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	return c.Write(&Message{
+		Method: method,
+		Params: encoded,
+	})
+}
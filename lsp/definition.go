@@ -0,0 +1,190 @@
+package lsp
+
+import (
+	"strings"
+
+	"github.com/ysugimoto/falco/ast"
+	"github.com/ysugimoto/falco/resolver"
+)
+
+// symbol is a declaration which could be jumped from its reference
+type symbol struct {
+	name     string
+	detail   string
+	kind     CompletionItemKind
+	location Location
+}
+
+// symbols collects declarations in the document and included files recursively
+func (s *Server) symbols(doc *document) map[string]*symbol {
+	symbols := make(map[string]*symbol)
+
+	vcl, err := parseVCL(doc.path, doc.text)
+	if err != nil {
+		return symbols
+	}
+	rslv := s.resolver(doc)
+	collectSymbols(rslv, vcl.Statements, doc.lines, symbols, map[string]struct{}{doc.path: {}})
+	return symbols
+}
+
+// lines are source lines of the statements to convert token positions to LSP ranges
+func collectSymbols(
+	rslv resolver.Resolver,
+	statements []ast.Statement,
+	lines []string,
+	symbols map[string]*symbol,
+	visited map[string]struct{},
+) {
+	for _, stmt := range statements {
+		var sym *symbol
+		switch t := stmt.(type) {
+		case *ast.IncludeStatement:
+			module := resolveModule(rslv, t, visited)
+			if module == nil {
+				continue
+			}
+			vcl, err := parseVCL(module.Name, module.Data)
+			if err != nil {
+				continue
+			}
+			collectSymbols(rslv, vcl.Statements, strings.Split(module.Data, "\n"), symbols, visited)
+			continue
+		case *ast.SubroutineDeclaration:
+			sym = newSymbol(t.Name, lines, "sub", CompletionKindFunction)
+			if t.ReturnType != nil {
+				sym.detail += " " + t.ReturnType.Value
+			}
+			collectLocalSymbols(rslv, t.Block.Statements, lines, symbols, visited)
+		case *ast.BackendDeclaration:
+			sym = newSymbol(t.Name, lines, "backend", CompletionKindReference)
+		case *ast.DirectorDeclaration:
+			sym = newSymbol(t.Name, lines, "director", CompletionKindReference)
+			sym.detail += " " + t.DirectorType.Value
+		case *ast.TableDeclaration:
+			sym = newSymbol(t.Name, lines, "table", CompletionKindReference)
+			if t.ValueType != nil {
+				sym.detail += " " + t.ValueType.Value
+			}
+		case *ast.AclDeclaration:
+			sym = newSymbol(t.Name, lines, "acl", CompletionKindReference)
+		case *ast.PenaltyboxDeclaration:
+			sym = newSymbol(t.Name, lines, "penaltybox", CompletionKindReference)
+		case *ast.RatecounterDeclaration:
+			sym = newSymbol(t.Name, lines, "ratecounter", CompletionKindReference)
+		default:
+			continue
+		}
+		symbols[sym.name] = sym
+	}
+}
+
+// Subroutine body could include files which have statements,
+// so local variable declarations are collected from the body and included files recursively
+func collectLocalSymbols(
+	rslv resolver.Resolver,
+	statements []ast.Statement,
+	lines []string,
+	symbols map[string]*symbol,
+	visited map[string]struct{},
+) {
+	for _, stmt := range statements {
+		switch t := stmt.(type) {
+		case *ast.IncludeStatement:
+			module := resolveModule(rslv, t, visited)
+			if module == nil {
+				continue
+			}
+			included, err := parseSnippetVCL(module.Name, module.Data)
+			if err != nil {
+				continue
+			}
+			collectLocalSymbols(rslv, included, strings.Split(module.Data, "\n"), symbols, visited)
+		case *ast.DeclareStatement:
+			sym := newSymbol(t.Name, lines, "declare local", CompletionKindVariable)
+			sym.detail += " " + t.ValueType.Value
+			symbols[sym.name] = sym
+		}
+	}
+}
+
+// Resolve included file, returns nil for snippet, unresolved and already visited file
+func resolveModule(rslv resolver.Resolver, include *ast.IncludeStatement, visited map[string]struct{}) *resolver.VCL {
+	// Snippet is fetched from remote, not a file
+	if strings.HasPrefix(include.Module.Value, "snippet::") {
+		return nil
+	}
+	module, err := rslv.Resolve(include)
+	if err != nil {
+		return nil
+	}
+	// Guard for circular include
+	if _, ok := visited[module.Name]; ok {
+		return nil
+	}
+	visited[module.Name] = struct{}{}
+	return module
+}
+
+func newSymbol(name *ast.Ident, lines []string, keyword string, kind CompletionItemKind) *symbol {
+	return &symbol{
+		name:   name.Value,
+		detail: keyword + " " + name.Value,
+		kind:   kind,
+		location: Location{
+			URI:   pathToURI(name.Token.File),
+			Range: tokenRange(lines, name.Token, name.Value),
+		},
+	}
+}
+
+// definition returns declared location of the word at the position.
+// If the position is on include statement, returns the location of included file.
+func (s *Server) definition(doc *document, pos Position) *Location {
+	if loc := s.includeDefinition(doc, pos); loc != nil {
+		return loc
+	}
+
+	word, _, _ := doc.wordAt(pos)
+	if word == "" {
+		return nil
+	}
+	if sym, ok := s.symbols(doc)[word]; ok {
+		return &sym.location
+	}
+	return nil
+}
+
+func (s *Server) includeDefinition(doc *document, pos Position) *Location {
+	vcl, err := parseVCL(doc.path, doc.text)
+	if err != nil {
+		return nil
+	}
+
+	var includes []*ast.IncludeStatement
+	for _, stmt := range vcl.Statements {
+		switch t := stmt.(type) {
+		case *ast.IncludeStatement:
+			includes = append(includes, t)
+		case *ast.SubroutineDeclaration:
+			for _, s := range t.Block.Statements {
+				if include, ok := s.(*ast.IncludeStatement); ok {
+					includes = append(includes, include)
+				}
+			}
+		}
+	}
+
+	rslv := s.resolver(doc)
+	for _, include := range includes {
+		if include.Token.Line-1 != pos.Line {
+			continue
+		}
+		module, err := rslv.Resolve(include)
+		if err != nil {
+			return nil
+		}
+		return &Location{URI: pathToURI(module.Name)}
+	}
+	return nil
+}
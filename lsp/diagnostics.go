@@ -0,0 +1,114 @@
+package lsp
+
+import (
+	"fmt"
+
+	"github.com/pkg/errors"
+	"github.com/ysugimoto/falco/ast"
+	"github.com/ysugimoto/falco/context"
+	"github.com/ysugimoto/falco/lexer"
+	"github.com/ysugimoto/falco/linter"
+	"github.com/ysugimoto/falco/parser"
+)
+
+func parseVCL(name, code string) (*ast.VCL, error) {
+	lx := lexer.NewFromString(code, lexer.WithFile(name))
+	return parser.New(lx).ParseVCL()
+}
+
+// Parse the file which is included in the subroutine
+func parseSnippetVCL(name, code string) ([]ast.Statement, error) {
+	lx := lexer.NewFromString(code, lexer.WithFile(name))
+	return parser.New(lx).ParseSnippetVCL()
+}
+
+// diagnostics lints the document and converts reported errors to LSP diagnostics.
+// Only errors which are reported in the document are returned,
+// errors in included files are reported when the file is opened.
+func (s *Server) diagnostics(doc *document) []Diagnostic {
+	diagnostics := []Diagnostic{}
+
+	vcl, err := parseVCL(doc.path, doc.text)
+	if err != nil {
+		return append(diagnostics, parseErrorDiagnostic(doc, err))
+	}
+
+	ctx := context.New(context.WithResolver(s.resolver(doc)))
+	lt := linter.New(s.config.Linter)
+	lt.Lint(vcl, ctx)
+
+	// Fatal error means parse error occurs on included file
+	if lt.FatalError != nil {
+		return append(diagnostics, parseErrorDiagnostic(doc, lt.FatalError.Error))
+	}
+
+	for _, err := range lt.Errors {
+		le, ok := err.(*linter.LintError)
+		if !ok {
+			continue
+		}
+		if le.Token.File != "" && le.Token.File != doc.path {
+			continue
+		}
+
+		severity := le.Severity
+		if v, ok := s.overrides[string(le.Rule)]; ok {
+			severity = v
+		}
+		if severity == linter.IGNORE {
+			continue
+		}
+
+		d := Diagnostic{
+			Range:    tokenRange(doc.lines, le.Token, le.Token.Literal),
+			Severity: diagnosticSeverity(severity),
+			Code:     string(le.Rule),
+			Source:   diagnosticSource,
+			Message:  le.Message,
+		}
+		if le.Reference != "" {
+			d.CodeDescription = &CodeDescription{Href: le.Reference}
+		}
+		diagnostics = append(diagnostics, d)
+	}
+
+	return diagnostics
+}
+
+func parseErrorDiagnostic(doc *document, err error) Diagnostic {
+	pe, ok := errors.Cause(err).(*parser.ParseError)
+	if !ok {
+		return Diagnostic{
+			Severity: SeverityError,
+			Source:   diagnosticSource,
+			Message:  err.Error(),
+		}
+	}
+
+	// Parse error in included file, report at the beginning of document
+	if pe.Token.File != "" && pe.Token.File != doc.path {
+		return Diagnostic{
+			Severity: SeverityError,
+			Source:   diagnosticSource,
+			Message:  fmt.Sprintf("Failed to parse included file %s: %s", pe.Token.File, pe.Message),
+		}
+	}
+
+	return Diagnostic{
+		Range:    tokenRange(doc.lines, pe.Token, pe.Token.Literal),
+		Severity: SeverityError,
+		Source:   diagnosticSource,
+		Message:  pe.Message,
+	}
+}
+
+func diagnosticSeverity(s linter.Severity) DiagnosticSeverity {
+	switch s {
+	case linter.ERROR:
+		return SeverityError
+	case linter.WARNING:
+		return SeverityWarning
+	default:
+		return SeverityInformation
+	}
+}
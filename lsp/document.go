@@ -0,0 +1,162 @@
+package lsp
+
+import (
+	"net/url"
+	"path/filepath"
+	"strings"
+
+	"github.com/ysugimoto/falco/ast"
+	"github.com/ysugimoto/falco/resolver"
+	"github.com/ysugimoto/falco/token"
+)
+
+// document is a text which is opened in the editor
+type document struct {
+	uri   string
+	path  string
+	text  string
+	lines []string
+}
+
+func newDocument(uri, text string) *document {
+	return &document{
+		uri:   uri,
+		path:  uriToPath(uri),
+		text:  text,
+		lines: strings.Split(text, "\n"),
+	}
+}
+
+// Find the word which contains character position, the word may have dot or colon like "req.http.Foo:bar".
+// Returns the word and its range, also returns the part of word before the position for completion.
+// Note that character position is counted in UTF-16 code units.
+func (d *document) wordAt(pos Position) (string, string, Range) {
+	if pos.Line < 0 || pos.Line >= len(d.lines) {
+		return "", "", Range{Start: pos, End: pos}
+	}
+	line := []rune(strings.TrimRight(d.lines[pos.Line], "\r"))
+	cursor := runeIndex(line, pos.Character)
+
+	start := cursor
+	for start > 0 && isWordRune(line[start-1]) {
+		start--
+	}
+	end := cursor
+	for end < len(line) && isWordRune(line[end]) {
+		end++
+	}
+
+	return string(line[start:end]), string(line[start:cursor]), Range{
+		Start: Position{Line: pos.Line, Character: utf16Len(line[:start])},
+		End:   Position{Line: pos.Line, Character: utf16Len(line[:end])},
+	}
+}
+
+// Convert UTF-16 character offset to the rune index of the line
+func runeIndex(line []rune, character int) int {
+	var units int
+	for i, r := range line {
+		if units >= character {
+			return i
+		}
+		units += utf16RuneLen(r)
+	}
+	return len(line)
+}
+
+// Runes outside of the BMP are encoded as surrogate pair in UTF-16
+func utf16RuneLen(r rune) int {
+	if r >= 0x10000 {
+		return 2
+	}
+	return 1
+}
+
+// Count UTF-16 code units of the runes
+func utf16Len(runes []rune) int {
+	var units int
+	for _, r := range runes {
+		units += utf16RuneLen(r)
+	}
+	return units
+}
+
+func isWordRune(r rune) bool {
+	switch {
+	case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+		return true
+	case r == '_', r == '.', r == '-', r == ':':
+		return true
+	default:
+		return false
+	}
+}
+
+func uriToPath(uri string) string {
+	u, err := url.Parse(uri)
+	if err != nil || u.Scheme != "file" {
+		return uri
+	}
+	return filepath.FromSlash(u.Path)
+}
+
+func pathToURI(path string) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+	u := url.URL{Scheme: "file", Path: filepath.ToSlash(path)}
+	return u.String()
+}
+
+// Convert token position to LSP range of the text, token line and position are 1-based and counted in runes
+// but LSP's one is 0-based and counted in UTF-16 code units so lines of the source file are needed.
+func tokenRange(lines []string, t token.Token, text string) Range {
+	line := max(t.Line-1, 0)
+	char := max(t.Position-1, 0)
+	if line < len(lines) {
+		runes := []rune(lines[line])
+		char = utf16Len(runes[:min(char, len(runes))])
+	}
+	return Range{
+		Start: Position{Line: line, Character: char},
+		End:   Position{Line: line, Character: char + utf16Len([]rune(text))},
+	}
+}
+
+// documentResolver resolves include statement relative to the document path.
+// If included file is also opened in the editor, use its editing text rather than file content.
+type documentResolver struct {
+	resolver.Resolver
+	main      *resolver.VCL
+	documents map[string]*document
+}
+
+func (s *Server) resolver(doc *document) resolver.Resolver {
+	var base resolver.Resolver = &resolver.EmptyResolver{}
+	if rslv, err := resolver.NewFileResolvers(doc.path, s.config.IncludePaths); err == nil {
+		base = rslv[0]
+	}
+
+	return &documentResolver{
+		Resolver:  base,
+		main:      &resolver.VCL{Name: doc.path, Data: doc.text},
+		documents: s.documents,
+	}
+}
+
+func (r *documentResolver) MainVCL() (*resolver.VCL, error) {
+	return r.main, nil
+}
+
+func (r *documentResolver) Resolve(stmt *ast.IncludeStatement) (*resolver.VCL, error) {
+	vcl, err := r.Resolver.Resolve(stmt)
+	if err != nil {
+		return nil, err
+	}
+	for _, doc := range r.documents {
+		if doc.path == vcl.Name {
+			return &resolver.VCL{Name: vcl.Name, Data: doc.text}, nil
+		}
+	}
+	return vcl, nil
+}
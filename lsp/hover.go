@@ -0,0 +1,123 @@
+package lsp
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/ysugimoto/falco/context"
+)
+
+var subroutineDeclaration = regexp.MustCompile(`^\s*sub\s+([A-Za-z0-9_\-]+)`)
+
+func (s *Server) hover(doc *document, pos Position) *Hover {
+	word, _, wordRange := doc.wordAt(pos)
+	if word == "" {
+		return nil
+	}
+
+	var contents string
+	if fn := lookupFunction(s.functions, word); fn != nil {
+		contents = functionDocument(word, fn)
+	} else if v := lookupVariable(s.variables, word); v != nil {
+		contents = variableDocument(word, v)
+	} else if sym, ok := s.symbols(doc)[word]; ok {
+		contents = fmt.Sprintf(
+			"```vcl\n%s\n```\n\nDefined in %s:%d",
+			sym.detail, uriToPath(sym.location.URI), sym.location.Range.Start.Line+1,
+		)
+	} else {
+		return nil
+	}
+
+	return &Hover{
+		Contents: MarkupContent{Kind: markupKindMarkdown, Value: contents},
+		Range:    &wordRange,
+	}
+}
+
+func (s *Server) completion(doc *document, pos Position) *CompletionList {
+	_, prefix, wordRange := doc.wordAt(pos)
+	// Replace from the beginning of word to the cursor
+	editRange := Range{Start: wordRange.Start, End: pos}
+	scope := s.scopeAt(doc, pos.Line)
+
+	list := &CompletionList{Items: []CompletionItem{}}
+	for _, v := range s.variableItems {
+		if !strings.HasPrefix(v.name, prefix) || v.accessor.Scopes&scope == 0 {
+			continue
+		}
+		list.Items = append(list.Items, CompletionItem{
+			Label:  v.name,
+			Kind:   CompletionKindVariable,
+			Detail: v.accessor.Get.String(),
+			Documentation: &MarkupContent{
+				Kind:  markupKindMarkdown,
+				Value: variableDocument(v.name, v.accessor),
+			},
+			TextEdit: &TextEdit{Range: editRange, NewText: v.name},
+		})
+	}
+	for _, fn := range s.functionItems {
+		if !strings.HasPrefix(fn.name, prefix) || fn.function.Scopes&scope == 0 {
+			continue
+		}
+		list.Items = append(list.Items, CompletionItem{
+			Label:  fn.name,
+			Kind:   CompletionKindFunction,
+			Detail: strings.Join(functionSignatures(fn.name, fn.function), "\n"),
+			Documentation: &MarkupContent{
+				Kind:  markupKindMarkdown,
+				Value: functionDocument(fn.name, fn.function),
+			},
+			TextEdit: &TextEdit{Range: editRange, NewText: fn.name},
+		})
+	}
+	for _, sym := range s.symbols(doc) {
+		if !strings.HasPrefix(sym.name, prefix) {
+			continue
+		}
+		list.Items = append(list.Items, CompletionItem{
+			Label:    sym.name,
+			Kind:     sym.kind,
+			Detail:   sym.detail,
+			TextEdit: &TextEdit{Range: editRange, NewText: sym.name},
+		})
+	}
+
+	return list
+}
+
+// Find the scope of subroutine which contains the line.
+// We find subroutine declaration by text because the document may not be parsed while editing.
+// If the scope could not be determined, all scopes are allowed.
+func (s *Server) scopeAt(doc *document, line int) int {
+	for i := min(line, len(doc.lines)-1); i >= 0; i-- {
+		m := subroutineDeclaration.FindStringSubmatch(doc.lines[i])
+		if m == nil {
+			continue
+		}
+		switch {
+		case strings.HasSuffix(m[1], "_recv"):
+			return context.RECV
+		case strings.HasSuffix(m[1], "_hash"):
+			return context.HASH
+		case strings.HasSuffix(m[1], "_hit"):
+			return context.HIT
+		case strings.HasSuffix(m[1], "_miss"):
+			return context.MISS
+		case strings.HasSuffix(m[1], "_pass"):
+			return context.PASS
+		case strings.HasSuffix(m[1], "_fetch"):
+			return context.FETCH
+		case strings.HasSuffix(m[1], "_error"):
+			return context.ERROR
+		case strings.HasSuffix(m[1], "_deliver"):
+			return context.DELIVER
+		case strings.HasSuffix(m[1], "_log"):
+			return context.LOG
+		}
+		break
+	}
+	return allScopes
+}
@@ -0,0 +1,263 @@
+package lsp
+
+import (
+	"encoding/json"
+	"io"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/ysugimoto/falco/config"
+	"github.com/ysugimoto/falco/context"
+	"github.com/ysugimoto/falco/linter"
+)
+
+var ErrExitWithoutShutdown = errors.New("exit notification is received without shutdown request")
+
+// Server is Language Server Protocol implementation for VCL.
+// Server communicates with the editor over stdio and provides:
+//
+// - diagnostics which are reported by falco linter
+// - hover documents for builtin functions, predefined variables and declarations
+// - completion for builtin functions, predefined variables and declarations
+// - go-to-definition for subroutines, backends, tables, ACLs and so on across included files
+type Server struct {
+	config    *config.Config
+	conn      *conn
+	documents map[string]*document
+	overrides map[string]linter.Severity
+	version   string
+
+	// Builtin definitions for hover and completion
+	variables     context.Variables
+	functions     context.Functions
+	variableItems []*variableItem
+	functionItems []*functionItem
+
+	initialized bool
+	isShutdown  bool
+}
+
+func New(c *config.Config, version string) *Server {
+	s := &Server{
+		config:    c,
+		documents: make(map[string]*document),
+		overrides: make(map[string]linter.Severity),
+		version:   version,
+	}
+
+	// Override linter rules as well as lint command
+	for key, value := range c.Linter.Rules {
+		switch strings.ToUpper(value) {
+		case "ERROR":
+			s.overrides[key] = linter.ERROR
+		case "WARNING":
+			s.overrides[key] = linter.WARNING
+		case "INFO":
+			s.overrides[key] = linter.INFO
+		case "IGNORE":
+			s.overrides[key] = linter.IGNORE
+		}
+	}
+
+	ctx := context.New()
+	s.variables = ctx.Variables
+	s.functions = ctx.Functions()
+	s.variableItems = flattenVariables(s.variables, "")
+	s.functionItems = flattenFunctions(s.functions, "")
+
+	return s
+}
+
+// Serve starts to read messages from reader and writes responses to writer.
+// This function blocks until exit notification is received or reader is closed.
+func (s *Server) Serve(r io.Reader, w io.Writer) error {
+	s.conn = newConn(r, w)
+
+	for {
+		msg, err := s.conn.Read()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			if re, ok := err.(*ResponseError); ok {
+				if err := s.conn.ReplyError(nil, re); err != nil {
+					return errors.WithStack(err)
+				}
+				continue
+			}
+			return errors.WithStack(err)
+		}
+
+		if msg.Method == methodExit {
+			if !s.isShutdown {
+				return ErrExitWithoutShutdown
+			}
+			return nil
+		}
+
+		result, err := s.handle(msg)
+		// Notification does not need to reply
+		if msg.ID == nil {
+			continue
+		}
+		if err != nil {
+			re, ok := err.(*ResponseError)
+			if !ok {
+				re = &ResponseError{Code: errorCodeInvalidRequest, Message: err.Error()}
+			}
+			if err := s.conn.ReplyError(msg.ID, re); err != nil {
+				return errors.WithStack(err)
+			}
+			continue
+		}
+		if err := s.conn.Reply(msg.ID, result); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+}
+
+func (s *Server) handle(msg *Message) (interface{}, error) {
+	if !s.initialized && msg.Method != methodInitialize {
+		return nil, &ResponseError{
+			Code:    errorCodeServerNotInitialized,
+			Message: "Server is not initialized",
+		}
+	}
+
+	// Only exit notification is accepted after shutdown
+	if s.isShutdown {
+		return nil, &ResponseError{
+			Code:    errorCodeInvalidRequest,
+			Message: "Server is shut down",
+		}
+	}
+
+	switch msg.Method {
+	case methodInitialize:
+		return s.initialize()
+	case methodInitialized:
+		return nil, nil
+	case methodShutdown:
+		s.isShutdown = true
+		return nil, nil
+	case methodDidOpen:
+		var params DidOpenTextDocumentParams
+		if err := unmarshalParams(msg, &params); err != nil {
+			return nil, err
+		}
+		doc := newDocument(params.TextDocument.URI, params.TextDocument.Text)
+		s.documents[doc.uri] = doc
+		return nil, s.publishDiagnostics(doc)
+	case methodDidChange:
+		var params DidChangeTextDocumentParams
+		if err := unmarshalParams(msg, &params); err != nil {
+			return nil, err
+		}
+		// Server declares full text synchronization so the last change has whole document
+		if len(params.ContentChanges) == 0 {
+			return nil, nil
+		}
+		doc := newDocument(params.TextDocument.URI, params.ContentChanges[len(params.ContentChanges)-1].Text)
+		s.documents[doc.uri] = doc
+		return nil, s.publishDiagnostics(doc)
+	case methodDidSave:
+		var params DidSaveTextDocumentParams
+		if err := unmarshalParams(msg, &params); err != nil {
+			return nil, err
+		}
+		doc, ok := s.documents[params.TextDocument.URI]
+		if !ok {
+			return nil, nil
+		}
+		if params.Text != nil {
+			doc = newDocument(doc.uri, *params.Text)
+			s.documents[doc.uri] = doc
+		}
+		return nil, s.publishDiagnostics(doc)
+	case methodDidClose:
+		var params DidCloseTextDocumentParams
+		if err := unmarshalParams(msg, &params); err != nil {
+			return nil, err
+		}
+		delete(s.documents, params.TextDocument.URI)
+		// Clear diagnostics for closed document
+		return nil, s.conn.Notify(methodPublishDiagnostics, PublishDiagnosticsParams{
+			URI:         params.TextDocument.URI,
+			Diagnostics: []Diagnostic{},
+		})
+	case methodHover:
+		var params TextDocumentPositionParams
+		if err := unmarshalParams(msg, &params); err != nil {
+			return nil, err
+		}
+		doc, ok := s.documents[params.TextDocument.URI]
+		if !ok {
+			return nil, nil
+		}
+		return s.hover(doc, params.Position), nil
+	case methodCompletion:
+		var params TextDocumentPositionParams
+		if err := unmarshalParams(msg, &params); err != nil {
+			return nil, err
+		}
+		doc, ok := s.documents[params.TextDocument.URI]
+		if !ok {
+			return nil, nil
+		}
+		return s.completion(doc, params.Position), nil
+	case methodDefinition:
+		var params TextDocumentPositionParams
+		if err := unmarshalParams(msg, &params); err != nil {
+			return nil, err
+		}
+		doc, ok := s.documents[params.TextDocument.URI]
+		if !ok {
+			return nil, nil
+		}
+		return s.definition(doc, params.Position), nil
+	default:
+		// Unknown notification should be ignored
+		if msg.ID == nil {
+			return nil, nil
+		}
+		return nil, &ResponseError{
+			Code:    errorCodeMethodNotFound,
+			Message: "Method not found: " + msg.Method,
+		}
+	}
+}
+
+func (s *Server) initialize() (*InitializeResult, error) {
+	s.initialized = true
+	return &InitializeResult{
+		Capabilities: ServerCapabilities{
+			TextDocumentSync:   textDocumentSyncKindFull,
+			HoverProvider:      true,
+			DefinitionProvider: true,
+			CompletionProvider: &CompletionOptions{
+				TriggerCharacters: []string{completionTriggerCharacter},
+			},
+		},
+		ServerInfo: ServerInfo{
+			Name:    "falco",
+			Version: s.version,
+		},
+	}, nil
+}
+
+func (s *Server) publishDiagnostics(doc *document) error {
+	return s.conn.Notify(methodPublishDiagnostics, PublishDiagnosticsParams{
+		URI:         doc.uri,
+		Diagnostics: s.diagnostics(doc),
+	})
+}
+
+func unmarshalParams(msg *Message, v interface{}) error {
+	if err := json.Unmarshal(msg.Params, v); err != nil {
+		return &ResponseError{
+			Code:    errorCodeInvalidParams,
+			Message: err.Error(),
+		}
+	}
+	return nil
+}
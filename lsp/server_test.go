@@ -0,0 +1,354 @@
+package lsp
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/ysugimoto/falco/config"
+	"github.com/ysugimoto/falco/token"
+)
+
+const mainVCL = `include "backends";
+
+sub vcl_recv {
+  #FASTLY RECV
+  set req.backend = F_origin;
+  set req.http.Foo = std.toupper(req.url);
+  return (lookup);
+}
+`
+
+const backendsVCL = `backend F_origin {
+  .host = "example.com";
+}
+`
+
+func setupFiles(t *testing.T) string {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "main.vcl"), []byte(mainVCL), 0o644); err != nil {
+		t.Fatalf("Failed to write main.vcl: %s", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "backends.vcl"), []byte(backendsVCL), 0o644); err != nil {
+		t.Fatalf("Failed to write backends.vcl: %s", err)
+	}
+	return dir
+}
+
+func newTestServer() *Server {
+	return New(&config.Config{Linter: &config.LinterConfig{}}, "test")
+}
+
+func frame(t *testing.T, messages ...map[string]interface{}) *bytes.Buffer {
+	var buf bytes.Buffer
+	for _, m := range messages {
+		m["jsonrpc"] = "2.0"
+		body, err := json.Marshal(m)
+		if err != nil {
+			t.Fatalf("Failed to marshal message: %s", err)
+		}
+		fmt.Fprintf(&buf, "Content-Length: %d\r\n\r\n%s", len(body), body)
+	}
+	return &buf
+}
+
+func readMessages(t *testing.T, buf *bytes.Buffer) []*Message {
+	var messages []*Message
+	c := newConn(bufio.NewReader(buf), nil)
+	for buf.Len() > 0 || c.reader.Buffered() > 0 {
+		msg, err := c.Read()
+		if err != nil {
+			t.Fatalf("Failed to read message: %s", err)
+		}
+		messages = append(messages, msg)
+	}
+	return messages
+}
+
+func TestServe(t *testing.T) {
+	dir := setupFiles(t)
+	uri := pathToURI(filepath.Join(dir, "main.vcl"))
+
+	input := frame(t,
+		map[string]interface{}{"id": 1, "method": "initialize", "params": map[string]interface{}{}},
+		map[string]interface{}{"method": "initialized", "params": map[string]interface{}{}},
+		map[string]interface{}{"method": "textDocument/didOpen", "params": map[string]interface{}{
+			"textDocument": map[string]interface{}{"uri": uri, "languageId": "vcl", "version": 1, "text": mainVCL},
+		}},
+		map[string]interface{}{"id": 2, "method": "textDocument/unknown", "params": map[string]interface{}{}},
+		map[string]interface{}{"id": 3, "method": "shutdown"},
+		map[string]interface{}{"method": "exit"},
+	)
+	var output bytes.Buffer
+	if err := newTestServer().Serve(input, &output); err != nil {
+		t.Errorf("Unexpected serve error: %s", err)
+		return
+	}
+
+	messages := readMessages(t, &output)
+	if len(messages) != 4 {
+		t.Errorf("Expected 4 messages, got %d", len(messages))
+		return
+	}
+
+	var result InitializeResult
+	if err := json.Unmarshal(messages[0].Result, &result); err != nil {
+		t.Errorf("Failed to unmarshal initialize result: %s", err)
+	}
+	if !result.Capabilities.HoverProvider || !result.Capabilities.DefinitionProvider {
+		t.Errorf("Unexpected capabilities: %+v", result.Capabilities)
+	}
+
+	if messages[1].Method != methodPublishDiagnostics {
+		t.Errorf("Expected publishDiagnostics notification, got %s", messages[1].Method)
+	}
+	var diagnostics PublishDiagnosticsParams
+	if err := json.Unmarshal(messages[1].Params, &diagnostics); err != nil {
+		t.Errorf("Failed to unmarshal diagnostics: %s", err)
+	}
+	if len(diagnostics.Diagnostics) != 0 {
+		t.Errorf("Expected no diagnostics, got %+v", diagnostics.Diagnostics)
+	}
+
+	if messages[2].Error == nil || messages[2].Error.Code != errorCodeMethodNotFound {
+		t.Errorf("Expected method not found error, got %+v", messages[2].Error)
+	}
+	if string(messages[3].Result) != "null" {
+		t.Errorf("Expected null result for shutdown, got %s", messages[3].Result)
+	}
+}
+
+func TestServeExitWithoutShutdown(t *testing.T) {
+	input := frame(t, map[string]interface{}{"method": "exit"})
+	if err := newTestServer().Serve(input, &bytes.Buffer{}); err != ErrExitWithoutShutdown {
+		t.Errorf("Expected ErrExitWithoutShutdown, got %v", err)
+	}
+}
+
+func TestServeAfterShutdown(t *testing.T) {
+	input := frame(t,
+		map[string]interface{}{"id": 1, "method": "initialize", "params": map[string]interface{}{}},
+		map[string]interface{}{"id": 2, "method": "shutdown"},
+		map[string]interface{}{"id": 3, "method": "textDocument/hover", "params": map[string]interface{}{}},
+		map[string]interface{}{"method": "exit"},
+	)
+	var output bytes.Buffer
+	if err := newTestServer().Serve(input, &output); err != nil {
+		t.Errorf("Unexpected serve error: %s", err)
+		return
+	}
+
+	messages := readMessages(t, &output)
+	if len(messages) != 3 {
+		t.Errorf("Expected 3 messages, got %d", len(messages))
+		return
+	}
+	if messages[2].Error == nil || messages[2].Error.Code != errorCodeInvalidRequest {
+		t.Errorf("Expected invalid request error after shutdown, got %+v", messages[2].Error)
+	}
+}
+
+func TestUTF16Position(t *testing.T) {
+	// Emoji is a surrogate pair in UTF-16
+	doc := newDocument("file:///main.vcl", "sub vcl_recv {\n  log \"🍣\" + req.url;\n}\n")
+	expect := Range{
+		Start: Position{Line: 1, Character: 13},
+		End:   Position{Line: 1, Character: 20},
+	}
+
+	word, prefix, wordRange := doc.wordAt(Position{Line: 1, Character: 16})
+	if word != "req.url" || prefix != "req" {
+		t.Errorf("Unexpected word %q and prefix %q", word, prefix)
+	}
+	if diff := cmp.Diff(expect, wordRange); diff != "" {
+		t.Errorf("Word range assertion error, diff=%s", diff)
+	}
+
+	tok := token.Token{Literal: "req.url", Line: 2, Position: 13}
+	if diff := cmp.Diff(expect, tokenRange(doc.lines, tok, tok.Literal)); diff != "" {
+		t.Errorf("Token range assertion error, diff=%s", diff)
+	}
+}
+
+func TestDiagnostics(t *testing.T) {
+	dir := setupFiles(t)
+	s := newTestServer()
+
+	t.Run("Lint error is reported with range", func(t *testing.T) {
+		doc := newDocument(pathToURI(filepath.Join(dir, "main.vcl")), strings.Replace(mainVCL, "F_origin", "F_unknown", 1))
+		diagnostics := s.diagnostics(doc)
+		if len(diagnostics) == 0 {
+			t.Errorf("Expected diagnostics but got nothing")
+			return
+		}
+		expect := Diagnostic{
+			Range: Range{
+				Start: Position{Line: 4, Character: 20},
+				End:   Position{Line: 4, Character: 29},
+			},
+			Severity: SeverityError,
+			Source:   "falco",
+			Message:  `Undefined variable "F_unknown"`,
+		}
+		if diff := cmp.Diff(expect, diagnostics[0]); diff != "" {
+			t.Errorf("Diagnostic assertion error, diff=%s", diff)
+		}
+	})
+
+	t.Run("Parse error is reported", func(t *testing.T) {
+		doc := newDocument(pathToURI(filepath.Join(dir, "main.vcl")), "sub vcl_recv {\n  set req.http.Foo = ;\n}\n")
+		diagnostics := s.diagnostics(doc)
+		if len(diagnostics) != 1 {
+			t.Errorf("Expected 1 diagnostic, got %d", len(diagnostics))
+			return
+		}
+		if diagnostics[0].Range.Start.Line != 1 {
+			t.Errorf("Expected parse error at line 1, got %d", diagnostics[0].Range.Start.Line)
+		}
+	})
+
+	t.Run("Rule severity is overridden by config", func(t *testing.T) {
+		s := New(&config.Config{
+			Linter: &config.LinterConfig{
+				Rules: map[string]string{"subroutine/syntax": "IGNORE"},
+			},
+		}, "test")
+		doc := newDocument(pathToURI(filepath.Join(dir, "main.vcl")), mainVCL+"\nsub InvalidName {}\n")
+		for _, d := range s.diagnostics(doc) {
+			if d.Code == "subroutine/syntax" {
+				t.Errorf("Ignored rule should not be reported: %+v", d)
+			}
+		}
+	})
+}
+
+func TestHover(t *testing.T) {
+	dir := setupFiles(t)
+	s := newTestServer()
+	doc := newDocument(pathToURI(filepath.Join(dir, "main.vcl")), mainVCL)
+
+	tests := []struct {
+		name     string
+		position Position
+		contains string
+	}{
+		{name: "builtin function", position: Position{Line: 5, Character: 24}, contains: "STRING std.toupper(STRING)"},
+		{name: "predefined variable", position: Position{Line: 5, Character: 36}, contains: "STRING req.url"},
+		{name: "any name variable", position: Position{Line: 5, Character: 15}, contains: "STRING req.http.Foo"},
+		{name: "declaration in included file", position: Position{Line: 4, Character: 22}, contains: "backend F_origin"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hover := s.hover(doc, tt.position)
+			if hover == nil {
+				t.Errorf("Expected hover but got nil")
+				return
+			}
+			if !strings.Contains(hover.Contents.Value, tt.contains) {
+				t.Errorf("Expected hover contains %q, got %q", tt.contains, hover.Contents.Value)
+			}
+		})
+	}
+}
+
+func TestCompletion(t *testing.T) {
+	dir := setupFiles(t)
+	s := newTestServer()
+
+	t.Run("Complete variables and functions in scope", func(t *testing.T) {
+		doc := newDocument(pathToURI(filepath.Join(dir, "main.vcl")), "sub vcl_recv {\n  set req.http.Foo = std.tou\n}\n")
+		list := s.completion(doc, Position{Line: 1, Character: 28})
+		var labels []string
+		for _, item := range list.Items {
+			labels = append(labels, item.Label)
+		}
+		if diff := cmp.Diff([]string{"std.toupper"}, labels); diff != "" {
+			t.Errorf("Completion assertion error, diff=%s", diff)
+		}
+		expect := Range{
+			Start: Position{Line: 1, Character: 21},
+			End:   Position{Line: 1, Character: 28},
+		}
+		if diff := cmp.Diff(expect, list.Items[0].TextEdit.Range); diff != "" {
+			t.Errorf("TextEdit range assertion error, diff=%s", diff)
+		}
+	})
+
+	t.Run("Variables which could not access in scope are not listed", func(t *testing.T) {
+		doc := newDocument(pathToURI(filepath.Join(dir, "main.vcl")), "sub vcl_recv {\n  set beresp.tt\n}\n")
+		list := s.completion(doc, Position{Line: 1, Character: 15})
+		if len(list.Items) != 0 {
+			t.Errorf("Expected no completion items, got %+v", list.Items)
+		}
+	})
+
+	t.Run("Complete declarations", func(t *testing.T) {
+		doc := newDocument(pathToURI(filepath.Join(dir, "main.vcl")), strings.Replace(mainVCL, "= F_origin", "= F_", 1))
+		list := s.completion(doc, Position{Line: 4, Character: 22})
+		if len(list.Items) != 1 || list.Items[0].Label != "F_origin" {
+			t.Errorf("Expected F_origin is completed, got %+v", list.Items)
+		}
+	})
+}
+
+func TestDefinition(t *testing.T) {
+	dir := setupFiles(t)
+	s := newTestServer()
+	doc := newDocument(pathToURI(filepath.Join(dir, "main.vcl")), mainVCL)
+
+	t.Run("Jump to declaration in included file", func(t *testing.T) {
+		loc := s.definition(doc, Position{Line: 4, Character: 22})
+		expect := &Location{
+			URI: pathToURI(filepath.Join(dir, "backends.vcl")),
+			Range: Range{
+				Start: Position{Line: 0, Character: 8},
+				End:   Position{Line: 0, Character: 16},
+			},
+		}
+		if diff := cmp.Diff(expect, loc); diff != "" {
+			t.Errorf("Definition assertion error, diff=%s", diff)
+		}
+	})
+
+	t.Run("Jump to included file", func(t *testing.T) {
+		loc := s.definition(doc, Position{Line: 0, Character: 12})
+		expect := &Location{URI: pathToURI(filepath.Join(dir, "backends.vcl"))}
+		if diff := cmp.Diff(expect, loc); diff != "" {
+			t.Errorf("Definition assertion error, diff=%s", diff)
+		}
+	})
+
+	t.Run("Jump to local variable declared in file included in subroutine", func(t *testing.T) {
+		if err := os.WriteFile(filepath.Join(dir, "locals.vcl"), []byte("declare local var.Foo STRING;\n"), 0o644); err != nil {
+			t.Fatalf("Failed to write locals.vcl: %s", err)
+		}
+		doc := newDocument(
+			pathToURI(filepath.Join(dir, "main.vcl")),
+			"sub vcl_recv {\n  include \"locals\";\n  set req.http.Foo = var.Foo;\n}\n",
+		)
+		loc := s.definition(doc, Position{Line: 2, Character: 24})
+		expect := &Location{
+			URI: pathToURI(filepath.Join(dir, "locals.vcl")),
+			Range: Range{
+				Start: Position{Line: 0, Character: 14},
+				End:   Position{Line: 0, Character: 21},
+			},
+		}
+		if diff := cmp.Diff(expect, loc); diff != "" {
+			t.Errorf("Definition assertion error, diff=%s", diff)
+		}
+	})
+
+	t.Run("Unknown word returns nil", func(t *testing.T) {
+		if loc := s.definition(doc, Position{Line: 2, Character: 1}); loc != nil {
+			t.Errorf("Expected nil but got %+v", loc)
+		}
+	})
+}
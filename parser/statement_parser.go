@@ -566,7 +566,11 @@ func (p *Parser) parseIfStatement() (*ast.IfStatement, error) {
 				continue
 			}
 
-			// Otherwise, it is else statement. next token must be LEFT_BRACE
+			// Otherwise, it is else statement. The leading comment of ELSE token
+			// is the comment for alternative block
+			stmt.AlternativeComments = p.curToken.Leading
+
+			// next token must be LEFT_BRACE
 			if !p.expectPeek(token.LEFT_BRACE) {
 				return nil, errors.WithStack(UnexpectedToken(p.peekToken, "LEFT_BRACE"))
 			}
@@ -82,17 +82,21 @@ type FalcoTransformInput struct {
 }
 
 func Encode(vcl *VCL) ([]byte, error) {
-	buf := new(bytes.Buffer)
 	cwd, err := os.Getwd()
 	if err != nil {
 		return nil, err
 	}
-	if err := gob.NewEncoder(buf).Encode(&FalcoTransformInput{
+	return encode(&FalcoTransformInput{
 		Metadata: Metadata{
 			WorkingDirectory: cwd,
 		},
 		VCL: vcl,
-	}); err != nil {
+	})
+}
+
+func encode(v interface{}) ([]byte, error) {
+	buf := new(bytes.Buffer)
+	if err := gob.NewEncoder(buf).Encode(v); err != nil {
 		return nil, err
 	}
 	return buf.Bytes(), nil
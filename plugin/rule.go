@@ -0,0 +1,155 @@
+package plugin
+
+import (
+	"encoding/gob"
+	"io"
+	"os"
+	"sort"
+
+	"github.com/ysugimoto/falco/context"
+	"github.com/ysugimoto/falco/token"
+)
+
+// Custom lint rule protocol.
+// falco sends gob encoded FalcoRuleInput to "falco-rule-[name]" executable through stdin,
+// and the rule executable writes gob encoded FalcoRuleOutput to stdout.
+
+// Declaration is a summary of declared resource in the linter context
+type Declaration struct {
+	Name     string
+	IsUsed   bool
+	External bool // true if declared in Fastly remote, not in VCL
+	Token    token.Token
+}
+
+// ContextSummary is a summary of the linter context after linting
+type ContextSummary struct {
+	Acls         []*Declaration
+	Backends     []*Declaration
+	Directors    []*Declaration
+	Tables       []*Declaration
+	Subroutines  []*Declaration
+	Penaltyboxes []*Declaration
+	Ratecounters []*Declaration
+}
+
+// LintError is reported from the custom rule.
+// Severity accepts ERROR, WARNING or INFO, and Rule defaults to the plugin name if empty.
+type LintError struct {
+	Rule      string
+	Severity  string
+	Message   string
+	Reference string
+	Token     token.Token
+}
+
+type FalcoRuleInput struct {
+	Metadata Metadata
+	VCL      *VCL
+	Context  *ContextSummary
+}
+
+type FalcoRuleOutput struct {
+	Errors []*LintError
+}
+
+func NewContextSummary(ctx *context.Context) *ContextSummary {
+	s := &ContextSummary{}
+
+	for name, v := range ctx.Acls {
+		d := &Declaration{Name: name, IsUsed: v.IsUsed, External: v.Decl == nil}
+		if v.Decl != nil {
+			d.Token = v.Decl.GetMeta().Token
+		}
+		s.Acls = append(s.Acls, d)
+	}
+	for name, v := range ctx.Backends {
+		d := &Declaration{Name: name, IsUsed: v.IsUsed}
+		switch {
+		case v.DirectorDecl != nil:
+			d.Token = v.DirectorDecl.GetMeta().Token
+			s.Directors = append(s.Directors, d)
+		case v.BackendDecl != nil:
+			d.Token = v.BackendDecl.GetMeta().Token
+			s.Backends = append(s.Backends, d)
+		default:
+			d.External = true
+			s.Backends = append(s.Backends, d)
+		}
+	}
+	for name, v := range ctx.Tables {
+		d := &Declaration{Name: name, IsUsed: v.IsUsed, External: v.Decl == nil}
+		if v.Decl != nil {
+			d.Token = v.Decl.GetMeta().Token
+		}
+		s.Tables = append(s.Tables, d)
+	}
+	for name, v := range ctx.Subroutines {
+		s.Subroutines = append(s.Subroutines, &Declaration{
+			Name:   name,
+			IsUsed: v.IsUsed,
+			Token:  v.Decl.GetMeta().Token,
+		})
+	}
+	for name, v := range ctx.Penaltyboxes {
+		s.Penaltyboxes = append(s.Penaltyboxes, &Declaration{
+			Name:   name,
+			IsUsed: v.IsUsed,
+			Token:  v.Decl.GetMeta().Token,
+		})
+	}
+	for name, v := range ctx.Ratecounters {
+		s.Ratecounters = append(s.Ratecounters, &Declaration{
+			Name:   name,
+			IsUsed: v.IsUsed,
+			Token:  v.Decl.GetMeta().Token,
+		})
+	}
+
+	// Sort by name to keep the order stable
+	for _, list := range [][]*Declaration{
+		s.Acls, s.Backends, s.Directors, s.Tables, s.Subroutines, s.Penaltyboxes, s.Ratecounters,
+	} {
+		sort.Slice(list, func(i, j int) bool {
+			return list[i].Name < list[j].Name
+		})
+	}
+	return s
+}
+
+func EncodeRuleInput(vcl *VCL, summary *ContextSummary) ([]byte, error) {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return nil, err
+	}
+	return encode(&FalcoRuleInput{
+		Metadata: Metadata{
+			WorkingDirectory: cwd,
+		},
+		VCL:     vcl,
+		Context: summary,
+	})
+}
+
+func DecodeRuleInput(r io.Reader) (*FalcoRuleInput, error) {
+	var input FalcoRuleInput
+	if err := gob.NewDecoder(r).Decode(&input); err != nil {
+		return nil, err
+	}
+	return &input, nil
+}
+
+// WriteRuleOutput is used in the rule executable to reply lint errors
+func WriteRuleOutput(w io.Writer, errs []*LintError) error {
+	return gob.NewEncoder(w).Encode(&FalcoRuleOutput{
+		Errors: errs,
+	})
+}
+
+func DecodeRuleOutput(r io.Reader) (*FalcoRuleOutput, error) {
+	var output FalcoRuleOutput
+	if err := gob.NewDecoder(r).Decode(&output); err != nil {
+		return nil, err
+	}
+	return &output, nil
+}
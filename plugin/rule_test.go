@@ -0,0 +1,83 @@
+package plugin
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/ysugimoto/falco/ast"
+	"github.com/ysugimoto/falco/context"
+	"github.com/ysugimoto/falco/token"
+	"github.com/ysugimoto/falco/types"
+)
+
+func TestRuleInputEncodeDecode(t *testing.T) {
+	ctx := context.New()
+	ctx.Backends["F_b"] = &types.Backend{
+		BackendDecl: &ast.BackendDeclaration{
+			Meta: &ast.Meta{Token: token.Token{Line: 1, Position: 1}},
+			Name: &ast.Ident{Value: "F_b"},
+		},
+		IsUsed: true,
+	}
+	ctx.Backends["F_a"] = &types.Backend{}
+	ctx.Backends["D_director"] = &types.Backend{
+		DirectorDecl: &ast.DirectorDeclaration{
+			Meta: &ast.Meta{Token: token.Token{Line: 5, Position: 1}},
+			Name: &ast.Ident{Value: "D_director"},
+		},
+	}
+
+	buf, err := EncodeRuleInput(&VCL{File: "main.vcl", AST: &ast.VCL{}}, NewContextSummary(ctx))
+	if err != nil {
+		t.Errorf("Encode error: %s", err)
+		return
+	}
+	input, err := DecodeRuleInput(bytes.NewReader(buf))
+	if err != nil {
+		t.Errorf("Decode error: %s", err)
+		return
+	}
+	if input.Metadata.WorkingDirectory == "" || input.VCL.File != "main.vcl" {
+		t.Errorf("Unexpected input: %+v", input)
+	}
+
+	expectBackends := []*Declaration{
+		{Name: "F_a", External: true},
+		{Name: "F_b", IsUsed: true, Token: token.Token{Line: 1, Position: 1}},
+	}
+	if diff := cmp.Diff(expectBackends, input.Context.Backends); diff != "" {
+		t.Errorf("Backends assertion error, diff=%s", diff)
+	}
+	expectDirectors := []*Declaration{
+		{Name: "D_director", Token: token.Token{Line: 5, Position: 1}},
+	}
+	if diff := cmp.Diff(expectDirectors, input.Context.Directors); diff != "" {
+		t.Errorf("Directors assertion error, diff=%s", diff)
+	}
+}
+
+func TestRuleOutputEncodeDecode(t *testing.T) {
+	errs := []*LintError{
+		{
+			Rule:     "backend/first-byte-timeout",
+			Severity: "WARNING",
+			Message:  "Backend must set .first_byte_timeout",
+			Token:    token.Token{File: "main.vcl", Line: 1, Position: 1},
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := WriteRuleOutput(&buf, errs); err != nil {
+		t.Errorf("Encode error: %s", err)
+		return
+	}
+	output, err := DecodeRuleOutput(&buf)
+	if err != nil {
+		t.Errorf("Decode error: %s", err)
+		return
+	}
+	if diff := cmp.Diff(errs, output.Errors); diff != "" {
+		t.Errorf("Output assertion error, diff=%s", diff)
+	}
+}
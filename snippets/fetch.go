@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"fmt"
 	"html/template"
+	"io"
 	"sort"
 	"strings"
 
@@ -20,7 +21,8 @@ type Fetcher interface {
 	LoggingEndpoints() ([]string, error)
 }
 
-func Fetch(fetcher Fetcher) (*Snippets, error) {
+// Fetch snippets from the fetcher, progress is written to w
+func Fetch(fetcher Fetcher, w io.Writer) (*Snippets, error) {
 	snippets := &Snippets{
 		ScopedSnippets:   make(map[string][]SnippetItem),
 		IncludeSnippets:  make(map[string]SnippetItem),
@@ -28,7 +30,7 @@ func Fetch(fetcher Fetcher) (*Snippets, error) {
 	}
 
 	var eg errgroup.Group
-	fmt.Print("Fething snippets...")
+	fmt.Fprint(w, "Fetching snippets...")
 	eg.Go(func() (err error) {
 		snippets.Dictionaries, err = fetchEdgeDictionary(fetcher)
 		return err
@@ -47,10 +49,10 @@ func Fetch(fetcher Fetcher) (*Snippets, error) {
 	})
 
 	if err := eg.Wait(); err != nil {
-		fmt.Println("Error!")
+		fmt.Fprintln(w, "Error!")
 		return nil, err
 	}
-	fmt.Println("Done.")
+	fmt.Fprintln(w, "Done.")
 	return snippets, nil
 }
 
@@ -19,6 +19,15 @@ func (f *TerraformFetcher) SetName(name string) {
 	f.currentName = name
 }
 
+// Service returns new fetcher which is filtered by the service name.
+// Unlike SetName, it is safe to use while other services are processed concurrently.
+func (f *TerraformFetcher) Service(name string) *TerraformFetcher {
+	return &TerraformFetcher{
+		services:    f.services,
+		currentName: name,
+	}
+}
+
 func (f *TerraformFetcher) filterService() []*FastlyService {
 	if f.currentName == "" {
 		return f.services
@@ -126,7 +126,6 @@ func UnmarshalTerraformPlannedInput(buf []byte) ([]*FastlyService, error) {
 	}
 
 	var services []*FastlyService
-	var serviceValues *FastlyServiceValues
 	// Case: service is declared in root module
 	if len(root.PlannedValues.RootModule.Resources) > 0 {
 		for _, v := range root.PlannedValues.RootModule.Resources {
@@ -134,6 +133,8 @@ func UnmarshalTerraformPlannedInput(buf []byte) ([]*FastlyService, error) {
 				continue
 			}
 
+			// Unmarshal to the fresh value for each service, otherwise slices are shared between services
+			var serviceValues *FastlyServiceValues
 			if err := json.Unmarshal(v.Values, &serviceValues); err != nil {
 				return nil, errors.Wrap(err, "Failed to unmarshal values")
 			}
@@ -157,6 +158,8 @@ func UnmarshalTerraformPlannedInput(buf []byte) ([]*FastlyService, error) {
 				continue
 			}
 
+			// Unmarshal to the fresh value for each service, otherwise slices are shared between services
+			var serviceValues *FastlyServiceValues
 			if err := json.Unmarshal(v.Values, &serviceValues); err != nil {
 				return nil, errors.Wrap(err, "Failed to unmarshal values")
 			}
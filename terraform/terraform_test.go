@@ -48,3 +48,28 @@ func TestUnmarshallInValidTfJson(t *testing.T) {
 		t.Fatalf("Expected error when unarshalling tf %s ", fileName)
 	}
 }
+
+func TestUnmarshallMultipleServicesTfJson(t *testing.T) {
+	fileName := "./data/terraform-multiple-services.json"
+	buf, err := os.ReadFile(fileName)
+	if err != nil {
+		t.Fatalf("Unexpected error %s reading file %s ", fileName, err)
+	}
+
+	services, err := UnmarshalTerraformPlannedInput(buf)
+	if err != nil {
+		t.Fatalf("Unexpected error %s unarshalling %s ", fileName, err)
+	}
+
+	if len(services) != 3 {
+		t.Fatalf("Length of services should be %d, got %d", 3, len(services))
+	}
+
+	// Each service must have its own VCL
+	if services[0].Vcls[0].Content == services[1].Vcls[0].Content {
+		t.Errorf("VCL of service %s should not be shared with %s", services[0].Name, services[1].Name)
+	}
+	if services[1].Vcls[0].Content == services[2].Vcls[0].Content {
+		t.Errorf("VCL of service %s should not be shared with %s", services[1].Name, services[2].Name)
+	}
+}
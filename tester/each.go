@@ -0,0 +1,209 @@
+package tester
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/ysugimoto/falco/ast"
+	"github.com/ysugimoto/falco/lexer"
+	"github.com/ysugimoto/falco/parser"
+	tf "github.com/ysugimoto/falco/tester/function"
+	"github.com/ysugimoto/falco/token"
+)
+
+// testRow is a row of the table-driven test case.
+// Row values are bound to the local variables by declare and set statements
+// which are prepended to the test subroutine body.
+type testRow struct {
+	name       string
+	statements []ast.Statement
+}
+
+// Find rows from "@each:" annotations. The annotation accepts either of:
+//
+// - Comma separated local variable bindings of literal values, one annotation per row
+// e.g // @each: var.input = "/Foo", var.expect = "/foo"
+//
+// - Table name which is declared in the testing file, each table item is bound to var.key and var.value
+// e.g // @each: normalize_cases
+func findEachRows(sub *ast.SubroutineDeclaration, defs *tf.Definiions) ([]*testRow, error) {
+	var rows []*testRow
+	comments := sub.GetMeta().Leading
+	for i := range comments {
+		l := strings.TrimLeft(comments[i].Value, " */#")
+		if !strings.HasPrefix(l, "@each:") {
+			continue
+		}
+		l = strings.TrimSpace(strings.TrimPrefix(l, "@each:"))
+
+		if table, ok := defs.Tables[l]; ok {
+			rows = append(rows, tableRows(table, comments[i].Token)...)
+			continue
+		}
+		row, err := parseEachRow(l, comments[i].Token)
+		if err != nil {
+			return nil, errors.WithStack(fmt.Errorf(
+				"Invalid @each annotation in %s at line %d: %w", sub.Name.Value, comments[i].Token.Line, err,
+			))
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// Parse local variable bindings like `var.input = "/Foo", var.expect = "/foo"`
+func parseEachRow(annotation string, tok token.Token) (*testRow, error) {
+	// Lexer position is counted in runes
+	src := []rune(annotation)
+	l := lexer.NewFromString(annotation)
+
+	var names, values []string
+	for {
+		name := l.NextToken()
+		if name.Type != token.IDENT || !strings.HasPrefix(name.Literal, "var.") {
+			return nil, fmt.Errorf("local variable name is expected but got %q", name.Literal)
+		}
+		for _, n := range names {
+			if n == name.Literal {
+				return nil, fmt.Errorf("variable %s is bound twice", n)
+			}
+		}
+		if assign := l.NextToken(); assign.Type != token.ASSIGN {
+			return nil, fmt.Errorf("\"=\" is expected after %s but got %q", name.Literal, assign.Literal)
+		}
+
+		// Value continues until the next comma which is not enclosed in parentheses
+		start := -1
+		var depth int
+		var next token.Token
+		for {
+			next = l.NextToken()
+			if next.Type == token.EOF || (next.Type == token.COMMA && depth == 0) {
+				break
+			}
+			if start < 0 {
+				start = next.Position - 1
+			}
+			switch next.Type {
+			case token.LEFT_PAREN:
+				depth++
+			case token.RIGHT_PAREN:
+				depth--
+			}
+		}
+		end := len(src)
+		if next.Type == token.COMMA {
+			end = next.Position - 1
+		}
+		if start < 0 {
+			return nil, fmt.Errorf("value of %s is empty", name.Literal)
+		}
+		names = append(names, name.Literal)
+		values = append(values, strings.TrimSpace(string(src[start:end])))
+
+		if next.Type == token.EOF {
+			break
+		}
+	}
+
+	row := &testRow{}
+	var bindings []string
+	for i := range names {
+		exp, err := parser.New(lexer.NewFromString(values[i])).ParseExpression(parser.LOWEST)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse value of %s: %w", names[i], err)
+		}
+		valueType, ok := literalType(exp)
+		if !ok {
+			return nil, fmt.Errorf("value of %s must be a literal but got %s", names[i], values[i])
+		}
+		row.statements = append(row.statements, bindStatements(names[i], valueType, exp, tok)...)
+		bindings = append(bindings, names[i]+"="+values[i])
+	}
+	row.name = strings.Join(bindings, ", ")
+	return row, nil
+}
+
+// Rows of table items, the key is bound to var.key and the value is bound to var.value
+func tableRows(table *ast.TableDeclaration, tok token.Token) []*testRow {
+	valueType := "STRING"
+	if table.ValueType != nil {
+		valueType = table.ValueType.Value
+	}
+
+	var rows []*testRow
+	for _, p := range table.Properties {
+		key := &ast.String{Meta: ast.New(tok, 0), Value: p.Key.Value}
+		var statements []ast.Statement
+		statements = append(statements, bindStatements("var.key", "STRING", key, tok)...)
+		statements = append(statements, bindStatements("var.value", valueType, p.Value, tok)...)
+		rows = append(rows, &testRow{
+			name:       fmt.Sprintf("var.key=%s, var.value=%s", key.String(), strings.TrimSpace(p.Value.String())),
+			statements: statements,
+		})
+	}
+	return rows
+}
+
+// Value type of literal expression to declare the local variable
+func literalType(exp ast.Expression) (string, bool) {
+	switch e := exp.(type) {
+	case *ast.String:
+		return "STRING", true
+	case *ast.Integer:
+		return "INTEGER", true
+	case *ast.Float:
+		return "FLOAT", true
+	case *ast.Boolean:
+		return "BOOL", true
+	case *ast.RTime:
+		return "RTIME", true
+	case *ast.PrefixExpression:
+		// Negative number like -1
+		if e.Operator != "-" {
+			return "", false
+		}
+		switch e.Right.(type) {
+		case *ast.Integer, *ast.Float, *ast.RTime:
+			return literalType(e.Right)
+		}
+	case *ast.GroupedExpression:
+		return literalType(e.Right)
+	}
+	return "", false
+}
+
+// Make "declare local" and "set" statements for the bound variable
+func bindStatements(name, valueType string, exp ast.Expression, tok token.Token) []ast.Statement {
+	return []ast.Statement{
+		&ast.DeclareStatement{
+			Meta:      ast.New(tok, 1),
+			Name:      &ast.Ident{Meta: ast.New(tok, 1), Value: name},
+			ValueType: &ast.Ident{Meta: ast.New(tok, 1), Value: valueType},
+		},
+		&ast.SetStatement{
+			Meta:     ast.New(tok, 1),
+			Ident:    &ast.Ident{Meta: ast.New(tok, 1), Value: name},
+			Operator: &ast.Operator{Meta: ast.New(tok, 1), Operator: "="},
+			Value:    exp,
+		},
+	}
+}
+
+// Make the subroutine for the row, row values are bound before the original statements
+func (r *testRow) subroutine(sub *ast.SubroutineDeclaration) *ast.SubroutineDeclaration {
+	statements := make([]ast.Statement, 0, len(r.statements)+len(sub.Block.Statements))
+	statements = append(statements, r.statements...)
+	statements = append(statements, sub.Block.Statements...)
+
+	return &ast.SubroutineDeclaration{
+		Meta: sub.Meta,
+		Name: sub.Name,
+		Block: &ast.BlockStatement{
+			Meta:       sub.Block.Meta,
+			Statements: statements,
+		},
+		ReturnType: sub.ReturnType,
+	}
+}
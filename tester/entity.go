@@ -3,6 +3,7 @@ package tester
 import (
 	"encoding/json"
 
+	"github.com/ysugimoto/falco/interpreter/coverage"
 	"github.com/ysugimoto/falco/interpreter/function/errors"
 	"github.com/ysugimoto/falco/lexer"
 )
@@ -18,6 +19,7 @@ func (t *TestCase) MarshalJSON() ([]byte, error) {
 	v := struct {
 		Name  string `json:"name"`
 		Error string `json:"error,omitempty"`
+		Hook  string `json:"hook,omitempty"`
 		Scope string `json:"scope"`
 		Time  int64  `json:"elapsed_time"`
 	}{
@@ -27,6 +29,9 @@ func (t *TestCase) MarshalJSON() ([]byte, error) {
 	}
 	if t.Error != nil {
 		switch e := t.Error.(type) {
+		case *HookError:
+			v.Error = e.Error()
+			v.Hook = string(e.Type)
 		case *errors.AssertionError:
 			v.Error = e.Message
 		case *errors.TestingError:
@@ -57,6 +62,7 @@ type TestFactory struct {
 	Results    []*TestResult
 	Statistics *TestCounter
 	Logs       []string
+	Coverage   *coverage.Report // nil if coverage is not enabled
 }
 
 type TestCounter struct {
@@ -0,0 +1,48 @@
+package function
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ysugimoto/falco/interpreter/value"
+)
+
+func Test_Assert_not_penaltybox_has(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	c := newRatelimitContext(&now)
+	c.Ratelimit.AddPenaltybox("pb", "client", time.Minute, now)
+
+	tests := []struct {
+		args   []value.Value
+		err    bool
+		passed bool
+	}{
+		{args: []value.Value{&value.Ident{Value: "pb"}, &value.String{Value: "other"}}, passed: true},
+		{args: []value.Value{&value.Ident{Value: "pb"}, &value.String{Value: "client"}}, err: true},
+		{args: []value.Value{&value.Ident{Value: "unknown"}, &value.String{Value: "client"}}, err: true},
+	}
+
+	for i, tt := range tests {
+		ret, err := Assert_not_penaltybox_has(c, tt.args...)
+		if tt.err {
+			if err == nil {
+				t.Errorf("[%d] Expected error but got nil", i)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("[%d] Unexpected error: %s", i, err)
+			continue
+		}
+		if v := value.Unwrap[*value.Boolean](ret).Value; v != tt.passed {
+			t.Errorf("[%d] Expected %t but got %t", i, tt.passed, v)
+		}
+	}
+
+	// Entry is out of penaltybox after TTL is expired
+	expired := now.Add(time.Minute)
+	c.FixedTime = &expired
+	if _, err := Assert_not_penaltybox_has(c, &value.Ident{Value: "pb"}, &value.String{Value: "client"}); err != nil {
+		t.Errorf("Unexpected error after TTL is expired: %s", err)
+	}
+}
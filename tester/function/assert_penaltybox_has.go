@@ -0,0 +1,55 @@
+package function
+
+import (
+	"github.com/ysugimoto/falco/interpreter/context"
+	"github.com/ysugimoto/falco/interpreter/function/errors"
+	"github.com/ysugimoto/falco/interpreter/value"
+)
+
+const Assert_penaltybox_has_Name = "assert.penaltybox_has"
+
+func Assert_penaltybox_has_Validate(args []value.Value) error {
+	if len(args) < 2 || len(args) > 3 {
+		return errors.ArgumentNotInRange(Assert_penaltybox_has_Name, 2, 3, args)
+	}
+
+	if args[0].Type() != value.IdentType {
+		return errors.TypeMismatch(Assert_penaltybox_has_Name, 1, value.IdentType, args[0].Type())
+	}
+	if args[1].Type() != value.StringType {
+		return errors.TypeMismatch(Assert_penaltybox_has_Name, 2, value.StringType, args[1].Type())
+	}
+	return nil
+}
+
+func Assert_penaltybox_has(ctx *context.Context, args ...value.Value) (value.Value, error) {
+	if err := Assert_penaltybox_has_Validate(args); err != nil {
+		return nil, errors.NewTestingError(err.Error())
+	}
+
+	name := value.Unwrap[*value.Ident](args[0]).Value
+	if _, ok := ctx.Penaltyboxes[name]; !ok {
+		return &value.Boolean{}, errors.NewTestingError("penaltybox %s not found in VCL", name)
+	}
+	entry := value.Unwrap[*value.String](args[1]).Value
+
+	// Check custom message
+	var message string
+	if len(args) == 3 { // (name, entry, message)
+		if args[2].Type() != value.StringType {
+			return &value.Boolean{}, errors.NewTestingError(
+				"%s: 3rd argument must be STRING, %s provided",
+				Assert_penaltybox_has_Name, args[2].Type(),
+			)
+		}
+		message = value.Unwrap[*value.String](args[2]).Value
+	}
+
+	if !ctx.Ratelimit.InPenaltybox(name, entry, testingNow(ctx)) {
+		if message != "" {
+			return &value.Boolean{}, errors.NewAssertionError(args[1], message)
+		}
+		return &value.Boolean{}, errors.NewAssertionError(args[1], "Entry %s is not in penaltybox %s", entry, name)
+	}
+	return &value.Boolean{Value: true}, nil
+}
@@ -0,0 +1,39 @@
+package function
+
+import (
+	"github.com/ysugimoto/falco/interpreter/context"
+	"github.com/ysugimoto/falco/interpreter/function/errors"
+	"github.com/ysugimoto/falco/interpreter/value"
+)
+
+const Testing_advance_time_Name = "testing.advance_time"
+
+func Testing_advance_time_Validate(args []value.Value) error {
+	if len(args) != 1 {
+		return errors.ArgumentNotEnough(Testing_advance_time_Name, 1, args)
+	}
+	if args[0].Type() != value.RTimeType {
+		return errors.TypeMismatch(Testing_advance_time_Name, 1, value.RTimeType, args[0].Type())
+	}
+	return nil
+}
+
+func Testing_advance_time(
+	ctx *context.Context,
+	args ...value.Value,
+) (value.Value, error) {
+
+	if err := Testing_advance_time_Validate(args); err != nil {
+		return nil, errors.NewTestingError(err.Error())
+	}
+
+	d := value.Unwrap[*value.RTime](args[0]).Value
+	if d < 0 {
+		return value.Null, errors.NewTestingError("Time could not go backwards, %s provided", d)
+	}
+	// Virtual clock starts from current time if time is not fixed yet
+	t := testingNow(ctx).Add(d)
+	ctx.FixedTime = &t
+
+	return value.Null, nil
+}
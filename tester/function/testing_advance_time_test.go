@@ -0,0 +1,44 @@
+package function
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ysugimoto/falco/interpreter/context"
+	"github.com/ysugimoto/falco/interpreter/value"
+)
+
+func Test_advance_time(t *testing.T) {
+	t.Run("Advance fixed time", func(t *testing.T) {
+		fixed := time.Unix(1700000000, 0)
+		c := &context.Context{FixedTime: &fixed}
+		for i := 0; i < 2; i++ {
+			if _, err := Testing_advance_time(c, &value.RTime{Value: time.Minute}); err != nil {
+				t.Errorf("Error should be nil, got: %s", err)
+				return
+			}
+		}
+		if expect := fixed.Add(2 * time.Minute); !c.FixedTime.Equal(expect) {
+			t.Errorf("Fixed time should be %s, got: %s", expect, c.FixedTime)
+		}
+	})
+
+	t.Run("Advance from current time", func(t *testing.T) {
+		c := &context.Context{}
+		start := time.Now()
+		if _, err := Testing_advance_time(c, &value.RTime{Value: time.Hour}); err != nil {
+			t.Errorf("Error should be nil, got: %s", err)
+			return
+		}
+		if c.FixedTime == nil || c.FixedTime.Before(start.Add(time.Hour)) {
+			t.Errorf("Fixed time should be advanced from current time, got: %v", c.FixedTime)
+		}
+	})
+
+	t.Run("Error on negative duration", func(t *testing.T) {
+		c := &context.Context{}
+		if _, err := Testing_advance_time(c, &value.RTime{Value: -time.Minute}); err == nil {
+			t.Errorf("Should return error for negative duration, got nil")
+		}
+	})
+}
@@ -0,0 +1,72 @@
+package function
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/ysugimoto/falco/interpreter"
+	"github.com/ysugimoto/falco/interpreter/context"
+	"github.com/ysugimoto/falco/interpreter/function/errors"
+	"github.com/ysugimoto/falco/interpreter/value"
+)
+
+const Testing_mock_backend_Name = "testing.mock_backend"
+
+var Testing_mock_backend_ArgumentTypes = []value.Type{value.BackendType, value.IntegerType, value.StringType}
+
+func Testing_mock_backend_Validate(args []value.Value) error {
+	if len(args) < 3 {
+		return errors.ArgumentAtLeast(Testing_mock_backend_Name, 3)
+	}
+
+	for i := range args {
+		expect := value.StringType
+		if i < len(Testing_mock_backend_ArgumentTypes) {
+			expect = Testing_mock_backend_ArgumentTypes[i]
+		}
+		if args[i].Type() != expect {
+			return errors.TypeMismatch(Testing_mock_backend_Name, i+1, expect, args[i].Type())
+		}
+	}
+	return nil
+}
+
+func Testing_mock_backend(
+	ctx *context.Context,
+	i *interpreter.Interpreter,
+	args ...value.Value,
+) (value.Value, error) {
+
+	if err := Testing_mock_backend_Validate(args); err != nil {
+		return nil, errors.NewTestingError(err.Error())
+	}
+
+	backend := value.Unwrap[*value.Backend](args[0])
+	if backend.Value == nil {
+		return value.Null, errors.NewTestingError("%s: director could not be mocked", Testing_mock_backend_Name)
+	}
+	status := value.Unwrap[*value.Integer](args[1]).Value
+	if status < 100 || status > 999 {
+		return value.Null, errors.NewTestingError("%s: invalid status code %d", Testing_mock_backend_Name, status)
+	}
+
+	// Rest arguments are response headers formatted as "Name: value"
+	header := http.Header{}
+	for _, arg := range args[3:] {
+		v := value.Unwrap[*value.String](arg).Value
+		key, val, found := strings.Cut(v, ":")
+		if !found || strings.TrimSpace(key) == "" {
+			return value.Null, errors.NewTestingError(
+				`%s: header must be formatted as "Name: value", %s provided`, Testing_mock_backend_Name, v,
+			)
+		}
+		header.Add(strings.TrimSpace(key), strings.TrimSpace(val))
+	}
+
+	i.MockBackend(backend.Value.Name.Value, &interpreter.MockedBackendResponse{
+		StatusCode: int(status),
+		Header:     header,
+		Body:       value.Unwrap[*value.String](args[2]).Value,
+	})
+	return value.Null, nil
+}
@@ -0,0 +1,68 @@
+package function
+
+import (
+	"testing"
+
+	"github.com/ysugimoto/falco/ast"
+	"github.com/ysugimoto/falco/interpreter"
+	"github.com/ysugimoto/falco/interpreter/value"
+)
+
+func Test_mock_backend(t *testing.T) {
+	origin := &value.Backend{Value: &ast.BackendDeclaration{Name: &ast.Ident{Value: "F_origin"}}}
+
+	tests := []struct {
+		name    string
+		args    []value.Value
+		isError bool
+	}{
+		{
+			name: "mock with headers",
+			args: []value.Value{
+				origin,
+				&value.Integer{Value: 200},
+				&value.String{Value: "OK"},
+				&value.String{Value: "Cache-Control: max-age=60"},
+				&value.String{Value: "Set-Cookie: foo=bar"},
+			},
+		},
+		{
+			name:    "not enough arguments",
+			args:    []value.Value{origin, &value.Integer{Value: 200}},
+			isError: true,
+		},
+		{
+			name:    "first argument is not backend",
+			args:    []value.Value{&value.String{Value: "F_origin"}, &value.Integer{Value: 200}, &value.String{Value: "OK"}},
+			isError: true,
+		},
+		{
+			name:    "invalid status code",
+			args:    []value.Value{origin, &value.Integer{Value: 0}, &value.String{Value: "OK"}},
+			isError: true,
+		},
+		{
+			name: "invalid header format",
+			args: []value.Value{
+				origin,
+				&value.Integer{Value: 200},
+				&value.String{Value: "OK"},
+				&value.String{Value: "Cache-Control"},
+			},
+			isError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		_, err := Testing_mock_backend(nil, interpreter.New(), tt.args...)
+		if tt.isError {
+			if err == nil {
+				t.Errorf("%s: expected error but nil", tt.name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: error should be nil, got: %s", tt.name, err)
+		}
+	}
+}
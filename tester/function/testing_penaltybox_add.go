@@ -0,0 +1,53 @@
+package function
+
+import (
+	"github.com/ysugimoto/falco/interpreter/context"
+	"github.com/ysugimoto/falco/interpreter/function/errors"
+	"github.com/ysugimoto/falco/interpreter/value"
+)
+
+const Testing_penaltybox_add_Name = "testing.penaltybox_add"
+
+var Testing_penaltybox_add_ArgumentTypes = []value.Type{value.IdentType, value.StringType, value.RTimeType}
+
+func Testing_penaltybox_add_Validate(args []value.Value) error {
+	if len(args) != 3 {
+		return errors.ArgumentNotEnough(Testing_penaltybox_add_Name, 3, args)
+	}
+
+	for i := range Testing_penaltybox_add_ArgumentTypes {
+		if args[i].Type() != Testing_penaltybox_add_ArgumentTypes[i] {
+			return errors.TypeMismatch(
+				Testing_penaltybox_add_Name, i+1, Testing_penaltybox_add_ArgumentTypes[i], args[i].Type(),
+			)
+		}
+	}
+	return nil
+}
+
+func Testing_penaltybox_add(
+	ctx *context.Context,
+	args ...value.Value,
+) (value.Value, error) {
+
+	if err := Testing_penaltybox_add_Validate(args); err != nil {
+		return nil, errors.NewTestingError(err.Error())
+	}
+
+	name := value.Unwrap[*value.Ident](args[0]).Value
+	// Check penaltybox existence
+	if _, ok := ctx.Penaltyboxes[name]; !ok {
+		return value.Null, errors.NewTestingError("penaltybox %s not found in VCL", name)
+	}
+
+	// Unlike ratelimit.penaltybox_add, TTL is not rounded to minutes
+	// so that the test can expire the entry at any time
+	entry := value.Unwrap[*value.String](args[1]).Value
+	ttl := value.Unwrap[*value.RTime](args[2]).Value
+	if ttl <= 0 {
+		return value.Null, errors.NewTestingError("TTL must be positive, %s provided", ttl)
+	}
+	ctx.Ratelimit.AddPenaltybox(name, entry, ttl, testingNow(ctx))
+
+	return value.Null, nil
+}
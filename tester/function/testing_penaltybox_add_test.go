@@ -0,0 +1,45 @@
+package function
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ysugimoto/falco/interpreter/value"
+)
+
+func Test_penaltybox_add(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+
+	t.Run("Add entry to penaltybox", func(t *testing.T) {
+		c := newRatelimitContext(&now)
+		_, err := Testing_penaltybox_add(
+			c,
+			&value.Ident{Value: "pb"},
+			&value.String{Value: "client"},
+			&value.RTime{Value: 30 * time.Second},
+		)
+		if err != nil {
+			t.Errorf("Error should be nil, got: %s", err)
+			return
+		}
+		if !c.Ratelimit.InPenaltybox("pb", "client", now.Add(29*time.Second)) {
+			t.Errorf("Entry should be in penaltybox before TTL is expired")
+		}
+		if c.Ratelimit.InPenaltybox("pb", "client", now.Add(30*time.Second)) {
+			t.Errorf("Entry should not be in penaltybox after TTL is expired")
+		}
+	})
+
+	t.Run("Error on penaltybox not found", func(t *testing.T) {
+		c := newRatelimitContext(&now)
+		_, err := Testing_penaltybox_add(
+			c,
+			&value.Ident{Value: "unknown"},
+			&value.String{Value: "client"},
+			&value.RTime{Value: time.Minute},
+		)
+		if err == nil {
+			t.Errorf("Should return error if penaltybox not found, got nil")
+		}
+	})
+}
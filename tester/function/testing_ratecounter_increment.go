@@ -0,0 +1,60 @@
+package function
+
+import (
+	"time"
+
+	"github.com/ysugimoto/falco/interpreter/context"
+	"github.com/ysugimoto/falco/interpreter/function/errors"
+	"github.com/ysugimoto/falco/interpreter/value"
+)
+
+const Testing_ratecounter_increment_Name = "testing.ratecounter_increment"
+
+var Testing_ratecounter_increment_ArgumentTypes = []value.Type{value.IdentType, value.StringType, value.IntegerType}
+
+func Testing_ratecounter_increment_Validate(args []value.Value) error {
+	if len(args) != 3 {
+		return errors.ArgumentNotEnough(Testing_ratecounter_increment_Name, 3, args)
+	}
+
+	for i := range Testing_ratecounter_increment_ArgumentTypes {
+		if args[i].Type() != Testing_ratecounter_increment_ArgumentTypes[i] {
+			return errors.TypeMismatch(
+				Testing_ratecounter_increment_Name, i+1, Testing_ratecounter_increment_ArgumentTypes[i], args[i].Type(),
+			)
+		}
+	}
+	return nil
+}
+
+func Testing_ratecounter_increment(
+	ctx *context.Context,
+	args ...value.Value,
+) (value.Value, error) {
+
+	if err := Testing_ratecounter_increment_Validate(args); err != nil {
+		return nil, errors.NewTestingError(err.Error())
+	}
+
+	name := value.Unwrap[*value.Ident](args[0]).Value
+	// Check ratecounter existence
+	if _, ok := ctx.Ratecounters[name]; !ok {
+		return value.Null, errors.NewTestingError("ratecounter %s not found in VCL", name)
+	}
+
+	entry := value.Unwrap[*value.String](args[1]).Value
+	n := value.Unwrap[*value.Integer](args[2]).Value
+	ctx.Ratelimit.Increment(name, entry, n, testingNow(ctx))
+	ctx.RatecounterEntries[name] = entry
+
+	return value.Null, nil
+}
+
+// Rate limiting state is stored with the fixed time if it is set,
+// as same as ratelimit.* builtin functions
+func testingNow(ctx *context.Context) time.Time {
+	if ctx.FixedTime != nil {
+		return *ctx.FixedTime
+	}
+	return time.Now()
+}
@@ -0,0 +1,62 @@
+package function
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ysugimoto/falco/ast"
+	"github.com/ysugimoto/falco/interpreter/context"
+	"github.com/ysugimoto/falco/interpreter/ratelimit"
+	"github.com/ysugimoto/falco/interpreter/value"
+)
+
+func newRatelimitContext(now *time.Time) *context.Context {
+	return &context.Context{
+		Ratecounters: map[string]*ast.RatecounterDeclaration{
+			"rc": {Name: &ast.Ident{Value: "rc"}},
+		},
+		Penaltyboxes: map[string]*ast.PenaltyboxDeclaration{
+			"pb": {Name: &ast.Ident{Value: "pb"}},
+		},
+		Ratelimit:          ratelimit.New(),
+		RatecounterEntries: make(map[string]string),
+		FixedTime:          now,
+	}
+}
+
+func Test_ratecounter_increment(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+
+	t.Run("Increment ratecounter entry", func(t *testing.T) {
+		c := newRatelimitContext(&now)
+		_, err := Testing_ratecounter_increment(
+			c,
+			&value.Ident{Value: "rc"},
+			&value.String{Value: "client"},
+			&value.Integer{Value: 10},
+		)
+		if err != nil {
+			t.Errorf("Error should be nil, got: %s", err)
+			return
+		}
+		if count := c.Ratelimit.Count("rc", "client", 10*time.Second, now); count != 10 {
+			t.Errorf("Ratecounter count should be 10, got: %d", count)
+		}
+		if entry := c.RatecounterEntries["rc"]; entry != "client" {
+			t.Errorf("Last incremented entry should be client, got: %s", entry)
+		}
+	})
+
+	t.Run("Error on ratecounter not found", func(t *testing.T) {
+		c := newRatelimitContext(&now)
+		_, err := Testing_ratecounter_increment(
+			c,
+			&value.Ident{Value: "unknown"},
+			&value.String{Value: "client"},
+			&value.Integer{Value: 10},
+		)
+		if err == nil {
+			t.Errorf("Should return error if ratecounter not found, got nil")
+		}
+	})
+}
@@ -0,0 +1,33 @@
+package function
+
+import (
+	"github.com/ysugimoto/falco/interpreter"
+	"github.com/ysugimoto/falco/interpreter/context"
+	"github.com/ysugimoto/falco/interpreter/function/errors"
+	"github.com/ysugimoto/falco/interpreter/value"
+)
+
+const Testing_run_request_Name = "testing.run_request"
+
+func Testing_run_request_Validate(args []value.Value) error {
+	if len(args) > 0 {
+		return errors.ArgumentMustEmpty(Testing_run_request_Name, args)
+	}
+	return nil
+}
+
+func Testing_run_request(
+	ctx *context.Context,
+	i *interpreter.Interpreter,
+	args ...value.Value,
+) (value.Value, error) {
+
+	if err := Testing_run_request_Validate(args); err != nil {
+		return nil, errors.NewTestingError(err.Error())
+	}
+
+	if err := i.TestProcessRequest(); err != nil {
+		return value.Null, errors.NewTestingError(err.Error())
+	}
+	return value.Null, nil
+}
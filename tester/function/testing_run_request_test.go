@@ -0,0 +1,91 @@
+package function
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ysugimoto/falco/ast"
+	"github.com/ysugimoto/falco/interpreter"
+	"github.com/ysugimoto/falco/interpreter/context"
+	"github.com/ysugimoto/falco/interpreter/value"
+	"github.com/ysugimoto/falco/resolver"
+)
+
+func newLifecycleInterpreter(t *testing.T) *interpreter.Interpreter {
+	vcl := `
+backend F_origin {
+  .host = "example.com";
+}
+
+sub vcl_recv {
+  set req.backend = F_origin;
+  return(lookup);
+}
+`
+	i := interpreter.New(context.WithResolver(resolver.NewStaticResolver("main", vcl)))
+	if err := i.TestProcessInit(httptest.NewRequest("GET", "http://localhost", nil)); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	return i
+}
+
+func Test_run_request(t *testing.T) {
+	origin := &value.Backend{Value: &ast.BackendDeclaration{Name: &ast.Ident{Value: "F_origin"}}}
+
+	t.Run("Mocked backend response is delivered", func(t *testing.T) {
+		i := newLifecycleInterpreter(t)
+		_, err := Testing_mock_backend(
+			nil, i, origin,
+			&value.Integer{Value: 404},
+			&value.String{Value: "Not Found"},
+			&value.String{Value: "X-Mocked: 1"},
+		)
+		if err != nil {
+			t.Errorf("Error should be nil, got: %s", err)
+			return
+		}
+		if _, err := Testing_run_request(nil, i); err != nil {
+			t.Errorf("Error should be nil, got: %s", err)
+			return
+		}
+		status, err := i.IdentValue("resp.status", false)
+		if err != nil {
+			t.Errorf("Error should be nil, got: %s", err)
+			return
+		}
+		if v := value.Unwrap[*value.Integer](status).Value; v != 404 {
+			t.Errorf("Response status should be 404, got: %d", v)
+		}
+		header, err := i.IdentValue("resp.http.X-Mocked", false)
+		if err != nil {
+			t.Errorf("Error should be nil, got: %s", err)
+			return
+		}
+		if v := value.Unwrap[*value.String](header).Value; v != "1" {
+			t.Errorf("Response header should be mocked, got: %s", v)
+		}
+	})
+
+	t.Run("Backend is unavailable if not mocked", func(t *testing.T) {
+		i := newLifecycleInterpreter(t)
+		if _, err := Testing_run_request(nil, i); err != nil {
+			t.Errorf("Error should be nil, got: %s", err)
+			return
+		}
+		status, err := i.IdentValue("resp.status", false)
+		if err != nil {
+			t.Errorf("Error should be nil, got: %s", err)
+			return
+		}
+		if v := value.Unwrap[*value.Integer](status).Value; v != 503 {
+			t.Errorf("Response status should be 503, got: %d", v)
+		}
+	})
+
+	t.Run("Arguments must be empty", func(t *testing.T) {
+		i := newLifecycleInterpreter(t)
+		if _, err := Testing_run_request(nil, i, &value.String{Value: "/"}); err == nil {
+			t.Errorf("Expected error but nil")
+		}
+	})
+}
@@ -0,0 +1,107 @@
+package tester
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/ysugimoto/falco/ast"
+	"github.com/ysugimoto/falco/interpreter"
+	icontext "github.com/ysugimoto/falco/interpreter/context"
+)
+
+type HookType string
+
+const (
+	BeforeAll  HookType = "before_all"
+	BeforeEach HookType = "before_each"
+	AfterEach  HookType = "after_each"
+	AfterAll   HookType = "after_all"
+)
+
+// Hook subroutines which are declared in the testing file, keyed by hook type in declared order
+type Hooks map[HookType][]*ast.SubroutineDeclaration
+
+// HookError indicates the test case is failed in the hook subroutine, not in the test subroutine itself
+type HookError struct {
+	Type HookType
+	Name string
+	Err  error
+}
+
+func (e *HookError) Error() string {
+	return fmt.Sprintf("%s hook %s is failed: %s", e.Type, e.Name, e.Err.Error())
+}
+
+func (e *HookError) Unwrap() error {
+	return e.Err
+}
+
+// Find hook type from annotation like "@before_each"
+func findHookType(sub *ast.SubroutineDeclaration) (HookType, bool) {
+	comments := sub.GetMeta().Leading
+	for i := range comments {
+		l := strings.TrimSpace(strings.TrimLeft(comments[i].Value, " */#"))
+		switch hook := HookType(strings.TrimPrefix(l, "@")); hook {
+		case BeforeAll, BeforeEach, AfterEach, AfterAll:
+			if strings.HasPrefix(l, "@") {
+				return hook, true
+			}
+		}
+	}
+	return "", false
+}
+
+// Collect hook subroutines in the testing file
+func (t *Tester) findHooks(vcl *ast.VCL) Hooks {
+	hooks := Hooks{}
+	for _, stmt := range vcl.Statements {
+		sub, ok := stmt.(*ast.SubroutineDeclaration)
+		if !ok {
+			continue
+		}
+		if hook, ok := findHookType(sub); ok {
+			hooks[hook] = append(hooks[hook], sub)
+		}
+	}
+	return hooks
+}
+
+// Run hook subroutines of the type on the interpreter in the scope of the test case.
+// Stop at the first failure and return it as HookError.
+func (t *Tester) runHooks(
+	i *interpreter.Interpreter,
+	hooks Hooks,
+	hook HookType,
+	scope icontext.Scope,
+) error {
+	for _, sub := range hooks[hook] {
+		if err := i.ProcessTestSubroutine(scope, sub); err != nil {
+			return &HookError{
+				Type: hook,
+				Name: sub.Name.Value,
+				Err:  errors.Cause(err),
+			}
+		}
+	}
+	return nil
+}
+
+// Run a test case surrounded by before_each and after_each hooks.
+// after_each hooks are always run even if the test case is failed,
+// and the first error is returned.
+func (t *Tester) runTestCase(
+	i *interpreter.Interpreter,
+	hooks Hooks,
+	scope icontext.Scope,
+	sub *ast.SubroutineDeclaration,
+) error {
+	err := t.runHooks(i, hooks, BeforeEach, scope)
+	if err == nil {
+		err = errors.Cause(i.ProcessTestSubroutine(scope, sub))
+	}
+	if hookErr := t.runHooks(i, hooks, AfterEach, scope); err == nil {
+		err = hookErr
+	}
+	return err
+}
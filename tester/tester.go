@@ -2,8 +2,10 @@ package tester
 
 import (
 	"context"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"os"
 	"path/filepath"
 	"strings"
 	"sync/atomic"
@@ -14,6 +16,7 @@ import (
 	"github.com/ysugimoto/falco/config"
 	"github.com/ysugimoto/falco/interpreter"
 	icontext "github.com/ysugimoto/falco/interpreter/context"
+	"github.com/ysugimoto/falco/interpreter/coverage"
 	"github.com/ysugimoto/falco/interpreter/function"
 	"github.com/ysugimoto/falco/interpreter/value"
 	"github.com/ysugimoto/falco/interpreter/variable"
@@ -34,15 +37,20 @@ type Tester struct {
 	config             *config.TestConfig
 	counter            *TestCounter
 	debugger           *Debugger
+	coverage           *coverage.Coverage
 }
 
 func New(c *config.TestConfig, opts []icontext.Option) *Tester {
-	return &Tester{
+	t := &Tester{
 		interpreterOptions: opts,
 		config:             c,
 		counter:            NewTestCounter(),
 		debugger:           NewDebugger(),
 	}
+	if c.Coverage {
+		t.coverage = coverage.New()
+	}
+	return t
 }
 
 // Find test target VCL files
@@ -83,11 +91,20 @@ func (t *Tester) Run(main string) (*TestFactory, error) {
 		results = append(results, result)
 	}
 
-	return &TestFactory{
+	factory := &TestFactory{
 		Results:    results,
 		Statistics: t.counter,
 		Logs:       t.debugger.stack,
-	}, nil
+	}
+	if t.coverage != nil {
+		// Report VCL files as relative path from working directory
+		cwd, err := os.Getwd()
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		factory.Coverage = t.coverage.Report(cwd)
+	}
+	return factory, nil
 }
 
 // Actually run testing method
@@ -124,29 +141,70 @@ func (t *Tester) run(testFile string) (*TestResult, error) {
 	go func(vcl *ast.VCL) {
 		// Factory definitions in the test file
 		defs := t.factoryDefinitions(vcl)
-		var cases []*TestCase
+		hooks := t.findHooks(vcl)
+
+		var suites []*testSuite
 		for _, stmt := range vcl.Statements {
-			// We treat subroutine as testing
+			// We treat subroutine as testing except hook subroutines
 			sub, ok := stmt.(*ast.SubroutineDeclaration)
 			if !ok {
 				continue
 			}
+			if _, ok := findHookType(sub); ok {
+				continue
+			}
+			s, err := t.findTestSuites(sub, defs)
+			if err != nil {
+				errChan <- errors.WithStack(err)
+				return
+			}
+			suites = append(suites, s...)
+		}
 
-			// Some functions like "testing.table_set()" will take side-effect for another testing subroutine
-			// so we always initialize interpreter, inject testing functions for each subroutine
+		newInterpreter := func() (*interpreter.Interpreter, error) {
 			i := t.setupInterpreter(defs)
-
 			if err := i.TestProcessInit(mockRequest.Clone(ctx)); err != nil {
-				errChan <- errors.WithStack(err)
+				return nil, errors.WithStack(err)
+			}
+			return i, nil
+		}
+
+		// before_all and after_all hooks run once around all test cases in the testing file,
+		// so test cases share the interpreter to take over the state which is set up in before_all hooks
+		var shared *interpreter.Interpreter
+		var beforeAllErr error
+		if len(suites) > 0 && (len(hooks[BeforeAll]) > 0 || len(hooks[AfterAll]) > 0) {
+			i, err := newInterpreter()
+			if err != nil {
+				errChan <- err
 				return
 			}
-			suite, scopes := t.findTestSuites(sub)
-			for _, s := range scopes {
+			shared = i
+			beforeAllErr = t.runHooks(shared, hooks, BeforeAll, icontext.RecvScope)
+		}
+
+		var cases []*TestCase
+		for _, suite := range suites {
+			i := shared
+			if i == nil {
+				// Some functions like "testing.table_set()" will take side-effect for another testing subroutine
+				// so we always initialize interpreter, inject testing functions for each subroutine
+				var err error
+				if i, err = newInterpreter(); err != nil {
+					errChan <- err
+					return
+				}
+			}
+
+			for _, s := range suite.scopes {
 				start := time.Now()
-				err := i.ProcessTestSubroutine(s, sub)
+				err := beforeAllErr
+				if err == nil {
+					err = t.runTestCase(i, hooks, s, suite.subroutine)
+				}
 				cases = append(cases, &TestCase{
-					Name:  suite,
-					Error: errors.Cause(err),
+					Name:  suite.name,
+					Error: err,
 					Scope: s.String(),
 					Time:  time.Since(start).Milliseconds(),
 				})
@@ -155,6 +213,15 @@ func (t *Tester) run(testFile string) (*TestResult, error) {
 				}
 			}
 		}
+
+		// Failure of after_all hooks is reported on the last test case
+		if shared != nil && beforeAllErr == nil && len(cases) > 0 {
+			last := cases[len(cases)-1]
+			if err := t.runHooks(shared, hooks, AfterAll, icontext.RecvScope); err != nil && last.Error == nil {
+				last.Error = err
+				t.counter.Fail()
+			}
+		}
 		finishChan <- cases
 	}(vcl)
 
@@ -173,8 +240,39 @@ func (t *Tester) run(testFile string) (*TestResult, error) {
 	}
 }
 
+// testSuite is a test case which runs the subroutine in each scope
+type testSuite struct {
+	name       string
+	scopes     []icontext.Scope
+	subroutine *ast.SubroutineDeclaration
+}
+
+// Find test suites from the test subroutine.
+// The subroutine is expanded into multiple suites for each row of "@each" annotation,
+// and row values are appended to the suite name.
+func (t *Tester) findTestSuites(sub *ast.SubroutineDeclaration, defs *tf.Definiions) ([]*testSuite, error) {
+	name, scopes := t.findTestScopes(sub)
+	rows, err := findEachRows(sub, defs)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if len(rows) == 0 {
+		return []*testSuite{{name: name, scopes: scopes, subroutine: sub}}, nil
+	}
+
+	suites := make([]*testSuite, 0, len(rows))
+	for _, row := range rows {
+		suites = append(suites, &testSuite{
+			name:       fmt.Sprintf("%s [%s]", name, row.name),
+			scopes:     scopes,
+			subroutine: row.subroutine(sub),
+		})
+	}
+	return suites, nil
+}
+
 // Find test suite name and may multile scopes
-func (t *Tester) findTestSuites(sub *ast.SubroutineDeclaration) (string, []icontext.Scope) {
+func (t *Tester) findTestScopes(sub *ast.SubroutineDeclaration) (string, []icontext.Scope) {
 	// Find test suite name and scope from annotation
 	suiteName := sub.Name.Value
 
@@ -190,6 +288,10 @@ func (t *Tester) findTestSuites(sub *ast.SubroutineDeclaration) (string, []icont
 			suiteName = strings.TrimSpace(strings.TrimPrefix(l, "@suite:"))
 			continue
 		}
+		// @each annotation is parsed as table-driven test rows
+		if strings.HasPrefix(l, "@each:") {
+			continue
+		}
 		var an []string
 		if strings.HasPrefix(l, "@scope:") {
 			an = strings.Split(strings.TrimPrefix(l, "@scope:"), ",")
@@ -235,6 +337,7 @@ func (t *Tester) findTestSuites(sub *ast.SubroutineDeclaration) (string, []icont
 func (t *Tester) setupInterpreter(defs *tf.Definiions) *interpreter.Interpreter {
 	i := interpreter.New(t.interpreterOptions...)
 	i.Debugger = t.debugger
+	i.Coverage = t.coverage
 	i.IdentResolver = func(val string) value.Value {
 		if v, ok := defs.Backends[val]; ok {
 			return v
@@ -11,7 +11,8 @@ import (
 
 // Dedicated for testing variables
 const (
-	TESTING_STATE = "testing.state"
+	TESTING_STATE               = "testing.state"
+	TESTING_VISITED_SUBROUTINES = "testing.visited_subroutines"
 )
 
 type TestingVariables struct {
@@ -19,9 +20,11 @@ type TestingVariables struct {
 }
 
 func (v *TestingVariables) Get(ctx *context.Context, scope context.Scope, name string) (value.Value, error) {
-	switch name { // nolint:gocritic
+	switch name {
 	case TESTING_STATE:
 		return &value.String{Value: strings.ToUpper(ctx.ReturnState.Value)}, nil
+	case TESTING_VISITED_SUBROUTINES:
+		return &value.String{Value: strings.Join(ctx.VisitedSubroutines, ",")}, nil
 	}
 
 	return nil, fmt.Errorf("Not Found")